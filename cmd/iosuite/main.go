@@ -17,23 +17,37 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"iosuite.io/internal/account"
+	"iosuite.io/internal/auth"
 	"iosuite.io/internal/benchmark"
 	"iosuite.io/internal/config"
 	"iosuite.io/internal/doctor"
 	"iosuite.io/internal/endpoint"
+	"iosuite.io/internal/fleet"
+	"iosuite.io/internal/history"
 	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/output"
 	"iosuite.io/internal/registry"
+	"iosuite.io/internal/retry"
+	"iosuite.io/internal/runpod"
 	"iosuite.io/internal/runtime"
 	"iosuite.io/internal/serve"
 	"iosuite.io/internal/transform"
@@ -43,11 +57,22 @@ import (
 
 func main() {
 	if err := run(); err != nil {
+		var code exitCode
+		if errors.As(err, &code) {
+			os.Exit(int(code))
+		}
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
 }
 
+// exitCode ends the process with a specific status and no error
+// message, for commands whose status is their result (`endpoint
+// diff`: 2 = changes pending).
+type exitCode int
+
+func (c exitCode) Error() string { return fmt.Sprintf("exit status %d", int(c)) }
+
 // usage is the top-level --help. Subcommand-specific help is emitted
 // by each subcommand's own flag.FlagSet.
 const usage = `iosuite — image / media processing CLI
@@ -74,11 +99,18 @@ Tools (mime-aware; the right model is picked from the input):
 
 Infrastructure:
   serve             Long-lived HTTP daemon (warm engine; what iosuite.io talks to)
-  endpoint          Manage remote provider endpoints (deploy / list / destroy / benchmark)
+  account           RunPod balance, spend rate and GPU availability
+  endpoint          Manage remote provider endpoints (deploy / diff / apply / list / status / destroy / prune / benchmark)
+  auth              Store the RunPod API key outside config.toml (login / logout / status)
+  config            Get / set / validate settings and list profiles
   doctor            Diagnose this host: PATH, Python, GPU, auth keys
   fetch-model       Download a verified model artefact (forwarded to real-esrgan-serve)
   version           Print version + commit
 
+Global flags:
+  --profile NAME    Use the [profile.NAME] account from the config file
+                    (default: $IOSUITE_PROFILE). Accepted anywhere on the line.
+
 Run 'iosuite <command> --help' for the full flag surface of any command.
 Config: ~/.config/iosuite/config.toml (see ARCHITECTURE.md).`
 
@@ -88,7 +120,16 @@ func run() error {
 		return nil
 	}
 
-	cmd, args := os.Args[1], os.Args[2:]
+	name, argv, err := extractProfile(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	profileFlag = name
+	if len(argv) == 0 {
+		fmt.Println(usage)
+		return nil
+	}
+	cmd, args := argv[0], argv[1:]
 
 	switch cmd {
 	case "-h", "--help", "help":
@@ -132,12 +173,36 @@ func run() error {
 		return cmdTransform(args)
 
 	case "serve":
+		if err := configureRunpod(); err != nil {
+			return err
+		}
 		return cmdServe(args)
 
 	case "endpoint":
+		if err := configureRunpod(); err != nil {
+			return err
+		}
 		return cmdEndpoint(args)
 
+	case "account":
+		if err := configureRunpod(); err != nil {
+			return err
+		}
+		return cmdAccount(args)
+
+	case "auth":
+		if err := configureRunpod(); err != nil {
+			return err
+		}
+		return cmdAuth(args)
+
+	case "config":
+		return cmdConfig(args)
+
 	case "doctor":
+		if err := configureRunpod(); err != nil {
+			return err
+		}
 		return cmdDoctor(args)
 
 	case "fetch-model":
@@ -185,7 +250,7 @@ Flags:`)
 	if in == "" {
 		in = pos
 	}
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
@@ -256,7 +321,7 @@ warnings but don't affect the exit code.`)
 	}
 	_ = fs.Parse(args)
 
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
@@ -283,10 +348,28 @@ func cmdServe(args []string) error {
 		runpodAPIKey = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
 		// PollMax — how long the daemon waits on a single RunPod job
 		// before giving up. 10m default; bump for slow / cold-prone
-		// endpoints. Falls back to IOSUITE_POLL_MAX env when the flag
-		// isn't passed (matches RUNPOD_API_KEY's resolution pattern).
-		pollMax = fs.Duration("poll-max", 0, "Max wait per upstream job, e.g. 10m (default 10m, env IOSUITE_POLL_MAX)")
+		// endpoints.
+		pollMax = fs.Duration("poll-max", 0, "Max wait per upstream job, e.g. 10m (default 10m)")
+		// Adaptive /status polling: starts at --poll-initial and
+		// backs off exponentially (with jitter) up to
+		// --poll-interval-max. Zero = provider defaults.
+		pollInitial     = fs.Duration("poll-initial", 0, "First wait between upstream /status polls (default 250ms)")
+		pollIntervalMax = fs.Duration("poll-interval-max", 0, "Cap on the backoff between /status polls (default 15s)")
+		// Transient /runsync failures (connection refused, or reset
+		// before the request was sent; 502/503/504, 429) are retried;
+		// 4xx never are. See internal/retry.
+		retryAttempts    = fs.Int("retry-max-attempts", 0, "Max tries per upstream /runsync POST, including the first (default 4)")
+		retryBudget      = fs.Duration("retry-budget", 0, "Total time allowed across /runsync retries, e.g. 2m (default: no cap)")
+		breakerThreshold = fs.Int("breaker-threshold", 0, "Consecutive upstream failures that open an endpoint's circuit breaker (default 5)")
+		breakerCooldown  = fs.Duration("breaker-cooldown", 0, "How long an open breaker fails requests fast before trying one, e.g. 1m (default 30s)")
+		// stream = /run + /stream, forwarding outputs to the client as
+		// the worker yields them instead of buffering the whole job.
+		runpodMode = fs.String("runpod-mode", serve.ModeSync, "Upstream job API: sync (/runsync) | stream (/run + /stream)")
+		// --route MODEL=ENDPOINT, repeatable. Merged over
+		// [runpod.routes] in config; ENDPOINT may be `name:<name>`.
+		routes = kvFlag{}
 	)
+	fs.Var(routes, "route", "Route a model/tool to an endpoint: MODEL=ENDPOINT_ID or MODEL=name:ENDPOINT_NAME (repeatable)")
 	fs.Usage = func() {
 		fmt.Fprintln(fs.Output(), `Usage: iosuite serve [flags]
 
@@ -298,6 +381,11 @@ Endpoints:
   POST /runsync     application/json envelope ({"input": ...}); /upscale alias
   GET  /health      {"status":"ok"} when the backend is reachable
 
+Flags not given fall back to $IOSUITE_SERVE_<FLAG> (e.g.
+IOSUITE_SERVE_BIND, IOSUITE_SERVE_POLL_MAX), then the config file's
+[serve] section; --provider, --model, --endpoint-id and the API key
+to their usual settings. See `+"`iosuite config list --env`"+`.
+
 Flags:`)
 		fs.PrintDefaults()
 	}
@@ -305,10 +393,13 @@ Flags:`)
 		return err
 	}
 
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	if err := settingDefaults(fs, cfg, serveFlagSettings); err != nil {
+		return err
+	}
 
 	prov := *provider
 	if prov == "" {
@@ -340,30 +431,44 @@ Flags:`)
 		if eid == "" {
 			eid = cfg.RunpodEndpointID
 		}
-		if eid == "" {
-			eid = os.Getenv("RUNPOD_ENDPOINT_ID")
+		table := map[string]string{}
+		for k, v := range cfg.RunpodRoutes {
+			table[k] = v
 		}
-		if eid == "" {
-			return fmt.Errorf("runpod provider requires --endpoint-id (or RUNPOD_ENDPOINT_ID env, or [runpod] endpoint_id in config)")
+		for k, v := range routes {
+			table[k] = v
+		}
+		if eid == "" && len(table) == 0 {
+			return fmt.Errorf("runpod provider requires --endpoint-id or --route (or RUNPOD_ENDPOINT_ID env, or [runpod] endpoint_id / [runpod.routes] in config)")
 		}
 		key := resolveRunpodAPIKey(*runpodAPIKey, cfg)
 		if key == "" {
-			return fmt.Errorf("runpod provider requires API key (--runpod-api-key, RUNPOD_API_KEY env, or [runpod] api_key in config)")
-		}
-		pm := *pollMax
-		if pm == 0 {
-			if env := os.Getenv("IOSUITE_POLL_MAX"); env != "" {
-				parsed, err := time.ParseDuration(env)
-				if err != nil {
-					return fmt.Errorf("invalid IOSUITE_POLL_MAX %q: %w", env, err)
-				}
-				pm = parsed
-			}
+			return endpoint.ErrNoAPIKey
 		}
+		client := runpod.NewClient(key, fmt.Sprintf("iosuite/%s", version.Version))
 		rp := serve.NewRunPod(serve.RunPodProviderOptions{
 			EndpointID: eid,
 			APIKey:     key,
-			PollMax:    pm,
+			Mode:       *runpodMode,
+			Routes:     table,
+			ResolveName: func(ctx context.Context, name string) (string, error) {
+				ep, err := client.FindEndpoint(ctx, name)
+				if err != nil {
+					return "", err
+				}
+				if ep == nil {
+					return "", fmt.Errorf("no endpoint named %q (see `iosuite endpoint list`)", name)
+				}
+				return ep.ID, nil
+			},
+			PollMax:         *pollMax,
+			PollInitial:     *pollInitial,
+			PollMaxInterval: *pollIntervalMax,
+			Retry: retry.Policy{
+				MaxAttempts: *retryAttempts,
+				Budget:      *retryBudget,
+				Breaker:     retry.NewBreaker(*breakerThreshold, *breakerCooldown),
+			},
 		})
 		return serve.Run(context.Background(), serve.Options{
 			Bind:     *bind,
@@ -375,6 +480,118 @@ Flags:`)
 	}
 }
 
+// serveFlagSettings are the `iosuite serve` flags a [serve] setting
+// (or its IOSUITE_SERVE_* variable) supplies when not passed.
+var serveFlagSettings = map[string]string{
+	"bind":               "serve.bind",
+	"port":               "serve.port",
+	"gpu-id":             "serve.gpu_id",
+	"runpod-mode":        "serve.mode",
+	"poll-max":           "serve.poll_max",
+	"poll-initial":       "serve.poll_initial",
+	"poll-interval-max":  "serve.poll_interval_max",
+	"retry-max-attempts": "serve.retry_max_attempts",
+	"retry-budget":       "serve.retry_budget",
+	"breaker-threshold":  "serve.breaker_threshold",
+	"breaker-cooldown":   "serve.breaker_cooldown",
+}
+
+// settingDefaults sets each flag in settings that wasn't passed from
+// its setting, parsed as the flag would parse it: flag > env > config
+// file > the flag's own default.
+func settingDefaults(fs *flag.FlagSet, cfg config.Config, settings map[string]string) error {
+	passed := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+	for name, key := range settings {
+		if passed[name] {
+			continue
+		}
+		s, err := cfg.Lookup(key)
+		if err != nil {
+			return err
+		}
+		if s.Value == "" {
+			continue
+		}
+		if err := fs.Set(name, s.Value); err != nil {
+			return fmt.Errorf("invalid %s %q (%s): %v", key, s.Value, originLabel(s), err)
+		}
+	}
+	return nil
+}
+
+// cmdAccount shows the RunPod account's balance and spend, and with
+// --tool whether the tool's GPU pools have capacity. --min-balance
+// turns it into a pre-flight check for scripts.
+func cmdAccount(args []string) error {
+	fs := flag.NewFlagSet("account", flag.ExitOnError)
+	var (
+		apiKey          = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+		tool            = fs.String("tool", "", "Also show GPU availability for this tool's gpu_pools (e.g. real-esrgan)")
+		manifestVersion = fs.String("version", "", "Git tag of the *-serve repo to read --tool's manifest from (default: registry's stable version)")
+		manifestPath    = fs.String("manifest", "", "Read the deploy manifest from a local file instead of fetching --tool's")
+		minBalance      = fs.Float64("min-balance", 0, "Exit non-zero when the credit balance (USD) is below this")
+	)
+	out := output.Register(fs)
+	fs.BoolFunc("json", "Shorthand for --output json", func(string) error {
+		out.Format = output.JSON
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite account [flags]
+
+Show the RunPod credit balance, current spend per hour (overall and
+per endpoint), and — with --tool or --manifest — stock for each GPU
+pool the tool deploys to.
+
+  iosuite account --tool real-esrgan
+  iosuite account --min-balance 25 --json   # exit 1 below $25
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if *minBalance < 0 {
+		return fmt.Errorf("--min-balance must not be negative")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	in := account.Input{
+		APIKey:    resolveRunpodAPIKey(*apiKey, cfg),
+		UserAgent: fmt.Sprintf("iosuite/%s", version.Version),
+		Tool:      *tool,
+	}
+	if *tool != "" || *manifestPath != "" {
+		man, src, err := resolveDeployManifest(ctx, *tool, *manifestVersion, *manifestPath)
+		if err != nil {
+			return fmt.Errorf("load manifest: %w", err)
+		}
+		if in.Tool == "" {
+			in.Tool = src
+		}
+		in.Pools = man.GPUPools
+	}
+	rep, err := account.Get(ctx, in)
+	if err != nil {
+		return err
+	}
+	if err := output.Render(os.Stdout, *out, rep, func(w io.Writer) { account.Print(w, rep) }); err != nil {
+		return err
+	}
+	if rep.BalanceUSD < *minBalance {
+		return fmt.Errorf("balance $%.2f is below --min-balance $%.2f", rep.BalanceUSD, *minBalance)
+	}
+	return nil
+}
+
 // cmdEndpoint dispatches `iosuite endpoint <subcommand>`. Sub-subs
 // (deploy / list / destroy / benchmark) parse their own flag sets.
 func cmdEndpoint(args []string) error {
@@ -383,8 +600,16 @@ func cmdEndpoint(args []string) error {
 
 Subcommands:
   deploy     Create or update a serverless endpoint on a provider
+  diff       Show what deploy would change (exit 2 = changes pending)
+  apply      Converge the account to a fleet file (many endpoints)
   list       List existing endpoints
-  destroy    Delete an endpoint
+  destroy    Delete an endpoint (and its <name>-tmpl template)
+  prune      Delete unused iosuite templates and long-idle endpoints
+  status     Show an endpoint's config plus live worker / queue counts
+  volumes    List network volumes deploy can mount (--network-volume)
+  gpus       List a tool's GPU classes with RunPod pool, stock and price
+  history    List an endpoint's recorded deploy revisions
+  rollback   Re-apply an earlier revision from the deploy history
   benchmark  Run the tool's published benchmark suite against an endpoint
 
 Each subcommand accepts --provider runpod (the only supported provider
@@ -396,10 +621,26 @@ $RUNPOD_API_KEY env, or [runpod] api_key in ~/.config/iosuite/config.toml.`)
 	switch sub {
 	case "deploy":
 		return cmdEndpointDeploy(rest)
+	case "apply":
+		return cmdEndpointApply(rest)
+	case "diff":
+		return cmdEndpointDiff(rest)
 	case "list":
 		return cmdEndpointList(rest)
 	case "destroy":
 		return cmdEndpointDestroy(rest)
+	case "prune":
+		return cmdEndpointPrune(rest)
+	case "status":
+		return cmdEndpointStatus(rest)
+	case "volumes":
+		return cmdEndpointVolumes(rest)
+	case "gpus":
+		return cmdEndpointGpus(rest)
+	case "history":
+		return cmdEndpointHistory(rest)
+	case "rollback":
+		return cmdEndpointRollback(rest)
 	case "benchmark":
 		return cmdEndpointBenchmark(rest)
 	case "-h", "--help", "help":
@@ -409,8 +650,18 @@ $RUNPOD_API_KEY env, or [runpod] api_key in ~/.config/iosuite/config.toml.`)
 	}
 }
 
-func cmdEndpointDeploy(args []string) error {
-	fs := flag.NewFlagSet("endpoint deploy", flag.ExitOnError)
+func cmdEndpointDeploy(args []string) error { return runEndpointDeploy("deploy", args) }
+
+// cmdEndpointDiff is `endpoint deploy --dry-run` under its own name.
+func cmdEndpointDiff(args []string) error { return runEndpointDeploy("diff", args) }
+
+// runEndpointDeploy backs both deploy and diff: same flags, same
+// desired state. With --dry-run (always, for diff) it prints the
+// field-level plan instead of saving, and exits 2 when the plan has
+// changes — 0 means the live endpoint already matches — so CI can
+// gate on it.
+func runEndpointDeploy(sub string, args []string) error {
+	fs := flag.NewFlagSet("endpoint "+sub, flag.ExitOnError)
 	var (
 		provider    = fs.String("provider", "runpod", "Provider (runpod is the only supported value today)")
 		tool        = fs.String("tool", "real-esrgan", "Tool to deploy (real-esrgan)")
@@ -419,6 +670,22 @@ func cmdEndpointDeploy(args []string) error {
 		apiKey      = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
 		workersMax  = fs.Int("workers-max", 0, "Max concurrent workers (0 = tool default)")
 		idleTimeout = fs.Int("idle-timeout", 0, "Worker idle timeout in seconds (0 = tool default)")
+		// Scaling. --workers-min is tri-state like --flashboot: an
+		// explicit 0 overrides a manifest that keeps a worker warm.
+		workersMin  = fs.Int("workers-min", 0, "Workers kept warm (default: tool's manifest, else 0)")
+		scalerType  = fs.String("scaler-type", "", "Autoscaler: QUEUE_DELAY or REQUEST_COUNT (default: tool's manifest, else QUEUE_DELAY)")
+		scalerValue = fs.Int("scaler-value", 0, "Autoscaler target: seconds of queue delay, or jobs per worker (0 = tool default, else 4)")
+		execTimeout = fs.Int("execution-timeout", 0, "Fail jobs that run longer than this many seconds (0 = tool default, else RunPod's)")
+		// Account resources, by name. Resolved to ids at save time.
+		registryAuth  = fs.String("registry-auth", "", "Name of the RunPod registry credential to pull a private image with (default: tool's manifest)")
+		noRegAuth     = fs.Bool("no-registry-auth", false, "Pull without a registry credential, overriding the manifest's and clearing the live template's")
+		networkVolume = fs.String("network-volume", "", "Network volume id or name to mount at /runpod-volume (see `iosuite endpoint volumes`)")
+		dataCenters   = fs.String("data-center", "", "Comma-separated RunPod data center ids to restrict workers to, e.g. EU-RO-1 (default: the volume's, else anywhere)")
+		// Template env on top of the manifest's. Precedence, lowest
+		// first: manifest < --env-file < --env < --secret.
+		envFile = fs.String("env-file", "", "Read KEY=VALUE env overrides from a dotenv-style file (values masked in output)")
+		envVars = kvFlag{}
+		secrets = kvFlag{}
 		// Tri-state flag: --flashboot, --no-flashboot, or unset (use
 		// tool default). Go's flag package only does true booleans;
 		// we model the unset case by walking fs.Visit() after Parse.
@@ -432,21 +699,78 @@ func cmdEndpointDeploy(args []string) error {
 		manifestVersion = fs.String("version", "", "Git tag of the *-serve repo to read the manifest from (default: registry's stable version)")
 		manifestPath    = fs.String("manifest", "", "Read deploy manifest from a local file instead of fetching by tool+version (dev override)")
 	)
+	fs.Var(envVars, "env", "Set a template env var: KEY=VALUE (repeatable; overrides --env-file and the manifest; value masked in output)")
+	fs.Var(secrets, "secret", "Inject a RunPod secret: KEY=SECRET_NAME becomes {{ RUNPOD_SECRET_SECRET_NAME }} (repeatable; wins over --env)")
+	out := output.Register(fs)
+	dryRun := sub == "diff"
+	var (
+		strategy          = endpoint.StrategyDirect
+		api               = runpod.DefaultAPI
+		maxRegression     float64
+		canaryMetrics     string
+		keepCanary        bool
+		benchmarkPath     string
+		inputResourcePath string
+	)
+	if !dryRun {
+		fs.BoolVar(&dryRun, "dry-run", false, "Show what would change (field-level diff) without saving; exit 2 if anything would")
+		fs.StringVar(&api, "api", runpod.DefaultAPI, "RunPod admin API that saves the template + endpoint: graphql or rest (--dry-run always reads via graphql)")
+		// Canary rollout: deploy to <name>-canary, benchmark it against
+		// the live endpoint, promote only within the thresholds.
+		fs.StringVar(&strategy, "strategy", endpoint.StrategyDirect, "Rollout strategy: direct (update in place) or canary (benchmark a <name>-canary first)")
+		fs.Float64Var(&maxRegression, "canary-max-regression", endpoint.DefaultMaxRegressionPct, "Canary: max % a metric may be slower than the live endpoint and still promote")
+		fs.StringVar(&canaryMetrics, "canary-metrics", "", "Canary: comma-separated benchmark metric names to compare (default: all)")
+		fs.BoolVar(&keepCanary, "canary-keep", false, "Canary: leave a canary that wasn't promoted running, for inspection")
+		fs.StringVar(&benchmarkPath, "benchmark-manifest", "", "Canary: read the benchmark manifest from a local file instead of fetching by tool+version")
+		fs.StringVar(&inputResourcePath, "input-resource", "", "Canary: read the benchmark input from a local file")
+	}
 	fs.Usage = func() {
-		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint deploy [flags]
+		if sub == "diff" {
+			fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint diff [flags]
+
+Show what `+"`endpoint deploy`"+` with the same flags would change: a
+field-level diff of the live template + endpoint against the manifest
+plus flags. Saves nothing. Exit status: 0 = no changes, 2 = changes,
+1 = error.
+
+Flags:`)
+		} else {
+			fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint deploy [flags]
 
 Create (or update) a serverless endpoint on a provider. Idempotent:
 re-running with the same name updates the template + endpoint
 in-place.
 
+With --strategy canary the new template is first deployed to a
+parallel <name>-canary endpoint and benchmarked against the live one;
+the live endpoint is only updated if no compared metric regressed by
+more than --canary-max-regression percent. The canary is deleted
+afterwards. Exits non-zero when the canary isn't promoted.
+
 Flags:`)
+		}
 		fs.PrintDefaults()
 	}
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	switch strategy {
+	case endpoint.StrategyDirect:
+	case endpoint.StrategyCanary:
+		if dryRun {
+			return fmt.Errorf("--strategy canary and --dry-run can't be combined (the plan is the same as a direct deploy's)")
+		}
+		if maxRegression < 0 {
+			return fmt.Errorf("--canary-max-regression must be >= 0")
+		}
+	default:
+		return fmt.Errorf("--strategy must be %s or %s, got %q", endpoint.StrategyDirect, endpoint.StrategyCanary, strategy)
+	}
 
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
@@ -456,265 +780,1717 @@ Flags:`)
 	// wins (dev override); otherwise fetch from the *-serve repo at
 	// the requested git tag.
 	ctx := context.Background()
-	var (
-		man          *manifest.Manifest
-		manifestSrc  string
-	)
-	if *manifestPath != "" {
-		man, err = manifest.LoadFile(*manifestPath)
+	man, manifestSrc, err := resolveDeployManifest(ctx, *tool, *manifestVersion, *manifestPath)
+	if err != nil {
+		return err
+	}
+
+	envOverrides, err := deployEnv(*envFile, envVars)
+	if err != nil {
+		return err
+	}
+
+	// Distinguish "user passed --flashboot=…" from "user didn't pass
+	// it"; the endpoint package needs the latter to fall back to the
+	// manifest default. flag.Visit walks only flags that were set.
+	var flashbootSet, workersMinSet bool
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "flashboot":
+			flashbootSet = true
+		case "workers-min":
+			workersMinSet = true
+		}
+	})
+	in := endpoint.DeployInput{
+		Provider:          *provider,
+		Tool:              *tool,
+		GPUClass:          *gpuClass,
+		Name:              *name,
+		APIKey:            key,
+		Manifest:          man,
+		WorkersMax:        *workersMax,
+		IdleTimeoutS:      *idleTimeout,
+		MinCudaVersion:    *minCudaVersion,
+		ScalerType:        strings.ToUpper(*scalerType),
+		ScalerValue:       *scalerValue,
+		UserAgent:         fmt.Sprintf("iosuite/%s", version.Version),
+		ExecutionTimeoutS: *execTimeout,
+		RegistryAuth:      *registryAuth,
+		NoRegistryAuth:    *noRegAuth,
+		NetworkVolume:     *networkVolume,
+		DataCenters:       splitList(*dataCenters),
+		Env:               envOverrides,
+		Secrets:           secrets,
+		API:               api,
+	}
+	if flashbootSet {
+		in.Flashboot = flashboot
+	}
+	if workersMinSet {
+		in.WorkersMin = workersMin
+	}
+	if warn := endpoint.StockWarning(ctx, in); warn != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warn)
+	}
+	if dryRun {
+		plan, err := endpoint.PlanDeploy(ctx, in)
 		if err != nil {
 			return err
 		}
-		manifestSrc = *manifestPath
-	} else {
-		url, err := registry.ManifestURL(*tool, *manifestVersion)
+		if err := output.Render(os.Stdout, *out, plan, func(w io.Writer) { endpoint.PrintPlan(w, plan) }); err != nil {
+			return err
+		}
+		if plan.HasChanges() {
+			return exitCode(2)
+		}
+		return nil
+	}
+	deployed := history.Revision{
+		Action:         history.ActionDeploy,
+		Tool:           *tool,
+		Version:        *manifestVersion,
+		ManifestSource: manifestSrc,
+		Manifest:       man,
+	}
+	if strategy == endpoint.StrategyCanary {
+		bench, _, inputBytes, err := resolveBenchmark(ctx, *tool, *manifestVersion, benchmarkPath, inputResourcePath)
 		if err != nil {
 			return err
 		}
-		man, err = manifest.Fetch(ctx, url)
+		progress := io.Writer(os.Stdout)
+		if out.Machine() {
+			progress = os.Stderr
+		}
+		// Ctrl-C stops the rollout but still deletes the canary.
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		th := endpoint.CanaryThresholds{MaxRegressionPct: maxRegression}
+		if canaryMetrics != "" {
+			th.Metrics = splitList(canaryMetrics)
+		}
+		cr, err := endpoint.Canary(ctx, endpoint.CanaryInput{
+			Deploy:     in,
+			Bench:      bench,
+			BenchInput: inputBytes,
+			Policy: retry.Policy{OnRetry: func(attempt int, err error, wait time.Duration) {
+				fmt.Fprintf(os.Stderr, "  retry %d in %s: %v\n", attempt, wait.Round(time.Millisecond), err)
+			}},
+			Thresholds: th,
+			KeepCanary: keepCanary,
+			Progress:   progress,
+		})
 		if err != nil {
 			return err
 		}
-		manifestSrc = url
+		if cr.Promoted {
+			cr.Deploy.ManifestSource = manifestSrc
+			recordDeploy(deployed, cr.Deploy)
+		}
+		if err := output.Render(os.Stdout, *out, cr, func(w io.Writer) { endpoint.PrintCanary(w, cr) }); err != nil {
+			return err
+		}
+		if !cr.Promoted {
+			return fmt.Errorf("canary not promoted: %s", cr.Reason)
+		}
+		return nil
+	}
+	res, err := endpoint.Deploy(ctx, in)
+	if err != nil {
+		return err
+	}
+	res.ManifestSource = manifestSrc
+	recordDeploy(deployed, res)
+	return output.Render(os.Stdout, *out, res, func(w io.Writer) { endpoint.PrintDeploy(w, res) })
+}
+
+// deployEnv orders the env overrides for DeployInput.Env: the
+// --env-file entries in file order, then --env by key, so --env wins.
+func deployEnv(path string, flags kvFlag) ([]manifest.EnvVar, error) {
+	var env []manifest.EnvVar
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("--env-file: %w", err)
+		}
+		defer f.Close()
+		if env, err = endpoint.ParseEnvFile(f, path); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]string, 0, len(flags))
+	for k := range flags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, manifest.EnvVar{Key: k, Value: flags[k]})
+	}
+	return env, nil
+}
+
+// recordDeploy appends a successful deploy to the local history. The
+// deploy already happened, so a history failure is a warning, not an
+// error.
+func recordDeploy(rev history.Revision, res *endpoint.DeployResult) *history.Revision {
+	store, err := historyStore()
+	if err == nil {
+		rev.Endpoint = res.EndpointName
+		rev.EndpointID = res.EndpointID
+		rev.TemplateID = res.TemplateID
+		if res.Spec != nil {
+			rev.Spec = *res.Spec
+		}
+		rev, err = store.Append(rev)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: deploy history not recorded: %v\n", err)
+		return nil
+	}
+	return &rev
+}
+
+// historyStore is the deploy history of the selected profile.
+func historyStore() (*history.Store, error) {
+	return history.Default(config.SelectedProfile(profileFlag))
+}
+
+// resolveDeployManifest loads a tool's deploy manifest: from path
+// when set (dev override), else from the *-serve repo at the given
+// git tag via the registry. Returns the manifest and its source.
+func resolveDeployManifest(ctx context.Context, tool, version, path string) (*manifest.Manifest, string, error) {
+	if path != "" {
+		man, err := manifest.LoadFile(path)
+		return man, path, err
+	}
+	url, err := registry.ManifestURL(tool, version)
+	if err != nil {
+		return nil, "", err
+	}
+	man, err := manifest.Fetch(ctx, url)
+	return man, url, err
+}
+
+// cmdEndpointApply converges the account to a fleet file: every
+// declared endpoint is created or updated through the same flow as
+// `endpoint deploy`; --prune also deletes undeclared ones.
+func cmdEndpointApply(args []string) error {
+	fs := flag.NewFlagSet("endpoint apply", flag.ExitOnError)
+	var (
+		file   = fs.String("f", "", "Fleet file (.toml or .json) — required")
+		prune  = fs.Bool("prune", false, "Delete iosuite-created endpoints that the fleet file doesn't declare")
+		yes    = fs.Bool("yes", false, "With --prune, delete without asking for confirmation")
+		api    = fs.String("api", runpod.DefaultAPI, "RunPod admin API: graphql or rest")
+		apiKey = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	fs.StringVar(file, "file", "", "Alias of -f")
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint apply -f fleet.toml [flags]
+
+Create or update every endpoint declared in a fleet file, reusing the
+deploy flow (manifest per tool + version, find-or-save template and
+endpoint). With --prune, endpoints iosuite created (each running its
+own <name>-tmpl template) that the file doesn't declare are deleted
+with their template — only if every create / update succeeded, and
+after a confirmation (or --yes, required when stdin isn't a terminal
+or with -o json). Other endpoints on the account and the
+<name>-canary endpoint of a rollout in progress are left alone.
+Exits non-zero when any endpoint failed.
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-f <fleet file> is required")
+	}
+	if *prune && !*yes && (out.Machine() || !stdinIsTerminal()) {
+		return fmt.Errorf("--prune deletes endpoints; pass --yes to prune without a prompt")
+	}
+	fl, err := fleet.Load(*file)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	results, err := endpoint.Apply(context.Background(), endpoint.ApplyInput{
+		Fleet:     fl,
+		APIKey:    resolveRunpodAPIKey(*apiKey, cfg),
+		UserAgent: fmt.Sprintf("iosuite/%s", version.Version),
+		Resolve:   resolveDeployManifest,
+		API:       *api,
+		Prune:     *prune,
+		ConfirmPrune: func(doomed []endpoint.ApplyResult) (bool, error) {
+			if *yes {
+				return true, nil
+			}
+			fmt.Fprintln(os.Stderr, "\nNot in the fleet file:")
+			for _, r := range doomed {
+				if r.Template != "" {
+					fmt.Fprintf(os.Stderr, "  %-28s  %s (and template %s)\n", r.Name, r.EndpointID, r.Template)
+				} else {
+					fmt.Fprintf(os.Stderr, "  %-28s  %s\n", r.Name, r.EndpointID)
+				}
+			}
+			return confirm(os.Stderr, os.Stdin, fmt.Sprintf("\nDelete %d endpoint(s)? [y/N] ", len(doomed)))
+		},
+		Deployed: func(e fleet.Endpoint, man *manifest.Manifest, res *endpoint.DeployResult) {
+			recordDeploy(history.Revision{
+				Action:         history.ActionApply,
+				Tool:           e.Tool,
+				Version:        e.Version,
+				ManifestSource: res.ManifestSource,
+				Manifest:       man,
+			}, res)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := output.Render(os.Stdout, *out, results, func(w io.Writer) { endpoint.PrintApply(w, results) }); err != nil {
+		return err
+	}
+	if endpoint.ApplyFailed(results) {
+		return fmt.Errorf("apply: one or more endpoints failed")
+	}
+	return nil
+}
+
+func cmdEndpointList(args []string) error {
+	fs := flag.NewFlagSet("endpoint list", flag.ExitOnError)
+	var (
+		provider = fs.String("provider", "runpod", "Provider")
+		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	endpoints, err := endpoint.List(context.Background(), *provider,
+		resolveRunpodAPIKey(*apiKey, cfg),
+		fmt.Sprintf("iosuite/%s", version.Version))
+	if err != nil {
+		return err
+	}
+	return output.Render(os.Stdout, *out, endpoints, func(w io.Writer) { endpoint.PrintList(w, endpoints) })
+}
+
+func cmdEndpointVolumes(args []string) error {
+	fs := flag.NewFlagSet("endpoint volumes", flag.ExitOnError)
+	var (
+		provider = fs.String("provider", "runpod", "Provider")
+		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	vols, err := endpoint.Volumes(context.Background(), *provider,
+		resolveRunpodAPIKey(*apiKey, cfg),
+		fmt.Sprintf("iosuite/%s", version.Version))
+	if err != nil {
+		return err
+	}
+	return output.Render(os.Stdout, *out, vols, func(w io.Writer) { endpoint.PrintVolumes(w, vols) })
+}
+
+// cmdEndpointGpus lists the GPU classes a tool's manifest declares
+// with the RunPod pool each maps to and that pool's stock and price,
+// to pick a --gpu-class before deploying.
+func cmdEndpointGpus(args []string) error {
+	fs := flag.NewFlagSet("endpoint gpus", flag.ExitOnError)
+	var (
+		provider        = fs.String("provider", "runpod", "Provider")
+		tool            = fs.String("tool", "real-esrgan", "Tool whose manifest gpu_pools to list")
+		apiKey          = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+		manifestVersion = fs.String("version", "", "Git tag of the *-serve repo to read the manifest from (default: registry's stable version)")
+		manifestPath    = fs.String("manifest", "", "Read deploy manifest from a local file instead of fetching by tool+version (dev override)")
+	)
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint gpus [--tool <tool>] [flags]
+
+List every GPU class the tool's deploy manifest declares, the RunPod
+pool --gpu-class maps it to, the pool's current stock (best of its GPU
+types) and the cheapest in-stock GPU type's on-demand price per
+second.
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	man, _, err := resolveDeployManifest(ctx, *tool, *manifestVersion, *manifestPath)
+	if err != nil {
+		return err
+	}
+	gpus, err := endpoint.GPUs(ctx, *provider,
+		resolveRunpodAPIKey(*apiKey, cfg),
+		fmt.Sprintf("iosuite/%s", version.Version), man)
+	if err != nil {
+		return err
+	}
+	return output.Render(os.Stdout, *out, gpus, func(w io.Writer) { endpoint.PrintGPUs(w, gpus) })
+}
+
+func cmdEndpointDestroy(args []string) error {
+	fs := flag.NewFlagSet("endpoint destroy", flag.ExitOnError)
+	var (
+		provider = fs.String("provider", "runpod", "Provider")
+		name     = fs.String("name", "", "Endpoint name (alternative to passing the id positionally)")
+		keepTmpl = fs.Bool("keep-template", false, "Leave the endpoint's <name>-tmpl template in place")
+		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	id := ""
+	if fs.NArg() > 0 {
+		id = fs.Arg(0)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	res, err := endpoint.Destroy(context.Background(), *provider,
+		resolveRunpodAPIKey(*apiKey, cfg),
+		fmt.Sprintf("iosuite/%s", version.Version),
+		id, *name, *keepTmpl)
+	if err != nil {
+		return err
+	}
+	return output.Render(os.Stdout, *out, res, func(w io.Writer) { endpoint.PrintDestroy(w, res) })
+}
+
+// cmdEndpointPrune deletes iosuite-created templates no endpoint uses
+// and iosuite-created endpoints that have been idle for --idle-days,
+// after a confirmation (or --yes).
+func cmdEndpointPrune(args []string) error {
+	fs := flag.NewFlagSet("endpoint prune", flag.ExitOnError)
+	var (
+		idleDays = fs.Int("idle-days", endpoint.DefaultPruneIdleDays, "Prune endpoints with no jobs for this many days (0 = templates only)")
+		yes      = fs.Bool("yes", false, "Delete without asking for confirmation")
+		dryRun   = fs.Bool("dry-run", false, "Only list what would be deleted")
+		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint prune [flags]
+
+Find and delete what iosuite created but nothing uses:
+
+  - serverless *-tmpl templates no endpoint references (left behind by
+    `+"`destroy --keep-template`"+` or the console)
+  - endpoints running their own <name>-tmpl template that have had no
+    jobs for --idle-days, together with that template
+
+RunPod doesn't report when an endpoint last served a job, so idleness
+is measured across prune runs (recorded in endpoint-activity.json next
+to config.toml): the first run only starts tracking an endpoint. Run
+prune regularly, e.g. daily from cron with --yes.
+
+Lists the candidates and asks before deleting; --yes skips the
+question (required when stdin isn't a terminal or with -o json).
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if *idleDays < 0 {
+		return fmt.Errorf("--idle-days must be >= 0")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	activityPath := filepath.Join(dir, endpoint.ActivityFileName)
+	activity, err := endpoint.LoadActivity(activityPath)
+	if err != nil {
+		return err
+	}
+	key := resolveRunpodAPIKey(*apiKey, cfg)
+	ua := fmt.Sprintf("iosuite/%s", version.Version)
+	ctx := context.Background()
+	plan, err := endpoint.FindPrunable(ctx, endpoint.PruneInput{
+		APIKey:    key,
+		UserAgent: ua,
+		IdleFor:   time.Duration(*idleDays) * 24 * time.Hour,
+		Activity:  activity,
+	})
+	if err != nil {
+		return err
+	}
+	if err := activity.Save(activityPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	render := func() error {
+		return output.Render(os.Stdout, *out, plan, func(w io.Writer) { endpoint.PrintPrune(w, plan) })
+	}
+	if *dryRun || len(plan.Candidates) == 0 {
+		return render()
+	}
+	if !*yes {
+		if out.Machine() || !stdinIsTerminal() {
+			return fmt.Errorf("%d resource(s) to prune; pass --yes to delete without a prompt (or --dry-run to list them)", len(plan.Candidates))
+		}
+		endpoint.PrintPrune(os.Stderr, plan)
+		ok, err := confirm(os.Stderr, os.Stdin, fmt.Sprintf("\nDelete %d resource(s)? [y/N] ", len(plan.Candidates)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted; nothing deleted")
+		}
+	}
+	plan.Candidates = endpoint.Prune(ctx, key, ua, plan.Candidates)
+	if err := render(); err != nil {
+		return err
+	}
+	if endpoint.PruneFailed(plan.Candidates) {
+		return fmt.Errorf("prune: one or more deletions failed")
+	}
+	return nil
+}
+
+// stdinIsTerminal reports whether stdin is interactive, so commands
+// that prompt can refuse instead of reading a pipe.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm writes prompt to w and reports whether the answer read from
+// r is yes.
+func confirm(w io.Writer, r io.Reader, prompt string) (bool, error) {
+	fmt.Fprint(w, prompt)
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// cmdEndpointHistory lists the revisions recorded for one endpoint
+// name, oldest first.
+func cmdEndpointHistory(args []string) error {
+	fs := flag.NewFlagSet("endpoint history", flag.ExitOnError)
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint history <name> [flags]
+
+List the deploy revisions recorded on this machine for an endpoint
+under the selected profile: every `+"`endpoint deploy`"+`, `+"`apply`"+` and
+`+"`rollback`"+`, newest last (marked *).
+Roll back with `+"`iosuite endpoint rollback <name> --to N`"+`.
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	name := parseInterspersed(fs, args)
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("usage: iosuite endpoint history <name>")
+	}
+	store, err := historyStore()
+	if err != nil {
+		return err
+	}
+	revs, err := store.List(name)
+	if err != nil {
+		return err
+	}
+	if revs == nil {
+		revs = []history.Revision{}
+	}
+	// The file keeps override values for rollback; output never does.
+	for i := range revs {
+		revs[i].Spec = revs[i].Spec.Masked()
+	}
+	return output.Render(os.Stdout, *out, revs, func(w io.Writer) { history.Print(w, name, revs) })
+}
+
+// cmdEndpointRollback re-applies a recorded revision's Spec through
+// the same SaveTemplate / SaveEndpoint flow as deploy, then records
+// the rollback as a new revision.
+func cmdEndpointRollback(args []string) error {
+	fs := flag.NewFlagSet("endpoint rollback", flag.ExitOnError)
+	var (
+		to     = fs.Int("to", 0, "Revision to roll back to (default: the deploy before the one live now; repeat to keep stepping back)")
+		api    = fs.String("api", runpod.DefaultAPI, "RunPod admin API: graphql or rest")
+		apiKey = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+	)
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint rollback <name> [--to N] [flags]
+
+Re-apply an earlier revision from the local deploy history: its image,
+env, GPU pool and worker settings are saved back to the template and
+endpoint as they were. The rollback is itself recorded as a new
+revision, so it can be undone the same way.
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	name := parseInterspersed(fs, args)
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("usage: iosuite endpoint rollback <name> [--to N]")
+	}
+	if *to < 0 {
+		return fmt.Errorf("--to must be a revision number (see `iosuite endpoint history`)")
+	}
+	store, err := historyStore()
+	if err != nil {
+		return err
+	}
+	target, err := store.Target(name, *to)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	res, err := endpoint.ApplySpec(context.Background(), *api,
+		resolveRunpodAPIKey(*apiKey, cfg),
+		fmt.Sprintf("iosuite/%s", version.Version),
+		&target.Spec)
+	if err != nil {
+		return err
+	}
+	res.ManifestSource = target.ManifestSource
+	rev := recordDeploy(history.Revision{
+		Action:         history.ActionRollback,
+		RollbackOf:     target.Revision,
+		Tool:           target.Tool,
+		Version:        target.Version,
+		ManifestSource: target.ManifestSource,
+		Manifest:       target.Manifest,
+	}, res)
+	return output.Render(os.Stdout, *out, res, func(w io.Writer) {
+		fmt.Fprintf(w, "rolled back %s to revision %d", name, target.Revision)
+		if rev != nil {
+			fmt.Fprintf(w, " (recorded as revision %d)", rev.Revision)
+		}
+		fmt.Fprintln(w)
+		endpoint.PrintDeploy(w, res)
+	})
+}
+
+// cmdEndpointStatus prints an endpoint's configuration alongside its
+// live /health counts. --watch re-polls until interrupted; with
+// --json each refresh is one JSON object per line.
+func cmdEndpointStatus(args []string) error {
+	fs := flag.NewFlagSet("endpoint status", flag.ExitOnError)
+	var (
+		provider = fs.String("provider", "runpod", "Provider")
+		name     = fs.String("name", "", "Endpoint name (alternative to passing the id positionally)")
+		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+		watch    = fs.Bool("watch", false, "Refresh until interrupted")
+		interval = fs.Duration("interval", 5*time.Second, "Refresh interval for --watch")
+	)
+	out := output.Register(fs)
+	fs.BoolFunc("json", "Shorthand for --output json (one object per refresh with --watch)", func(string) error {
+		out.Format = output.JSON
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint status <id> | --name <name> [flags]
+
+Show an endpoint's configuration (GPU pool, worker limits, idle
+timeout, FlashBoot, CUDA pin, image) and its live worker / job counts.
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	id := parseInterspersed(fs, args)
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	out.Compact = *watch
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	key := resolveRunpodAPIKey(*apiKey, cfg)
+	ua := fmt.Sprintf("iosuite/%s", version.Version)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for refresh := 0; ; refresh++ {
+		res, err := endpoint.Status(ctx, *provider, key, ua, id, *name)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil // interrupted mid-fetch
+		case err != nil && refresh == 0:
+			return err
+		case err != nil:
+			// A blip mid-watch shouldn't end the session.
+			fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		case out.Format == output.JSON:
+			if err := output.Render(os.Stdout, *out, res, nil); err != nil {
+				return err
+			}
+		case out.Format == output.YAML:
+			if *watch && refresh > 0 {
+				fmt.Println("---")
+			}
+			if err := output.Render(os.Stdout, *out, res, nil); err != nil {
+				return err
+			}
+		default:
+			if *watch {
+				// Clear + home so the block redraws in place.
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("every %s, Ctrl-C to stop · %s\n\n", *interval, res.FetchedAt.Local().Format("15:04:05"))
+			}
+			if err := output.Render(os.Stdout, *out, res, func(w io.Writer) { endpoint.PrintStatus(w, res) }); err != nil {
+				return err
+			}
+		}
+		if !*watch {
+			return nil
+		}
+		if err == nil {
+			// Name lookups cost a list query; pin the id after the
+			// first successful one.
+			id = res.EndpointID
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// cmdEndpointBenchmark drives the workload declared in a *-serve
+// module's deploy/benchmark.json against a deployed endpoint.
+// iosuite owns the wire (POST loop, timing, percentile math); the
+// serve module owns what to send and what to measure.
+func cmdEndpointBenchmark(args []string) error {
+	fs := flag.NewFlagSet("endpoint benchmark", flag.ExitOnError)
+	var (
+		provider          = fs.String("provider", "runpod", "Provider (runpod is the only supported value today)")
+		tool              = fs.String("tool", "real-esrgan", "Tool whose benchmark manifest to run")
+		endpointID        = fs.String("endpoint-id", "", "RunPod endpoint id to benchmark (required)")
+		apiKey            = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
+		manifestVersion   = fs.String("version", "", "Git tag of the *-serve repo to read the benchmark manifest from (default: registry's stable version)")
+		benchmarkPath     = fs.String("benchmark-manifest", "", "Read benchmark manifest from a local file instead of fetching by tool+version")
+		inputResourcePath = fs.String("input-resource", "", "Read the benchmark input from a local file instead of fetching from the *-serve repo (paired with --benchmark-manifest for offline dev)")
+		retryAttempts     = fs.Int("retry-max-attempts", 0, "Max tries per benchmark POST, including the first (default 4)")
+		retryBudget       = fs.Duration("retry-budget", 0, "Total time allowed across one POST's retries, e.g. 2m (default: no cap)")
+		breakerThreshold  = fs.Int("breaker-threshold", 0, "Consecutive failed POSTs that open the circuit breaker (default 5)")
+		breakerCooldown   = fs.Duration("breaker-cooldown", 0, "How long an open breaker fails POSTs fast before trying one, e.g. 1m (default 30s)")
+		// Load-test mode: any of these replaces the one-at-a-time
+		// measure loop with benchmark.RunLoad.
+		concurrency = fs.Int("concurrency", 0, "Load test: keep N requests in flight (closed loop); with --rps, the most allowed in flight")
+		rps         = fs.Float64("rps", 0, "Load test: start R requests per second however fast the endpoint answers (open loop)")
+		duration    = fs.Duration("duration", 0, "Load test: keep sending for this long, e.g. 5m (default: the manifest's measure count)")
+	)
+	out := output.Register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint benchmark [flags]
+
+Run a tool's published benchmark workload against a deployed
+endpoint. The serve module owns the workload (input image, request
+shape, metrics); iosuite owns the wire.
+
+By default requests go one at a time, measuring single-request
+latency. --concurrency, --rps or --duration turn the run into a load
+test reporting throughput, error rate, client-side latency and
+RunPod's queue vs execution time next to the worker's metrics:
+
+  iosuite endpoint benchmark --endpoint-id abc123 --concurrency 8 --duration 5m
+  iosuite endpoint benchmark --endpoint-id abc123 --rps 2 --duration 10m
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if *provider != "runpod" {
+		return fmt.Errorf("provider %q is not supported (only 'runpod' is implemented)", *provider)
+	}
+	if *endpointID == "" {
+		return fmt.Errorf("--endpoint-id is required")
+	}
+	if *concurrency < 0 || *rps < 0 || *duration < 0 {
+		return fmt.Errorf("--concurrency, --rps and --duration must not be negative")
+	}
+	load := *concurrency > 0 || *rps > 0 || *duration > 0
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	key := resolveRunpodAPIKey(*apiKey, cfg)
+	if key == "" {
+		return endpoint.ErrNoAPIKey
+	}
+
+	ctx := context.Background()
+	bench, bSource, inputBytes, err := resolveBenchmark(ctx, *tool, *manifestVersion, *benchmarkPath, *inputResourcePath)
+	if err != nil {
+		return err
+	}
+
+	// The run header is progress chatter; keep stdout parseable for
+	// machine formats.
+	progress := io.Writer(os.Stdout)
+	if out.Machine() {
+		progress = os.Stderr
+	}
+	if load {
+		fmt.Fprintf(progress, "benchmark: tool=%s endpoint=%s warmup=%d %s\n",
+			bench.Tool, *endpointID, bench.Warmup, loadSummary(*concurrency, *rps, *duration, bench.Measure))
+	} else {
+		fmt.Fprintf(progress, "benchmark: tool=%s endpoint=%s warmup=%d measure=%d\n",
+			bench.Tool, *endpointID, bench.Warmup, bench.Measure)
+	}
+	fmt.Fprintf(progress, "manifest:  %s\n", bSource)
+	fmt.Fprintln(progress)
+
+	policy := retry.Policy{
+		MaxAttempts: *retryAttempts,
+		Budget:      *retryBudget,
+		Breaker:     retry.NewBreaker(*breakerThreshold, *breakerCooldown),
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			fmt.Fprintf(os.Stderr, "  retry %d in %s: %v\n", attempt, wait.Round(time.Millisecond), err)
+		},
+	}
+	if load {
+		// Ctrl-C ends a load test early; the partial report still prints.
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		rep, err := benchmark.RunLoad(ctx, *endpointID, key, bench, inputBytes, benchmark.LoadOptions{
+			Concurrency: *concurrency,
+			RPS:         *rps,
+			Duration:    *duration,
+			Policy:      policy,
+			Progress:    os.Stderr,
+		})
+		if err != nil {
+			return err
+		}
+		if rep.Interrupted {
+			fmt.Fprintln(os.Stderr, "benchmark: interrupted; reporting the requests that completed")
+		}
+		report := &benchmark.Report{
+			Tool:       bench.Tool,
+			EndpointID: *endpointID,
+			Warmup:     bench.Warmup,
+			Measure:    rep.Requests,
+			Manifest:   bSource,
+			Results:    rep.Results,
+			Load:       rep,
+		}
+		err = output.Render(os.Stdout, *out, report, func(w io.Writer) {
+			fmt.Fprint(w, benchmark.FormatLoad(rep))
+		})
+		if err == nil && rep.Interrupted {
+			err = exitCode(130)
+		}
+		return err
+	}
+	results, err := benchmark.Run(ctx, *endpointID, key, bench, inputBytes, policy)
+	if err != nil {
+		return err
+	}
+	report := &benchmark.Report{
+		Tool:       bench.Tool,
+		EndpointID: *endpointID,
+		Warmup:     bench.Warmup,
+		Measure:    bench.Measure,
+		Manifest:   bSource,
+		Results:    results,
+	}
+	return output.Render(os.Stdout, *out, report, func(w io.Writer) {
+		fmt.Fprint(w, benchmark.FormatResults(results))
+	})
+}
+
+// loadSummary describes a load test for the benchmark header, e.g.
+// "load=closed concurrency=8 duration=5m0s".
+func loadSummary(concurrency int, rps float64, duration time.Duration, measure int) string {
+	var b strings.Builder
+	if rps > 0 {
+		fmt.Fprintf(&b, "load=open rps=%g", rps)
+		if concurrency > 0 {
+			fmt.Fprintf(&b, " max-in-flight=%d", concurrency)
+		}
+	} else {
+		fmt.Fprintf(&b, "load=closed concurrency=%d", max(concurrency, 1))
+	}
+	if duration > 0 {
+		fmt.Fprintf(&b, " duration=%s", duration)
+	} else {
+		fmt.Fprintf(&b, " requests=%d", measure)
+	}
+	return b.String()
+}
+
+// resolveBenchmark loads a tool's benchmark manifest and its input
+// resource. Local files win (dev override); otherwise both are
+// fetched from the *-serve repo at the requested tag. Returns the
+// manifest, its source and the decoded input bytes.
+func resolveBenchmark(ctx context.Context, tool, version, benchmarkPath, inputResourcePath string) (*manifest.BenchmarkManifest, string, []byte, error) {
+	var (
+		bench   *manifest.BenchmarkManifest
+		bSource string
+		baseURL string
+		err     error
+	)
+	if benchmarkPath != "" {
+		bench, err = manifest.LoadBenchmarkFile(benchmarkPath)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bSource = benchmarkPath
+		// For input-resource resolution we use a file:// scheme so
+		// FetchInputResource reads from disk relative to the manifest.
+		// Caller may pass --input-resource explicitly to skip that.
+		baseURL = "file://" + benchmarkPath
+	} else {
+		url, err := registry.BenchmarkURL(tool, version)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bench, err = manifest.FetchBenchmark(ctx, url)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bSource = url
+		baseURL = url
+	}
+
+	// Fetch the input image. --input-resource takes precedence; else
+	// resolve relative to the manifest's source.
+	var inputBytes []byte
+	if inputResourcePath != "" {
+		inputBytes, err = os.ReadFile(inputResourcePath)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("read --input-resource: %w", err)
+		}
+	} else {
+		inputBytes, err = manifest.FetchInputResource(ctx, baseURL, bench.InputResource)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	// inputBytes can be either raw image bytes OR an already-base64
+	// string (real-esrgan-serve ships its bench input pre-encoded).
+	// Detect the b64 case so we don't double-encode in buildRequestBody.
+	if isLikelyBase64(inputBytes) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(inputBytes)))
+		if err == nil {
+			inputBytes = decoded
+		}
+	}
+	return bench, bSource, inputBytes, nil
+}
+
+// isLikelyBase64 returns true if the input looks like base64-encoded
+// text (single line, only base64 alphabet chars). Real-esrgan-serve's
+// deploy/bench/*.b64 files ship in this form; a future tool might
+// ship raw bytes instead — both should work without operator
+// intervention.
+func isLikelyBase64(b []byte) bool {
+	s := strings.TrimSpace(string(b))
+	if s == "" || len(s) < 8 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '+' || c == '/' || c == '=' || c == '\n' || c == '\r' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// resolveRunpodAPIKey applies the precedence: flag > env > config,
+// except that a selected profile's key beats the env var (see
+// config.Config.Lookup). Empty return is a soft signal — let the
+// underlying call surface the actionable error (which mentions all
+// three sources).
+func resolveRunpodAPIKey(flagVal string, cfg config.Config) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	s, err := cfg.Lookup("runpod.api_key")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: stored credentials: %v\n", err)
+	}
+	return s.Value
+}
+
+// profileFlag is the global --profile, stripped from the arguments
+// by run() so every subcommand honours it without declaring it.
+var profileFlag string
+
+// loadConfig is config.Load with the selected profile (--profile,
+// else $IOSUITE_PROFILE) applied and stored credentials attached.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, err
+	}
+	if cfg, err = cfg.SelectProfile(profileFlag); err != nil {
+		return cfg, err
+	}
+	store, err := credentialStore(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg.WithCredentials(store), nil
+}
+
+// extractProfile removes `--profile NAME` / `--profile=NAME` from
+// args (up to a `--` terminator) and returns the name.
+func extractProfile(args []string) (string, []string, error) {
+	var name string
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+		switch {
+		case a == "--profile" || a == "-profile":
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("--profile needs a profile name")
+			}
+			name = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--profile=") || strings.HasPrefix(a, "-profile="):
+			name = a[strings.IndexByte(a, '=')+1:]
+		default:
+			out = append(out, a)
+		}
+	}
+	return name, out, nil
+}
+
+// configureRunpod sets the timeout and retry logging every RunPod
+// admin client in this process uses. Timeout precedence is
+// IOSUITE_RUNPOD_TIMEOUT env > the profile's or [runpod] timeout >
+// 60s. A config file that fails to load is left for the subcommand to
+// report.
+func configureRunpod() error {
+	cfg, _ := loadConfig()
+	s, _ := cfg.Lookup("runpod.timeout")
+	timeout, err := time.ParseDuration(s.Value)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid runpod.timeout %q (from %s): want a positive duration like 90s", s.Value, s.Source)
+	}
+	runpod.DefaultOptions = runpod.Options{
+		Timeout: timeout,
+		Retry: retry.Policy{OnRetry: func(attempt int, err error, wait time.Duration) {
+			fmt.Fprintf(os.Stderr, "runpod api: retry %d in %s: %v\n", attempt, wait.Round(time.Millisecond), err)
+		}},
+	}
+	return nil
+}
+
+// cmdAuth dispatches `iosuite auth <subcommand>`.
+func cmdAuth(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(`Usage: iosuite auth <subcommand> [flags]
+
+Subcommands:
+  login    Store a RunPod API key for the selected profile
+  logout   Remove the stored key
+  status   Show which key is in use, where it came from, and whether RunPod accepts it
+
+Keys go to an encrypted file beside config.toml (sealed with a key
+file only this user can read, or with --passphrase), or to an external
+credential helper set with:
+
+  iosuite config set auth.helper NAME   # runs iosuite-credential-NAME
+
+A stored key beats the config file's api_key; $RUNPOD_API_KEY still
+beats a stored key unless a profile is selected. Select a profile
+with --profile NAME on any command, or $IOSUITE_PROFILE.`)
+		return nil
+	}
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "login":
+		return cmdAuthLogin(rest)
+	case "logout":
+		return cmdAuthLogout(rest)
+	case "status":
+		return cmdAuthStatus(rest)
+	case "-h", "--help", "help":
+		return cmdAuth(nil)
+	default:
+		return fmt.Errorf("unknown auth subcommand: %s (want login | logout | status)", sub)
+	}
+}
+
+// credentialStore is where `iosuite auth` keeps keys: the [auth]
+// helper when one is configured, else the encrypted file beside
+// config.toml.
+func credentialStore(cfg config.Config) (auth.Store, error) {
+	if cfg.AuthHelper != "" {
+		return auth.HelperStore{Command: cfg.AuthHelper}, nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	store := auth.NewFileStore(dir)
+	store.Passphrase = passphrase
+	return store, nil
+}
+
+// passphraseEnv supplies the credentials file's passphrase without a
+// prompt, for scripts and CI.
+const passphraseEnv = "IOSUITE_PASSPHRASE"
+
+// passphrase reads the credentials file's passphrase from
+// $IOSUITE_PASSPHRASE, else prompts when stdin is a terminal. It asks
+// at most once per run.
+var passphrase = sync.OnceValues(func() (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	if !stdinIsTerminal() {
+		return "", auth.ErrNoPassphrase
+	}
+	return readSecret("Credentials passphrase: ")
+})
+
+// readSecret prompts on stderr and reads one line from stdin with
+// echo off. Turning echo off goes through stty, so it is best effort:
+// consoles without it echo the input.
+func readSecret(prompt string) (string, error) {
+	stty := func(arg string) {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		_ = cmd.Run()
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	stty("-echo")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	stty("echo")
+	fmt.Fprintln(os.Stderr)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// verifyRunpodKey checks a key against RunPod, returning the
+// account's balance.
+func verifyRunpodKey(ctx context.Context, key string) (float64, error) {
+	acct, err := runpod.NewClient(key, fmt.Sprintf("iosuite/%s", version.Version)).GetAccount(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return acct.Balance, nil
+}
+
+func cmdAuthLogin(args []string) error {
+	fs := flag.NewFlagSet("auth login", flag.ExitOnError)
+	var (
+		withToken = fs.Bool("with-token", false, "Read the key from stdin instead of prompting")
+		usePass   = fs.Bool("passphrase", false, "Seal the credentials file with a passphrase instead of the machine key file")
+		noVerify  = fs.Bool("no-verify", false, "Store the key without checking it against RunPod")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: iosuite auth login [flags]
+
+Store a RunPod API key for the selected profile (--profile / $IOSUITE_PROFILE)
+in the credential store, after checking RunPod accepts it.
+
+  iosuite auth login
+  iosuite --profile prod auth login --passphrase
+  echo "$KEY" | iosuite auth login --with-token
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: iosuite auth login [--with-token] [--passphrase] [--no-verify]")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := credentialStore(cfg)
+	if err != nil {
+		return err
+	}
+	if *usePass {
+		fileStore, ok := store.(*auth.FileStore)
+		if !ok {
+			return fmt.Errorf("--passphrase applies to the encrypted file; keys are going to the %s", store)
+		}
+		if err := sealWithPassphrase(fileStore); err != nil {
+			return err
+		}
+	}
+
+	var key string
+	switch {
+	case *withToken:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+		key = strings.TrimSpace(string(data))
+	case stdinIsTerminal():
+		if key, err = readSecret("RunPod API key (input hidden): "); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("stdin is not a terminal: pipe the key in with --with-token")
+	}
+	if key == "" {
+		return fmt.Errorf("no API key given")
+	}
+	if !*noVerify {
+		balance, err := verifyRunpodKey(context.Background(), key)
+		if err != nil {
+			return fmt.Errorf("RunPod rejected the key: %w (store it anyway with --no-verify)", err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ key accepted (balance $%.2f)\n", balance)
+	}
+	if err := store.Put(cfg.Profile, key); err != nil {
+		return err
+	}
+	fmt.Printf("✓ stored the RunPod API key for %s in the %s\n", auth.ProfileLabel(cfg.Profile), store)
+	for _, k := range cfg.PlaintextKeys() {
+		fmt.Printf("  config.toml still holds %s in plaintext; remove it with `iosuite config unset %s`\n", k, k)
+	}
+	return nil
+}
+
+// sealWithPassphrase makes the file store's next write use a
+// passphrase, asking for a new one (twice) unless the file already
+// has one or $IOSUITE_PASSPHRASE is set.
+func sealWithPassphrase(store *auth.FileStore) error {
+	store.Seal = auth.SealPassphrase
+	sealed, err := store.Sealed()
+	if err != nil || sealed == auth.SealPassphrase || os.Getenv(passphraseEnv) != "" {
+		return err
 	}
-
-	// Distinguish "user passed --flashboot=…" from "user didn't pass
-	// it"; the endpoint package needs the latter to fall back to the
-	// manifest default. flag.Visit walks only flags that were set.
-	var flashbootSet bool
-	fs.Visit(func(f *flag.Flag) {
-		if f.Name == "flashboot" {
-			flashbootSet = true
-		}
-	})
-	in := endpoint.DeployInput{
-		Provider:       *provider,
-		Tool:           *tool,
-		GPUClass:       *gpuClass,
-		Name:           *name,
-		APIKey:         key,
-		Manifest:       man,
-		WorkersMax:     *workersMax,
-		IdleTimeoutS:   *idleTimeout,
-		MinCudaVersion: *minCudaVersion,
-		UserAgent:      fmt.Sprintf("iosuite/%s", version.Version),
+	if !stdinIsTerminal() {
+		return fmt.Errorf("set $%s to choose a passphrase non-interactively", passphraseEnv)
 	}
-	if flashbootSet {
-		in.Flashboot = flashboot
+	first, err := readSecret("New credentials passphrase: ")
+	if err != nil {
+		return err
 	}
-	res, err := endpoint.Deploy(ctx, in)
+	again, err := readSecret("Repeat passphrase: ")
 	if err != nil {
 		return err
 	}
-	res.ManifestSource = manifestSrc
-	endpoint.PrintDeploy(os.Stdout, res)
+	if first == "" || first != again {
+		return fmt.Errorf("passphrases are empty or don't match")
+	}
+	store.Passphrase = func() (string, error) { return first, nil }
 	return nil
 }
 
-func cmdEndpointList(args []string) error {
-	fs := flag.NewFlagSet("endpoint list", flag.ExitOnError)
-	var (
-		provider = fs.String("provider", "runpod", "Provider")
-		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
-	)
-	if err := fs.Parse(args); err != nil {
+func cmdAuthLogout(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: iosuite auth logout")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
 		return err
 	}
-	cfg, err := config.Load()
+	store, err := credentialStore(cfg)
 	if err != nil {
 		return err
 	}
-	endpoints, err := endpoint.List(context.Background(), *provider,
-		resolveRunpodAPIKey(*apiKey, cfg),
-		fmt.Sprintf("iosuite/%s", version.Version))
+	found, err := store.Erase(cfg.Profile)
 	if err != nil {
 		return err
 	}
-	if len(endpoints) == 0 {
-		fmt.Println("(no endpoints on this account)")
+	if !found {
+		fmt.Printf("no stored key for %s in the %s\n", auth.ProfileLabel(cfg.Profile), store)
 		return nil
 	}
-	for _, e := range endpoints {
-		fmt.Printf("  %s  %s  template=%s\n", e.ID, e.Name, e.TemplateID)
-	}
+	fmt.Printf("✓ removed the stored key for %s from the %s\n", auth.ProfileLabel(cfg.Profile), store)
 	return nil
 }
 
-func cmdEndpointDestroy(args []string) error {
-	fs := flag.NewFlagSet("endpoint destroy", flag.ExitOnError)
-	var (
-		provider = fs.String("provider", "runpod", "Provider")
-		name     = fs.String("name", "", "Endpoint name (alternative to passing the id positionally)")
-		apiKey   = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
-	)
+// authStatus is `iosuite auth status`.
+type authStatus struct {
+	Profile    string   `json:"profile"`
+	Store      string   `json:"store"`
+	Stored     bool     `json:"stored"`
+	Key        string   `json:"key"` // masked; "" = none resolved
+	Origin     string   `json:"origin,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	Verified   *bool    `json:"verified,omitempty"`
+	BalanceUSD *float64 `json:"balance_usd,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Plaintext  []string `json:"plaintext_keys,omitempty"` // api_key settings config.toml holds
+}
+
+func cmdAuthStatus(args []string) error {
+	fs := flag.NewFlagSet("auth status", flag.ExitOnError)
+	noVerify := fs.Bool("no-verify", false, "Don't check the key against RunPod")
+	out := output.Register(fs)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	id := ""
-	if fs.NArg() > 0 {
-		id = fs.Arg(0)
+	if err := out.Validate(); err != nil {
+		return err
 	}
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
-	deleted, err := endpoint.Destroy(context.Background(), *provider,
-		resolveRunpodAPIKey(*apiKey, cfg),
-		fmt.Sprintf("iosuite/%s", version.Version),
-		id, *name)
+	store, err := credentialStore(cfg)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("deleted endpoint: %s\n", deleted)
+	st := authStatus{Profile: cfg.Profile, Store: store.String(), Plaintext: cfg.PlaintextKeys()}
+	if _, found, err := store.Get(cfg.Profile); err != nil {
+		st.Error = err.Error()
+	} else {
+		st.Stored = found
+	}
+	setting, _ := cfg.Lookup("runpod.api_key")
+	if setting.Value != "" {
+		st.Key, st.Origin, st.Source = setting.Masked().Value, setting.Origin, setting.Source
+		if !*noVerify {
+			ok := true
+			balance, err := verifyRunpodKey(context.Background(), setting.Value)
+			if err != nil {
+				ok, st.Error = false, err.Error()
+			} else {
+				st.BalanceUSD = &balance
+			}
+			st.Verified = &ok
+		}
+	}
+	if err := output.Render(os.Stdout, *out, st, func(w io.Writer) {
+		stored := "no"
+		if st.Stored {
+			stored = "yes"
+		}
+		fmt.Fprintf(w, "profile:  %s\n", auth.ProfileLabel(st.Profile))
+		fmt.Fprintf(w, "store:    %s (key stored: %s)\n", st.Store, stored)
+		if st.Key == "" {
+			fmt.Fprintln(w, "in use:   none — run `iosuite auth login`")
+		} else {
+			fmt.Fprintf(w, "in use:   %s  (%s)\n", st.Key, originLabel(setting))
+		}
+		switch {
+		case st.BalanceUSD != nil:
+			fmt.Fprintf(w, "runpod:   ✓ key accepted (balance $%.2f)\n", *st.BalanceUSD)
+		case st.Error != "":
+			fmt.Fprintf(w, "error:    %s\n", st.Error)
+		}
+		for _, k := range st.Plaintext {
+			fmt.Fprintf(w, "warning:  config.toml holds %s in plaintext; move it with `iosuite auth login`\n", k)
+		}
+	}); err != nil {
+		return err
+	}
+	if st.Key == "" || st.Error != "" {
+		return exitCode(1)
+	}
 	return nil
 }
 
-// cmdEndpointBenchmark drives the workload declared in a *-serve
-// module's deploy/benchmark.json against a deployed endpoint.
-// iosuite owns the wire (POST loop, timing, percentile math); the
-// serve module owns what to send and what to measure.
-func cmdEndpointBenchmark(args []string) error {
-	fs := flag.NewFlagSet("endpoint benchmark", flag.ExitOnError)
-	var (
-		provider          = fs.String("provider", "runpod", "Provider (runpod is the only supported value today)")
-		tool              = fs.String("tool", "real-esrgan", "Tool whose benchmark manifest to run")
-		endpointID        = fs.String("endpoint-id", "", "RunPod endpoint id to benchmark (required)")
-		apiKey            = fs.String("runpod-api-key", "", "RunPod API key (overrides env + config)")
-		manifestVersion   = fs.String("version", "", "Git tag of the *-serve repo to read the benchmark manifest from (default: registry's stable version)")
-		benchmarkPath     = fs.String("benchmark-manifest", "", "Read benchmark manifest from a local file instead of fetching by tool+version")
-		inputResourcePath = fs.String("input-resource", "", "Read the benchmark input from a local file instead of fetching from the *-serve repo (paired with --benchmark-manifest for offline dev)")
-	)
+// cmdConfig dispatches `iosuite config <subcommand>`.
+func cmdConfig(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(`Usage: iosuite config <subcommand> [flags]
+
+Subcommands:
+  get <key>          Print a setting's effective value
+  set <key> <value>  Write a setting to the config file
+  unset <key>        Remove a setting from the config file
+  list               Show every effective setting (--show-origin: where from;
+                     --env: the environment variables that override them)
+  path               Print the config file's location
+  edit               Open the config file in $VISUAL / $EDITOR, then validate it
+  validate           Report unknown sections / keys and bad values
+  profiles           List the [profile.<name>] accounts and which is selected
+
+Keys are dotted: default.provider, runpod.api_key, serve.port,
+runpod.routes.<model>, profile.<name>.api_key, ... A bare key is a
+[default] one. Each fixed key can also be set with
+IOSUITE_<SECTION>_<KEY>, e.g. IOSUITE_SERVE_PORT. set / unset rewrite the file atomically and keep its
+comments and layout.
+
+Select a profile with --profile NAME on any command, or $IOSUITE_PROFILE.`)
+		return nil
+	}
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "get":
+		return cmdConfigGet(rest)
+	case "set":
+		return cmdConfigSet(rest)
+	case "unset":
+		return cmdConfigUnset(rest)
+	case "list":
+		return cmdConfigList(rest)
+	case "path":
+		return cmdConfigPath(rest)
+	case "edit":
+		return cmdConfigEdit(rest)
+	case "validate":
+		return cmdConfigValidate(rest)
+	case "profiles":
+		return cmdConfigProfiles(rest)
+	case "-h", "--help", "help":
+		return cmdConfig(nil)
+	default:
+		return fmt.Errorf("unknown config subcommand: %s", sub)
+	}
+}
+
+// cmdConfigGet prints one effective setting, unmasked so scripts can
+// use it.
+func cmdConfigGet(args []string) error {
+	fs := flag.NewFlagSet("config get", flag.ExitOnError)
+	showOrigin := fs.Bool("show-origin", false, "Also print where the value came from")
 	fs.Usage = func() {
-		fmt.Fprintln(fs.Output(), `Usage: iosuite endpoint benchmark [flags]
+		fmt.Fprintln(fs.Output(), `Usage: iosuite config get <key> [--show-origin]
 
-Run a tool's published benchmark workload against a deployed
-endpoint. The serve module owns the workload (input image, request
-shape, metrics); iosuite owns the wire.
+Print a setting's effective value: flag, env, config file (the
+selected profile's first) or built-in default. Exits 1 when the
+setting is empty.
 
 Flags:`)
 		fs.PrintDefaults()
 	}
-	if err := fs.Parse(args); err != nil {
+	key := parseInterspersed(fs, args)
+	if key == "" {
+		return fmt.Errorf("usage: iosuite config get <key> [--show-origin]")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
 		return err
 	}
-	if *provider != "runpod" {
-		return fmt.Errorf("provider %q is not supported (only 'runpod' is implemented)", *provider)
+	setting, err := cfg.Lookup(key)
+	if err != nil {
+		return err
 	}
-	if *endpointID == "" {
-		return fmt.Errorf("--endpoint-id is required")
+	if *showOrigin {
+		fmt.Printf("%s\t%s\n", originLabel(setting), setting.Value)
+	} else {
+		fmt.Println(setting.Value)
+	}
+	if setting.Value == "" {
+		return exitCode(1)
 	}
+	return nil
+}
 
-	cfg, err := config.Load()
+// cmdConfigSet writes one key, creating the file (0600) or section
+// as needed. Unknown keys and bad values are refused.
+func cmdConfigSet(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: iosuite config set <key> <value> (e.g. runpod.timeout 90s)")
+	}
+	section, key, err := config.ParseKey(args[0])
 	if err != nil {
 		return err
 	}
-	key := resolveRunpodAPIKey(*apiKey, cfg)
-	if key == "" {
-		return fmt.Errorf("RunPod API key required (--runpod-api-key, RUNPOD_API_KEY, or [runpod] api_key in config)")
+	if err := config.CheckValue(section, key, args[1]); err != nil {
+		return err
 	}
+	return editConfig(func(doc *config.Document) error {
+		return doc.Set(section, key, args[1])
+	})
+}
 
-	ctx := context.Background()
-
-	// Resolve the benchmark manifest. Local file wins (dev override);
-	// otherwise fetch from the *-serve repo at the requested tag.
-	var (
-		bench   *manifest.BenchmarkManifest
-		bSource string
-		baseURL string
-	)
-	if *benchmarkPath != "" {
-		bench, err = manifest.LoadBenchmarkFile(*benchmarkPath)
-		if err != nil {
-			return err
-		}
-		bSource = *benchmarkPath
-		// For input-resource resolution we use a file:// scheme so
-		// FetchInputResource reads from disk relative to the manifest.
-		// Caller may pass --input-resource explicitly to skip that.
-		baseURL = "file://" + *benchmarkPath
-	} else {
-		url, err := registry.BenchmarkURL(*tool, *manifestVersion)
-		if err != nil {
-			return err
-		}
-		bench, err = manifest.FetchBenchmark(ctx, url)
-		if err != nil {
-			return err
-		}
-		bSource = url
-		baseURL = url
+// cmdConfigUnset removes one key from the file, so it falls back to
+// env or the built-in default.
+func cmdConfigUnset(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: iosuite config unset <key>")
 	}
-
-	// Fetch the input image. --input-resource takes precedence; else
-	// resolve relative to the manifest's source.
-	var inputBytes []byte
-	if *inputResourcePath != "" {
-		inputBytes, err = os.ReadFile(*inputResourcePath)
-		if err != nil {
-			return fmt.Errorf("read --input-resource: %w", err)
-		}
-	} else {
-		inputBytes, err = manifest.FetchInputResource(ctx, baseURL, bench.InputResource)
-		if err != nil {
-			return err
+	// Not ParseKey: removing a misspelt key is how `config validate`
+	// findings get fixed.
+	section, key := config.SplitKey(args[0])
+	return editConfig(func(doc *config.Document) error {
+		found, err := doc.Unset(section, key)
+		if err == nil && !found {
+			err = fmt.Errorf("%s is not set in the config file", args[0])
 		}
+		return err
+	})
+}
+
+// editConfig applies change to the config file and writes it back
+// atomically. Edits are in place, so comments and layout survive.
+func editConfig(change func(*config.Document) error) error {
+	data, path, err := config.ReadFile()
+	if err != nil {
+		return err
 	}
+	doc, err := config.ParseDocument(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w (fix it with `iosuite config edit`)", path, err)
+	}
+	if err := change(doc); err != nil {
+		return err
+	}
+	return config.WriteFile(path, doc.Bytes())
+}
 
-	// inputBytes can be either raw image bytes OR an already-base64
-	// string (real-esrgan-serve ships its bench input pre-encoded).
-	// Detect the b64 case so we don't double-encode in buildRequestBody.
-	if isLikelyBase64(inputBytes) {
-		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(inputBytes)))
-		if err == nil {
-			inputBytes = decoded
-		}
+// cmdConfigList prints every effective setting. Secrets are masked.
+func cmdConfigList(args []string) error {
+	fs := flag.NewFlagSet("config list", flag.ExitOnError)
+	showOrigin := fs.Bool("show-origin", false, "Say whether each value came from a flag, env, the config file (with line) or a default")
+	env := fs.Bool("env", false, "List the environment variables that override settings instead, with their current values")
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	if *env {
+		vars := config.EnvVars()
+		return output.Render(os.Stdout, *out, vars, func(w io.Writer) {
+			for _, v := range vars {
+				val := v.Value
+				if val == "" {
+					val = "(unset)"
+				}
+				line := fmt.Sprintf("%-34s %-26s %s", v.Name, v.Setting, val)
+				if v.Note != "" {
+					line += "  # " + v.Note
+				}
+				fmt.Fprintln(w, line)
+			}
+		})
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	settings := cfg.Settings()
+	for i := range settings {
+		settings[i] = settings[i].Masked()
 	}
+	return output.Render(os.Stdout, *out, settings, func(w io.Writer) {
+		for _, st := range settings {
+			if *showOrigin {
+				fmt.Fprintf(w, "%-44s %s=%s\n", originLabel(st), st.Key, st.Value)
+			} else {
+				fmt.Fprintf(w, "%s=%s\n", st.Key, st.Value)
+			}
+		}
+	})
+}
 
-	fmt.Printf("benchmark: tool=%s endpoint=%s warmup=%d measure=%d\n",
-		bench.Tool, *endpointID, bench.Warmup, bench.Measure)
-	fmt.Printf("manifest:  %s\n", bSource)
-	fmt.Println()
+// originLabel renders a setting's origin like git's --show-origin:
+// "file:~/.config/iosuite/config.toml:4", "env:$RUNPOD_API_KEY".
+func originLabel(s config.Setting) string {
+	if s.Source == "" {
+		return s.Origin
+	}
+	return s.Origin + ":" + s.Source
+}
 
-	results, err := benchmark.Run(ctx, *endpointID, key, bench, inputBytes)
+func cmdConfigPath(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: iosuite config path")
+	}
+	path, err := config.Path()
 	if err != nil {
 		return err
 	}
-	fmt.Print(benchmark.FormatResults(results))
+	fmt.Println(path)
 	return nil
 }
 
-// isLikelyBase64 returns true if the input looks like base64-encoded
-// text (single line, only base64 alphabet chars). Real-esrgan-serve's
-// deploy/bench/*.b64 files ship in this form; a future tool might
-// ship raw bytes instead — both should work without operator
-// intervention.
-func isLikelyBase64(b []byte) bool {
-	s := strings.TrimSpace(string(b))
-	if s == "" || len(s) < 8 {
-		return false
+// cmdConfigEdit opens the config file in the user's editor and
+// validates the result, so a typo is caught before the next command
+// silently ignores it.
+func cmdConfigEdit(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: iosuite config edit")
 	}
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
-			c == '+' || c == '/' || c == '=' || c == '\n' || c == '\r' {
-			continue
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := config.WriteFile(path, []byte("# iosuite config — see `iosuite config --help`\n")); err != nil {
+			return err
 		}
-		return false
 	}
-	return true
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR may carry arguments ("code --wait").
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", editor, err)
+	}
+	return cmdConfigValidate(nil)
 }
 
-// resolveRunpodAPIKey applies the precedence: flag > env > config.
-// Empty return is a soft signal — let the underlying call surface
-// the actionable error (which mentions all three sources).
-func resolveRunpodAPIKey(flagVal string, cfg config.Config) string {
-	if flagVal != "" {
-		return flagVal
+// cmdConfigValidate reports problems Load would silently ignore.
+func cmdConfigValidate(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	data, path, err := config.ReadFile()
+	if err != nil {
+		return err
+	}
+	issues := config.Validate(data)
+	if issues == nil {
+		issues = []config.Issue{}
+	}
+	if err := output.Render(os.Stdout, *out, issues, func(w io.Writer) {
+		if len(issues) == 0 {
+			fmt.Fprintf(w, "%s: ok\n", path)
+		}
+		for _, is := range issues {
+			if is.Column > 0 {
+				fmt.Fprintf(w, "%s:%d:%d: %s\n", path, is.Line, is.Column, is.Message)
+			} else {
+				fmt.Fprintf(w, "%s:%d: %s\n", path, is.Line, is.Message)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
+
+// cmdConfigProfiles lists the declared profiles. API keys are shown
+// only as set / unset.
+func cmdConfigProfiles(args []string) error {
+	fs := flag.NewFlagSet("config profiles", flag.ExitOnError)
+	out := output.Register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	if env := os.Getenv("RUNPOD_API_KEY"); env != "" {
-		return env
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	// Load without applying the selection, so an unknown --profile
+	// still lists what exists.
+	cfg, err := config.Load()
+	if err != nil {
+		return err
 	}
-	return cfg.RunpodAPIKey
+	rows := cfg.ProfileSummaries(config.SelectedProfile(profileFlag))
+	return output.Render(os.Stdout, *out, rows, func(w io.Writer) {
+		if len(rows) == 0 {
+			fmt.Fprintln(w, "(no profiles; add a [profile.<name>] section to the config file)")
+			return
+		}
+		for _, p := range rows {
+			mark := " "
+			if p.Active {
+				mark = "*"
+			}
+			key := "no api key"
+			if p.APIKeySet {
+				key = "api key set"
+			}
+			provider, ep := p.Provider, p.EndpointID
+			if provider == "" {
+				provider = "-"
+			}
+			if ep == "" {
+				ep = "-"
+			}
+			fmt.Fprintf(w, "%s %-16s provider %-8s endpoint %-16s %s\n", mark, p.Name, provider, ep, key)
+		}
+	})
 }
 
 // cmdFetchModel forwards every flag to `real-esrgan-serve fetch-model`
@@ -1156,3 +2932,37 @@ func first(values ...string) string {
 	}
 	return ""
 }
+
+// splitList splits a comma-separated flag value, trimming blanks and
+// dropping empty entries. "" → nil.
+func splitList(v string) []string {
+	var out []string
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// kvFlag collects a repeatable KEY=VALUE flag (`--route a=b --route
+// c=d`) into a map. Later occurrences of the same key win.
+type kvFlag map[string]string
+
+func (f kvFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f kvFlag) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", v)
+	}
+	f[k] = strings.TrimSpace(val)
+	return nil
+}
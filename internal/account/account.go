@@ -0,0 +1,156 @@
+// Package account implements `iosuite account` — what a RunPod
+// account can afford before a big batch: credit balance, what it is
+// spending per hour and on which endpoints, and whether the GPU
+// pools a tool deploys to have capacity.
+package account
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"iosuite.io/internal/endpoint"
+	"iosuite.io/internal/runpod"
+)
+
+// Report is one `iosuite account` snapshot. JSON tags are iosuite's
+// own schema, like endpoint.StatusResult.
+type Report struct {
+	BalanceUSD    float64 `json:"balance_usd"`
+	SpendPerHrUSD float64 `json:"spend_per_hr_usd"`
+	// RunwayHours is how long the balance lasts at the current spend
+	// rate; omitted while nothing is running.
+	RunwayHours   *float64        `json:"runway_hours,omitempty"`
+	SpendLimitUSD float64         `json:"spend_limit_usd"` // hourly cap; 0 = none set
+	Endpoints     []EndpointSpend `json:"endpoints"`
+	Tool          string          `json:"tool,omitempty"`
+	GPUs          []PoolStock     `json:"gpus,omitempty"`
+	FetchedAt     time.Time       `json:"fetched_at"`
+}
+
+// EndpointSpend is one endpoint's current spend.
+type EndpointSpend struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	GPUPool        string  `json:"gpu_pool"`
+	RunningWorkers int     `json:"running_workers"`
+	SpendPerHrUSD  float64 `json:"spend_per_hr_usd"`
+}
+
+// PoolStock is the availability of one GPU class a tool deploys to.
+type PoolStock struct {
+	GPUClass string `json:"gpu_class"`
+	Pool     string `json:"pool"`
+	Stock    string `json:"stock"` // runpod.StockHigh … StockNone, StockUnknown
+}
+
+// Input is what Get needs. Pools is the tool's manifest gpu_pools
+// (GPU class → pool id); nil skips the GPU availability query.
+type Input struct {
+	APIKey    string
+	UserAgent string
+	Tool      string
+	Pools     map[string]string
+}
+
+// Get fetches the account snapshot.
+func Get(ctx context.Context, in Input) (*Report, error) {
+	if in.APIKey == "" {
+		return nil, endpoint.ErrNoAPIKey
+	}
+	rp := runpod.NewClient(in.APIKey, in.UserAgent)
+	acct, err := rp.GetAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read account: %w", err)
+	}
+	var catalogue []runpod.GPUType
+	if len(in.Pools) > 0 {
+		if catalogue, err = rp.ListGPUTypes(ctx); err != nil {
+			return nil, fmt.Errorf("read GPU availability: %w", err)
+		}
+	}
+	return newReport(acct, in.Tool, in.Pools, catalogue, time.Now().UTC()), nil
+}
+
+func newReport(acct *runpod.Account, tool string, pools map[string]string, catalogue []runpod.GPUType, at time.Time) *Report {
+	r := &Report{
+		BalanceUSD:    acct.Balance,
+		SpendPerHrUSD: acct.SpendPerHr,
+		SpendLimitUSD: acct.SpendLimit,
+		Endpoints:     make([]EndpointSpend, 0, len(acct.Endpoints)),
+		Tool:          tool,
+		FetchedAt:     at,
+	}
+	if acct.SpendPerHr > 0 {
+		h := acct.Balance / acct.SpendPerHr
+		r.RunwayHours = &h
+	}
+	for _, ep := range acct.Endpoints {
+		r.Endpoints = append(r.Endpoints, EndpointSpend{
+			ID:             ep.ID,
+			Name:           ep.Name,
+			GPUPool:        ep.GPUIDs,
+			RunningWorkers: ep.RunningWorkers(),
+			SpendPerHrUSD:  ep.SpendPerHr(),
+		})
+	}
+	// Biggest spenders first; name breaks ties so output is stable.
+	sort.Slice(r.Endpoints, func(i, j int) bool {
+		a, b := r.Endpoints[i], r.Endpoints[j]
+		if a.SpendPerHrUSD != b.SpendPerHrUSD {
+			return a.SpendPerHrUSD > b.SpendPerHrUSD
+		}
+		return a.Name < b.Name
+	})
+	classes := make([]string, 0, len(pools))
+	for cls := range pools {
+		classes = append(classes, cls)
+	}
+	sort.Strings(classes)
+	for _, cls := range classes {
+		r.GPUs = append(r.GPUs, PoolStock{
+			GPUClass: cls,
+			Pool:     pools[cls],
+			Stock:    runpod.PoolStock(catalogue, pools[cls]),
+		})
+	}
+	return r
+}
+
+// Print writes the human-friendly report.
+func Print(w io.Writer, r *Report) {
+	fmt.Fprintf(w, "balance:      $%.2f\n", r.BalanceUSD)
+	fmt.Fprintf(w, "spend rate:   $%.3f/hr", r.SpendPerHrUSD)
+	if r.RunwayHours != nil {
+		fmt.Fprintf(w, "  (runway %s)", formatRunway(*r.RunwayHours))
+	}
+	fmt.Fprintln(w)
+	if r.SpendLimitUSD > 0 {
+		fmt.Fprintf(w, "spend limit:  $%.2f/hr\n", r.SpendLimitUSD)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "endpoints:")
+	if len(r.Endpoints) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, ep := range r.Endpoints {
+		fmt.Fprintf(w, "  %-32s %-12s %d running  $%.3f/hr\n", ep.Name, ep.GPUPool, ep.RunningWorkers, ep.SpendPerHrUSD)
+	}
+	if len(r.GPUs) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "gpu availability (%s):\n", r.Tool)
+		for _, g := range r.GPUs {
+			fmt.Fprintf(w, "  %-12s %-12s %s\n", g.GPUClass, g.Pool, g.Stock)
+		}
+	}
+}
+
+// formatRunway renders hours as "3.5h" or, past two days, "12.3d".
+func formatRunway(h float64) string {
+	if h >= 48 {
+		return fmt.Sprintf("%.1fd", h/24)
+	}
+	return fmt.Sprintf("%.1fh", h)
+}
@@ -0,0 +1,87 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/runpod"
+)
+
+func stock(s string) *string { return &s }
+
+func testReport(pools map[string]string) *Report {
+	acct := &runpod.Account{
+		Balance:    41.27,
+		SpendPerHr: 1.38,
+		Endpoints: []runpod.EndpointSpend{
+			{ID: "ep2", Name: "ffmpeg-l4", GPUIDs: "AMPERE_24"},
+			{ID: "ep1", Name: "real-esrgan-rtx-4090", GPUIDs: "ADA_24", Workers: []runpod.WorkerSpend{
+				{ID: "w1", DesiredStatus: "RUNNING", CostPerHr: 0.69},
+				{ID: "w2", DesiredStatus: "RUNNING", CostPerHr: 0.69},
+			}},
+		},
+	}
+	var catalogue []runpod.GPUType
+	g := runpod.GPUType{ID: "NVIDIA GeForce RTX 4090"}
+	g.LowestPrice.StockStatus = stock(runpod.StockLow)
+	catalogue = append(catalogue, g)
+	return newReport(acct, "real-esrgan", pools, catalogue, time.Now())
+}
+
+func TestNewReport(t *testing.T) {
+	r := testReport(map[string]string{"rtx-4090": "ADA_24", "h200": "HOPPER_141"})
+	if r.RunwayHours == nil || *r.RunwayHours < 29.9 || *r.RunwayHours > 30 {
+		t.Errorf("runway = %v, want ~29.9h", r.RunwayHours)
+	}
+	if r.Endpoints[0].ID != "ep1" || r.Endpoints[0].RunningWorkers != 2 {
+		t.Errorf("endpoints not sorted by spend: %+v", r.Endpoints)
+	}
+	want := []PoolStock{
+		{GPUClass: "h200", Pool: "HOPPER_141", Stock: runpod.StockNone},
+		{GPUClass: "rtx-4090", Pool: "ADA_24", Stock: runpod.StockLow},
+	}
+	if len(r.GPUs) != len(want) || r.GPUs[0] != want[0] || r.GPUs[1] != want[1] {
+		t.Errorf("gpus = %+v, want %+v", r.GPUs, want)
+	}
+}
+
+func TestNewReport_NoSpendNoRunway(t *testing.T) {
+	r := newReport(&runpod.Account{Balance: 10}, "", nil, nil, time.Now())
+	if r.RunwayHours != nil {
+		t.Errorf("runway = %v, want omitted while idle", *r.RunwayHours)
+	}
+	if r.GPUs != nil {
+		t.Errorf("gpus = %+v, want none without a tool", r.GPUs)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, testReport(map[string]string{"rtx-4090": "ADA_24"}))
+	out := buf.String()
+	for _, want := range []string{
+		"balance:      $41.27",
+		"spend rate:   $1.380/hr  (runway 29.9h)",
+		"real-esrgan-rtx-4090",
+		"2 running  $1.380/hr",
+		"gpu availability (real-esrgan):",
+		"rtx-4090     ADA_24       Low",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "spend limit") {
+		t.Errorf("spend limit shown when unset:\n%s", out)
+	}
+}
+
+func TestGet_RejectsMissingAPIKey(t *testing.T) {
+	_, err := Get(context.Background(), Input{})
+	if err == nil || !strings.Contains(err.Error(), "RUNPOD_API_KEY") {
+		t.Fatalf("expected API key error, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Package auth keeps RunPod API keys out of config.toml. `iosuite
+// auth login` stores a key per profile either in an encrypted
+// credentials file next to the config, or — like git's credential
+// helpers — in whatever an external program keeps them in (a
+// password manager, the OS keychain).
+package auth
+
+// Store holds one API key per profile; profile "" is the key used
+// when no profile is selected.
+type Store interface {
+	// Get returns the stored key; found is false when there is none.
+	Get(profile string) (key string, found bool, err error)
+	Put(profile, key string) error
+	// Erase removes the key, reporting whether one was stored.
+	Erase(profile string) (found bool, err error)
+	// String says where keys are kept, for status output.
+	String() string
+}
+
+// ProfileLabel names a profile in messages.
+func ProfileLabel(profile string) string {
+	if profile == "" {
+		return "(no profile)"
+	}
+	return profile
+}
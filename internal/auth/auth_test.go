@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStore_MachineKey(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(dir)
+	if _, found, err := s.Get(""); found || err != nil {
+		t.Fatalf("empty store: found=%v err=%v", found, err)
+	}
+	if err := s.Put("", "rpa_base"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("prod", "rpa_prod"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{s.Path, s.KeyPath} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %v, want 0600", filepath.Base(path), fi.Mode().Perm())
+		}
+	}
+	data, _ := os.ReadFile(s.Path)
+	if bytes.Contains(data, []byte("rpa_")) {
+		t.Errorf("credentials file holds a key in the clear: %s", data)
+	}
+
+	// A fresh store over the same files reads both back.
+	s = NewFileStore(dir)
+	if key, found, err := s.Get("prod"); key != "rpa_prod" || !found || err != nil {
+		t.Errorf("Get(prod) = %q, %v, %v", key, found, err)
+	}
+	if found, err := s.Erase(""); !found || err != nil {
+		t.Fatalf("Erase = %v, %v", found, err)
+	}
+	if found, _ := s.Erase(""); found {
+		t.Error("Erase found a key twice")
+	}
+	if found, _ := s.Erase("prod"); !found {
+		t.Fatal("Erase(prod) found nothing")
+	}
+	if _, err := os.Stat(s.Path); !os.IsNotExist(err) {
+		t.Errorf("empty store left its file behind: %v", err)
+	}
+}
+
+func TestFileStore_Passphrase(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(dir)
+	if err := s.Put("", "rpa_machine"); err != nil {
+		t.Fatal(err)
+	}
+	// Switching an existing file to a passphrase re-seals it.
+	s.Seal = SealPassphrase
+	s.Passphrase = func() (string, error) { return "correct horse", nil }
+	if err := s.Put("staging", "rpa_staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	s = NewFileStore(dir)
+	if sealed, _ := s.Sealed(); sealed != SealPassphrase {
+		t.Errorf("Sealed = %q", sealed)
+	}
+	if _, _, err := s.Get(""); err != ErrNoPassphrase {
+		t.Errorf("no passphrase: err = %v", err)
+	}
+	s.Passphrase = func() (string, error) { return "wrong", nil }
+	if _, _, err := s.Get(""); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("wrong passphrase: err = %v", err)
+	}
+	s.Passphrase = func() (string, error) { return "correct horse", nil }
+	for profile, want := range map[string]string{"": "rpa_machine", "staging": "rpa_staging"} {
+		if key, _, err := s.Get(profile); key != want || err != nil {
+			t.Errorf("Get(%q) = %q, %v", profile, key, err)
+		}
+	}
+	// Later writes keep the passphrase without being told again.
+	if err := s.Put("prod", "rpa_prod"); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, _ := s.Sealed(); sealed != SealPassphrase {
+		t.Errorf("after Put, Sealed = %q", sealed)
+	}
+}
+
+func TestFileStore_MissingMachineKey(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	if err := s.Put("", "rpa_x"); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(s.KeyPath)
+	if _, _, err := s.Get(""); err == nil || !strings.Contains(err.Error(), "auth login") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// fakeHelper writes a credential helper that keeps keys in files
+// under dir, named after the profile.
+func fakeHelper(t *testing.T, dir string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("helper is a shell script")
+	}
+	script := `#!/bin/sh
+store=` + dir + `
+while IFS='=' read -r k v; do
+  [ -z "$k" ] && break
+  eval "in_$k=\$v"
+done
+f="$store/key-${in_profile:-default}"
+case "$1" in
+  get)   [ -f "$f" ] && printf 'api_key=%s\n' "$(cat "$f")" ;;
+  store) [ "$in_service" = runpod ] || exit 2; printf '%s' "$in_api_key" > "$f" ;;
+  erase) rm -f "$f" ;;
+esac
+exit 0
+`
+	path := filepath.Join(dir, "iosuite-credential-test")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHelperStore(t *testing.T) {
+	dir := t.TempDir()
+	fakeHelper(t, dir)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	h := HelperStore{Command: "test"}
+	if got := h.String(); got != "credential helper iosuite-credential-test" {
+		t.Errorf("String = %q", got)
+	}
+	if _, found, err := h.Get("prod"); found || err != nil {
+		t.Fatalf("Get before store: found=%v err=%v", found, err)
+	}
+	if err := h.Put("prod", "rpa_prod"); err != nil {
+		t.Fatal(err)
+	}
+	if key, found, err := h.Get("prod"); key != "rpa_prod" || !found || err != nil {
+		t.Errorf("Get = %q, %v, %v", key, found, err)
+	}
+	if _, err := h.Erase("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := h.Get("prod"); found {
+		t.Error("key survived Erase")
+	}
+	if err := (HelperStore{Command: "missing-helper"}).Put("", "k"); err == nil {
+		t.Error("missing helper reported success")
+	}
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"iosuite.io/internal/config"
+)
+
+// How a credentials file's encryption key is obtained.
+const (
+	// SealMachineKey uses a random key kept in a 0600 file beside the
+	// credentials: no prompt, and a copied credentials file alone is
+	// useless.
+	SealMachineKey = "machine-key"
+	// SealPassphrase derives the key from a passphrase (PBKDF2-SHA256),
+	// asked for whenever the key is read.
+	SealPassphrase = "passphrase"
+)
+
+// pbkdf2Iterations follows OWASP's 2023 guidance for PBKDF2-SHA256.
+const pbkdf2Iterations = 600_000
+
+// fileAAD binds the ciphertext to this format.
+var fileAAD = []byte("iosuite credentials v1")
+
+// ErrNoPassphrase is returned when a passphrase-sealed file must be
+// read or written and no passphrase is available.
+var ErrNoPassphrase = errors.New("credentials file is passphrase-protected: set $IOSUITE_PASSPHRASE or run in a terminal")
+
+// FileStore keeps keys in an AES-256-GCM encrypted JSON file.
+type FileStore struct {
+	Path    string // the credentials file
+	KeyPath string // the machine key, for SealMachineKey files
+	// Seal is how the next write is sealed; "" keeps the file's
+	// current method (SealMachineKey for a new file).
+	Seal string
+	// Passphrase supplies the passphrase for SealPassphrase files.
+	Passphrase func() (string, error)
+}
+
+// NewFileStore returns the store in dir (the config directory):
+// dir/credentials, sealed with dir/credentials.key by default.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{
+		Path:    filepath.Join(dir, "credentials"),
+		KeyPath: filepath.Join(dir, "credentials.key"),
+	}
+}
+
+func (s *FileStore) String() string { return "encrypted file " + s.Path }
+
+// sealedFile is the on-disk format. The plaintext is a JSON object of
+// profile → key.
+type sealedFile struct {
+	Version    int    `json:"version"`
+	Seal       string `json:"seal"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (s *FileStore) Get(profile string) (string, bool, error) {
+	keys, _, err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+	key, ok := keys[profile]
+	return key, ok, nil
+}
+
+func (s *FileStore) Put(profile, key string) error {
+	keys, seal, err := s.load()
+	if err != nil {
+		return err
+	}
+	keys[profile] = key
+	if s.Seal != "" {
+		seal = s.Seal
+	}
+	return s.save(keys, seal)
+}
+
+func (s *FileStore) Erase(profile string) (bool, error) {
+	keys, seal, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := keys[profile]; !ok {
+		return false, nil
+	}
+	delete(keys, profile)
+	if len(keys) == 0 {
+		if err := os.Remove(s.Path); err != nil {
+			return true, fmt.Errorf("remove %s: %w", s.Path, err)
+		}
+		return true, nil
+	}
+	return true, s.save(keys, seal)
+}
+
+// Sealed reports how the file is sealed, "" when there is no file.
+func (s *FileStore) Sealed() (string, error) {
+	sf, err := s.read()
+	if sf == nil || err != nil {
+		return "", err
+	}
+	return sf.Seal, nil
+}
+
+func (s *FileStore) read() (*sealedFile, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+	var sf sealedFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("read credentials %s: %w", s.Path, err)
+	}
+	if sf.Version != 1 {
+		return nil, fmt.Errorf("read credentials %s: unsupported version %d", s.Path, sf.Version)
+	}
+	return &sf, nil
+}
+
+// load decrypts the file; a missing file is an empty store, sealed
+// SealMachineKey.
+func (s *FileStore) load() (map[string]string, string, error) {
+	sf, err := s.read()
+	if err != nil {
+		return nil, "", err
+	}
+	if sf == nil {
+		return map[string]string{}, SealMachineKey, nil
+	}
+	aead, err := s.cipher(sf.Seal, sf.Salt, sf.Iterations, false)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := aead.Open(nil, sf.Nonce, sf.Ciphertext, fileAAD)
+	if err != nil {
+		if sf.Seal == SealPassphrase {
+			return nil, "", fmt.Errorf("decrypt %s: wrong passphrase", s.Path)
+		}
+		return nil, "", fmt.Errorf("decrypt %s: the file or %s is corrupt", s.Path, s.KeyPath)
+	}
+	keys := map[string]string{}
+	if err := json.Unmarshal(plain, &keys); err != nil {
+		return nil, "", fmt.Errorf("decrypt %s: %w", s.Path, err)
+	}
+	return keys, sf.Seal, nil
+}
+
+func (s *FileStore) save(keys map[string]string, seal string) error {
+	sf := sealedFile{Version: 1, Seal: seal, Nonce: make([]byte, 12)}
+	if seal == SealPassphrase {
+		sf.Iterations, sf.Salt = pbkdf2Iterations, make([]byte, 16)
+		rand.Read(sf.Salt)
+	}
+	aead, err := s.cipher(seal, sf.Salt, sf.Iterations, true)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	rand.Read(sf.Nonce)
+	sf.Ciphertext = aead.Seal(nil, sf.Nonce, plain, fileAAD)
+	data, err := json.MarshalIndent(sf, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteAtomic(s.Path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write credentials: %w", err)
+	}
+	return nil
+}
+
+// cipher builds the AES-GCM cipher for a seal method. create makes
+// the machine key when it doesn't exist yet.
+func (s *FileStore) cipher(seal string, salt []byte, iterations int, create bool) (cipher.AEAD, error) {
+	var key []byte
+	switch seal {
+	case SealMachineKey:
+		var err error
+		if key, err = s.machineKey(create); err != nil {
+			return nil, err
+		}
+	case SealPassphrase:
+		if s.Passphrase == nil {
+			return nil, ErrNoPassphrase
+		}
+		pass, err := s.Passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if pass == "" {
+			return nil, ErrNoPassphrase
+		}
+		if key, err = pbkdf2.Key(sha256.New, pass, salt, iterations, 32); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("credentials %s: unknown seal %q", s.Path, seal)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *FileStore) machineKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.KeyPath)
+	switch {
+	case err == nil && len(key) == 32:
+		return key, nil
+	case err == nil:
+		return nil, fmt.Errorf("machine key %s is corrupt", s.KeyPath)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("read machine key: %w", err)
+	case !create:
+		return nil, fmt.Errorf("machine key %s is missing; run `iosuite auth login` again", s.KeyPath)
+	}
+	key = make([]byte, 32)
+	rand.Read(key)
+	if err := config.WriteAtomic(s.KeyPath, key, 0o600); err != nil {
+		return nil, fmt.Errorf("write machine key: %w", err)
+	}
+	return key, nil
+}
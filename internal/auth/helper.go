@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HelperStore hands keys to an external credential helper, the way
+// git does. The helper runs as `<helper> get|store|erase` with
+// `key=value` lines on stdin, ended by a blank line:
+//
+//	service=runpod
+//	profile=<name, empty for none>
+//	api_key=<key>      (store only)
+//
+// For get it prints `api_key=<key>` on stdout, or nothing when it has
+// no key. A non-zero exit is an error. stderr passes through, so a
+// helper may talk to the user there.
+type HelperStore struct {
+	// Command is `[auth] helper`: a bare name runs
+	// iosuite-credential-<name> from PATH; a path runs as-is.
+	// Either may be followed by arguments.
+	Command string
+}
+
+func (h HelperStore) String() string { return "credential helper " + h.argv()[0] }
+
+func (h HelperStore) argv() []string {
+	argv := strings.Fields(h.Command)
+	if len(argv) == 0 {
+		return []string{"(none)"}
+	}
+	if !strings.ContainsRune(argv[0], '/') && !strings.ContainsRune(argv[0], filepath.Separator) {
+		argv[0] = "iosuite-credential-" + argv[0]
+	}
+	return argv
+}
+
+func (h HelperStore) run(action, profile, key string) ([]byte, error) {
+	if strings.TrimSpace(h.Command) == "" {
+		return nil, fmt.Errorf("no credential helper configured")
+	}
+	argv := append(h.argv(), action)
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "service=runpod\nprofile=%s\n", profile)
+	if key != "" {
+		fmt.Fprintf(&in, "api_key=%s\n", key)
+	}
+	in.WriteString("\n")
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = &in
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", argv[0], action, err)
+	}
+	return out, nil
+}
+
+func (h HelperStore) Get(profile string) (string, bool, error) {
+	out, err := h.run("get", profile, "")
+	if err != nil {
+		return "", false, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if key, ok := strings.CutPrefix(sc.Text(), "api_key="); ok && key != "" {
+			return key, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (h HelperStore) Put(profile, key string) error {
+	if strings.ContainsAny(key, "\r\n") {
+		return fmt.Errorf("API key contains a newline")
+	}
+	_, err := h.run("store", profile, key)
+	return err
+}
+
+// Erase asks the helper to forget the key. Helpers don't say whether
+// they had one, so found is always true on success.
+func (h HelperStore) Erase(profile string) (bool, error) {
+	_, err := h.run("erase", profile, "")
+	return err == nil, err
+}
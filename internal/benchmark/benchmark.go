@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/retry"
 )
 
 // runpodBaseForTesting points at api.runpod.ai for prod and a
@@ -38,73 +39,53 @@ var runpodBaseForTesting = "https://api.runpod.ai"
 
 // Result is the per-metric output of one run.
 type Result struct {
-	Name  string  // metric name from the benchmark manifest
-	Agg   string  // mean | p50 | p95 | p99 | max | min
-	Value float64 // computed aggregate
+	Name  string  `json:"name"`  // metric name from the benchmark manifest
+	Agg   string  `json:"agg"`   // mean | p50 | p95 | p99 | max | min
+	Value float64 `json:"value"` // computed aggregate
+}
+
+// Report is a whole run as `iosuite endpoint benchmark --output
+// json|yaml` prints it: what was run against what, then the metrics.
+// Load is set for --concurrency / --rps / --duration runs, whose
+// Measure is the number of requests sent. The golden files in
+// testdata pin the schema.
+type Report struct {
+	Tool       string      `json:"tool"`
+	EndpointID string      `json:"endpoint_id"`
+	Warmup     int         `json:"warmup"`
+	Measure    int         `json:"measure"`
+	Manifest   string      `json:"manifest"`
+	Results    []Result    `json:"results"`
+	Load       *LoadReport `json:"load,omitempty"`
 }
 
 // Run executes warmup + measure POSTs against the given RunPod
 // endpoint and returns one Result per declared metric.
 //
 // inputBytes is the raw bytes of the input resource named in
-// manifest.InputResource (the caller fetched it).
+// manifest.InputResource (the caller fetched it). policy governs
+// retries of individual POSTs — a transient gateway error costs one
+// backoff instead of the whole run. The zero Policy uses the retry
+// package defaults.
 func Run(
 	ctx context.Context,
 	endpointID, apiKey string,
 	man *manifest.BenchmarkManifest,
 	inputBytes []byte,
+	policy retry.Policy,
 ) ([]Result, error) {
-	if endpointID == "" {
-		return nil, fmt.Errorf("endpoint id required")
-	}
-	if apiKey == "" {
-		return nil, fmt.Errorf("RunPod API key required")
-	}
-
-	// Build the request body from the manifest's request_template,
-	// injecting the input.images list from inputBytes (base64-encoded).
-	// Operators sometimes look at this in TRACE — keep it readable.
-	body, err := buildRequestBody(man, inputBytes)
+	r, err := newRunner(endpointID, apiKey, man, inputBytes, policy)
 	if err != nil {
 		return nil, err
 	}
-	url := fmt.Sprintf("%s/v2/%s/runsync", runpodBaseForTesting, endpointID)
-	httpClient := &http.Client{Timeout: 12 * time.Minute}
-
-	post := func() (map[string]any, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-		req.Header.Set("Content-Type", "application/json")
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-		respBody, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
-		if err != nil {
-			return nil, err
-		}
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 300))
-		}
-		var env map[string]any
-		if err := json.Unmarshal(respBody, &env); err != nil {
-			return nil, fmt.Errorf("parse response: %w", err)
-		}
-		status, _ := env["status"].(string)
-		if status != "COMPLETED" {
-			return nil, fmt.Errorf("status=%s (want COMPLETED): %s", status, truncate(string(respBody), 300))
-		}
-		return env, nil
+	if r.policy.Breaker == nil {
+		r.policy.Breaker = retry.NewBreaker(0, 0)
 	}
 
 	// Warmup. We discard results; only purpose is to absorb cold-start
 	// variance so the measurement window reflects warm-pool perf.
 	for i := 0; i < man.Warmup; i++ {
-		if _, err := post(); err != nil {
+		if _, err := r.post(ctx); err != nil {
 			return nil, fmt.Errorf("warmup %d/%d: %w", i+1, man.Warmup, err)
 		}
 	}
@@ -116,29 +97,16 @@ func Run(
 		values[mt.From] = make([]float64, 0, man.Measure)
 	}
 	for i := 0; i < man.Measure; i++ {
-		env, err := post()
+		env, err := r.post(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("measure %d/%d: %w", i+1, man.Measure, err)
 		}
-		// Each metric's `from` is a numeric field on the worker's
-		// per-item output. Look it up under output.outputs[0].<from>
-		// (the standard real-esrgan-serve handler shape, mirrored by
-		// every iosuite-serve compatible worker).
-		out, _ := env["output"].(map[string]any)
-		outs, _ := out["outputs"].([]any)
-		var first map[string]any
-		if len(outs) > 0 {
-			first, _ = outs[0].(map[string]any)
+		sample, err := workerMetrics(env, man)
+		if err != nil {
+			return nil, fmt.Errorf("measure %d/%d: %w", i+1, man.Measure, err)
 		}
-		for _, mt := range man.Metrics {
-			if first == nil {
-				return nil, fmt.Errorf("measure %d/%d: response had no output.outputs[0] to read %q from", i+1, man.Measure, mt.From)
-			}
-			v, ok := numericField(first, mt.From)
-			if !ok {
-				return nil, fmt.Errorf("measure %d/%d: response.output.outputs[0].%s is missing or non-numeric", i+1, man.Measure, mt.From)
-			}
-			values[mt.From] = append(values[mt.From], v)
+		for from, v := range sample {
+			values[from] = append(values[from], v)
 		}
 	}
 
@@ -153,6 +121,106 @@ func Run(
 	return results, nil
 }
 
+// runner POSTs the benchmark request to one endpoint. Run uses it
+// one request at a time, RunLoad from many goroutines.
+type runner struct {
+	url, apiKey string
+	body        []byte
+	client      *http.Client
+	policy      retry.Policy
+}
+
+func newRunner(endpointID, apiKey string, man *manifest.BenchmarkManifest, inputBytes []byte, policy retry.Policy) (*runner, error) {
+	if endpointID == "" {
+		return nil, fmt.Errorf("endpoint id required")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("RunPod API key required")
+	}
+	// Build the request body from the manifest's request_template,
+	// injecting the input.images list from inputBytes (base64-encoded).
+	// Operators sometimes look at this in TRACE — keep it readable.
+	body, err := buildRequestBody(man, inputBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &runner{
+		url:    fmt.Sprintf("%s/v2/%s/runsync", runpodBaseForTesting, endpointID),
+		apiKey: apiKey,
+		body:   body,
+		client: &http.Client{Timeout: 12 * time.Minute},
+		policy: policy,
+	}, nil
+}
+
+// post sends one request and returns the COMPLETED response envelope.
+func (r *runner) post(ctx context.Context) (map[string]any, error) {
+	var respBody []byte
+	// A /runsync POST creates a job, so only failures that prove
+	// the job was never accepted are retried (non-idempotent).
+	err := r.policy.Do(ctx, false, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(r.body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		req, sent := retry.Track(req)
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return sent(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+		if err != nil {
+			return sent(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return retry.NewHTTPError(r.url, resp, truncate(string(b), 300))
+		}
+		respBody = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var env map[string]any
+	if err := json.Unmarshal(respBody, &env); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	status, _ := env["status"].(string)
+	if status != "COMPLETED" {
+		return nil, fmt.Errorf("status=%s (want COMPLETED): %s", status, truncate(string(respBody), 300))
+	}
+	return env, nil
+}
+
+// workerMetrics reads each metric's `from` field off a response,
+// keyed by that field name. Each is a numeric field on the worker's
+// per-item output, looked up under output.outputs[0].<from> (the
+// standard real-esrgan-serve handler shape, mirrored by every
+// iosuite-serve compatible worker).
+func workerMetrics(env map[string]any, man *manifest.BenchmarkManifest) (map[string]float64, error) {
+	out, _ := env["output"].(map[string]any)
+	outs, _ := out["outputs"].([]any)
+	var first map[string]any
+	if len(outs) > 0 {
+		first, _ = outs[0].(map[string]any)
+	}
+	sample := make(map[string]float64, len(man.Metrics))
+	for _, mt := range man.Metrics {
+		if first == nil {
+			return nil, fmt.Errorf("response had no output.outputs[0] to read %q from", mt.From)
+		}
+		v, ok := numericField(first, mt.From)
+		if !ok {
+			return nil, fmt.Errorf("response.output.outputs[0].%s is missing or non-numeric", mt.From)
+		}
+		sample[mt.From] = v
+	}
+	return sample, nil
+}
+
 func buildRequestBody(man *manifest.BenchmarkManifest, inputBytes []byte) ([]byte, error) {
 	// Deep-copy the request_template so we don't mutate the parsed
 	// manifest if Run gets called multiple times.
@@ -9,8 +9,10 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/retry"
 )
 
 func bench() *manifest.BenchmarkManifest {
@@ -35,6 +37,10 @@ func bench() *manifest.BenchmarkManifest {
 	}
 }
 
+// fastRetry keeps retry backoff in the millisecond range so the
+// transient-failure tests don't sleep.
+var fastRetry = retry.Policy{Initial: time.Millisecond, Max: time.Millisecond}
+
 func TestRun_HappyPath(t *testing.T) {
 	// Mock RunPod: returns COMPLETED + a synthetic exec_ms that
 	// increases per call so we can verify aggregation across
@@ -59,7 +65,7 @@ func TestRun_HappyPath(t *testing.T) {
 	runpodBaseForTesting = srv.URL
 	defer func() { runpodBaseForTesting = prevBase }()
 
-	results, err := Run(context.Background(), "test-endpoint-id", "test-key", bench(), []byte("fake-png"))
+	results, err := Run(context.Background(), "test-endpoint-id", "test-key", bench(), []byte("fake-png"), fastRetry)
 	if err != nil {
 		t.Fatalf("Run: %v", err)
 	}
@@ -95,7 +101,7 @@ func TestRun_RejectsNonCompletedStatus(t *testing.T) {
 	runpodBaseForTesting = srv.URL
 	defer func() { runpodBaseForTesting = prevBase }()
 
-	_, err := Run(context.Background(), "id", "key", bench(), []byte("fake"))
+	_, err := Run(context.Background(), "id", "key", bench(), []byte("fake"), fastRetry)
 	if err == nil {
 		t.Fatal("expected error on non-COMPLETED status")
 	}
@@ -104,6 +110,50 @@ func TestRun_RejectsNonCompletedStatus(t *testing.T) {
 	}
 }
 
+func TestRun_RetriesTransientGatewayErrors(t *testing.T) {
+	// Every other POST 503s. Without retries the first one would
+	// abort the run; with them the run completes and each logical
+	// request is counted once.
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			http.Error(w, "upstream hiccup", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"COMPLETED","output":{"outputs":[{"exec_ms":100}]}}`))
+	}))
+	defer srv.Close()
+	prevBase := runpodBaseForTesting
+	runpodBaseForTesting = srv.URL
+	defer func() { runpodBaseForTesting = prevBase }()
+
+	if _, err := Run(context.Background(), "id", "key", bench(), []byte("fake"), fastRetry); err != nil {
+		t.Fatalf("Run should ride out 503s: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 14 {
+		t.Errorf("call count = %d, want 14 (7 requests × 1 retry each)", got)
+	}
+}
+
+func TestRun_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	prevBase := runpodBaseForTesting
+	runpodBaseForTesting = srv.URL
+	defer func() { runpodBaseForTesting = prevBase }()
+
+	if _, err := Run(context.Background(), "id", "key", bench(), []byte("fake"), fastRetry); err == nil {
+		t.Fatal("expected error on 401")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("call count = %d, want 1", got)
+	}
+}
+
 func TestBuildRequestBody_InjectsImages(t *testing.T) {
 	body, err := buildRequestBody(bench(), []byte("hello-world"))
 	if err != nil {
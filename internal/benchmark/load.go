@@ -0,0 +1,409 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/retry"
+)
+
+// LoadOptions shapes a load test. Concurrency alone is a closed loop:
+// that many workers, each sending its next request as soon as the
+// last returns, so the offered load follows the endpoint's speed. RPS
+// is an open loop: requests start on a fixed schedule however slowly
+// the endpoint answers, with Concurrency (when set) capping how many
+// may be in flight.
+type LoadOptions struct {
+	Concurrency int
+	RPS         float64
+	// Duration is how long to keep starting requests; zero sends the
+	// manifest's `measure` count instead.
+	Duration time.Duration
+	// Policy governs retries of each POST. Unlike Run there is no
+	// default circuit breaker: under load it would turn the failures
+	// being measured into fast local rejections.
+	Policy retry.Policy
+	// Progress, when set, gets a status line every ProgressEvery.
+	Progress      io.Writer
+	ProgressEvery time.Duration
+}
+
+// Distribution summarises one timing across a load test's successful
+// requests, in milliseconds.
+type Distribution struct {
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Mean float64 `json:"mean"`
+	Max  float64 `json:"max"`
+}
+
+func distribution(xs []float64) Distribution {
+	return Distribution{
+		P50:  aggregate(xs, "p50"),
+		P95:  aggregate(xs, "p95"),
+		P99:  aggregate(xs, "p99"),
+		Mean: aggregate(xs, "mean"),
+		Max:  aggregate(xs, "max"),
+	}
+}
+
+// LoadReport is the outcome of RunLoad. Latency is measured by the
+// client, end to end including retries; Queue and Execution are
+// RunPod's delayTime and executionTime for the same requests, absent
+// when the responses don't carry them. Results are the manifest's
+// worker-side metrics over the successful requests; the JSON report
+// carries them once, as Report.Results.
+type LoadReport struct {
+	Mode        string  `json:"mode"` // "closed" | "open"
+	Concurrency int     `json:"concurrency,omitempty"`
+	TargetRPS   float64 `json:"target_rps,omitempty"`
+	Seconds     float64 `json:"seconds"` // first request to last response
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"`
+	// Dropped counts open-loop starts skipped because Concurrency
+	// requests were already in flight. They count as failures in
+	// ErrorRate: the load asked for was not served.
+	Dropped      int           `json:"dropped,omitempty"`
+	ErrorRate    float64       `json:"error_rate"`     // (errors + dropped) / (requests + dropped)
+	Throughput   float64       `json:"throughput_rps"` // successful requests per second
+	Latency      Distribution  `json:"latency_ms"`
+	Queue        *Distribution `json:"queue_ms,omitempty"`
+	Execution    *Distribution `json:"execution_ms,omitempty"`
+	ErrorSamples []string      `json:"error_samples,omitempty"` // first few distinct errors
+	// Interrupted is set when the run was cancelled before it
+	// finished; the numbers cover what completed until then.
+	Interrupted bool     `json:"interrupted,omitempty"`
+	Results     []Result `json:"-"`
+}
+
+// maxErrorSamples bounds LoadReport.ErrorSamples.
+const maxErrorSamples = 5
+
+// RunLoad runs the manifest's warmup one request at a time, then
+// drives the endpoint as opts describes. Failed requests are counted,
+// not fatal — an endpoint shedding load is a result. A 401, 403 or
+// 404 stops the run, since every later request would fail the same
+// way. Cancelling ctx after warmup ends the run early and returns
+// what it measured so far, marked Interrupted.
+func RunLoad(
+	ctx context.Context,
+	endpointID, apiKey string,
+	man *manifest.BenchmarkManifest,
+	inputBytes []byte,
+	opts LoadOptions,
+) (*LoadReport, error) {
+	if opts.Concurrency < 0 || opts.RPS < 0 || opts.Duration < 0 {
+		return nil, fmt.Errorf("concurrency, rps and duration must not be negative")
+	}
+	if opts.Concurrency == 0 && opts.RPS == 0 {
+		opts.Concurrency = 1
+	}
+	r, err := newRunner(endpointID, apiKey, man, inputBytes, opts.Policy)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < man.Warmup; i++ {
+		if _, err := r.post(ctx); err != nil {
+			return nil, fmt.Errorf("warmup %d/%d: %w", i+1, man.Warmup, err)
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	rec := &recorder{man: man, values: map[string][]float64{}}
+	do := func() {
+		start := time.Now()
+		env, err := r.post(ctx)
+		if err == nil {
+			err = rec.success(env, time.Since(start))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return // cut off by the end of the run, not a result
+			}
+			rec.failure(err)
+			if fatalStatus(err) {
+				cancel(err)
+			}
+		}
+	}
+
+	start := time.Now()
+	var deadline time.Time
+	if opts.Duration > 0 {
+		deadline = start.Add(opts.Duration)
+	}
+	stopProgress := rec.reportProgress(opts.Progress, opts.ProgressEvery, start)
+	rep := &LoadReport{Concurrency: opts.Concurrency}
+	if opts.RPS > 0 {
+		rep.Mode, rep.TargetRPS = "open", opts.RPS
+		rep.Dropped = openLoop(ctx, opts, man.Measure, start, deadline, do)
+	} else {
+		rep.Mode = "closed"
+		closedLoop(ctx, opts.Concurrency, man.Measure, deadline, do)
+	}
+	stopProgress()
+	if parent.Err() != nil {
+		rep.Interrupted = true
+	} else if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	rec.fill(rep, time.Since(start))
+	return rep, nil
+}
+
+// closedLoop runs workers that each send back-to-back until total
+// requests have started, or deadline passes when it is set.
+func closedLoop(ctx context.Context, workers, total int, deadline time.Time, do func()) {
+	var (
+		mu      sync.Mutex
+		started int
+	)
+	next := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if !deadline.IsZero() {
+			return time.Now().Before(deadline)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		started++
+		return started <= total
+	}
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for next() {
+				do()
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// openLoop starts a request every 1/RPS seconds, until total have
+// been scheduled or deadline passes, and returns how many starts it
+// dropped at the in-flight cap.
+func openLoop(ctx context.Context, opts LoadOptions, total int, start, deadline time.Time, do func()) int {
+	interval := time.Duration(float64(time.Second) / opts.RPS)
+	var slots chan struct{}
+	if opts.Concurrency > 0 {
+		slots = make(chan struct{}, opts.Concurrency)
+	}
+	var wg sync.WaitGroup
+	dropped := 0
+	for i := 0; ; i++ {
+		at := start.Add(time.Duration(i) * interval)
+		if deadline.IsZero() && i >= total || !deadline.IsZero() && !at.Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return dropped
+		case <-time.After(time.Until(at)):
+		}
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				dropped++
+				continue
+			}
+		}
+		wg.Go(func() {
+			do()
+			if slots != nil {
+				<-slots
+			}
+		})
+	}
+	wg.Wait()
+	return dropped
+}
+
+// fatalStatus reports errors no amount of load explains: a wrong key
+// or endpoint id.
+func fatalStatus(err error) bool {
+	var herr *retry.HTTPError
+	if !errors.As(err, &herr) {
+		return false
+	}
+	switch herr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// recorder collects per-request samples from concurrent workers.
+type recorder struct {
+	man *manifest.BenchmarkManifest
+
+	mu        sync.Mutex
+	latency   []float64
+	queue     []float64
+	execution []float64
+	values    map[string][]float64 // metric `from` field → samples
+	errors    int
+	samples   []string
+}
+
+func (rec *recorder) success(env map[string]any, took time.Duration) error {
+	sample, err := workerMetrics(env, rec.man)
+	if err != nil {
+		return err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.latency = append(rec.latency, float64(took)/float64(time.Millisecond))
+	if v, ok := numericField(env, "delayTime"); ok {
+		rec.queue = append(rec.queue, v)
+	}
+	if v, ok := numericField(env, "executionTime"); ok {
+		rec.execution = append(rec.execution, v)
+	}
+	for from, v := range sample {
+		rec.values[from] = append(rec.values[from], v)
+	}
+	return nil
+}
+
+func (rec *recorder) failure(err error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.errors++
+	msg := err.Error()
+	if len(rec.samples) < maxErrorSamples && !slices.Contains(rec.samples, msg) {
+		rec.samples = append(rec.samples, msg)
+	}
+}
+
+// reportProgress writes a status line to w every interval until the
+// returned stop is called. A nil w reports nothing.
+func (rec *recorder) reportProgress(w io.Writer, every time.Duration, start time.Time) (stop func()) {
+	if w == nil {
+		return func() {}
+	}
+	if every <= 0 {
+		every = 10 * time.Second
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		tick := time.NewTicker(every)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				rec.mu.Lock()
+				ok, failed := len(rec.latency), rec.errors
+				rec.mu.Unlock()
+				fmt.Fprintf(w, "  %5.0fs  ok=%d errors=%d\n", time.Since(start).Seconds(), ok, failed)
+			}
+		}
+	})
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (rec *recorder) fill(rep *LoadReport, elapsed time.Duration) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rep.Seconds = elapsed.Seconds()
+	rep.Errors = rec.errors
+	rep.Requests = len(rec.latency) + rec.errors
+	if offered := rep.Requests + rep.Dropped; offered > 0 {
+		rep.ErrorRate = float64(rec.errors+rep.Dropped) / float64(offered)
+	}
+	if rep.Seconds > 0 {
+		rep.Throughput = float64(len(rec.latency)) / rep.Seconds
+	}
+	rep.Latency = distribution(rec.latency)
+	if len(rec.queue) > 0 {
+		d := distribution(rec.queue)
+		rep.Queue = &d
+	}
+	if len(rec.execution) > 0 {
+		d := distribution(rec.execution)
+		rep.Execution = &d
+	}
+	rep.ErrorSamples = rec.samples
+	rep.Results = make([]Result, 0, len(rec.man.Metrics))
+	for _, mt := range rec.man.Metrics {
+		rep.Results = append(rep.Results, Result{
+			Name:  mt.Name,
+			Agg:   mt.Agg,
+			Value: aggregate(rec.values[mt.From], mt.Agg),
+		})
+	}
+}
+
+// Rows flattens the report's timings into Results, client-side
+// first, then the worker's: the shape FormatResults and the table
+// output print.
+func (rep *LoadReport) Rows() []Result {
+	var rows []Result
+	add := func(name string, d *Distribution) {
+		if d == nil {
+			return
+		}
+		rows = append(rows,
+			Result{Name: name, Agg: "p50", Value: d.P50},
+			Result{Name: name, Agg: "p95", Value: d.P95},
+			Result{Name: name, Agg: "p99", Value: d.P99},
+			Result{Name: name, Agg: "mean", Value: d.Mean},
+		)
+	}
+	add("client_latency_ms", &rep.Latency)
+	add("queue_ms", rep.Queue)
+	add("execution_ms", rep.Execution)
+	return append(rows, rep.Results...)
+}
+
+// FormatLoad renders a load test for people: the totals, then Rows.
+func FormatLoad(rep *LoadReport) string {
+	var b strings.Builder
+	switch rep.Mode {
+	case "open":
+		fmt.Fprintf(&b, "  mode         open loop, %g req/s", rep.TargetRPS)
+		if rep.Concurrency > 0 {
+			fmt.Fprintf(&b, ", at most %d in flight", rep.Concurrency)
+		}
+		b.WriteString("\n")
+	default:
+		fmt.Fprintf(&b, "  mode         closed loop, concurrency %d\n", rep.Concurrency)
+	}
+	fmt.Fprintf(&b, "  requests     %d in %.1fs, %d errors", rep.Requests, rep.Seconds, rep.Errors)
+	if rep.Dropped > 0 {
+		fmt.Fprintf(&b, ", %d dropped at the in-flight cap", rep.Dropped)
+	}
+	fmt.Fprintf(&b, " (%.1f%% failed)", 100*rep.ErrorRate)
+	if rep.Interrupted {
+		b.WriteString(", interrupted")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  throughput   %.2f req/s\n", rep.Throughput)
+	for _, msg := range rep.ErrorSamples {
+		fmt.Fprintf(&b, "  error        %s\n", msg)
+	}
+	b.WriteString("\n")
+	b.WriteString(FormatResults(rep.Rows()))
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/retry"
+)
+
+// loadServer stands in for RunPod: each request takes delay and
+// reports fixed delayTime / executionTime. It tracks the most
+// requests it saw in flight at once.
+func loadServer(t *testing.T, delay time.Duration, status func(n int32) int) (calls, peak *int32) {
+	t.Helper()
+	calls, peak = new(int32), new(int32)
+	var inFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		cur := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(peak)
+			if cur <= p || atomic.CompareAndSwapInt32(peak, p, cur) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		if code := status(n); code != http.StatusOK {
+			http.Error(w, "nope", code)
+			return
+		}
+		fmt.Fprint(w, `{"status":"COMPLETED","delayTime":40,"executionTime":60,"output":{"outputs":[{"exec_ms":55}]}}`)
+	}))
+	t.Cleanup(srv.Close)
+	prevBase := runpodBaseForTesting
+	runpodBaseForTesting = srv.URL
+	t.Cleanup(func() { runpodBaseForTesting = prevBase })
+	return calls, peak
+}
+
+func ok(int32) int { return http.StatusOK }
+
+var noRetry = retry.Policy{MaxAttempts: 1}
+
+func TestRunLoad_ClosedLoop(t *testing.T) {
+	calls, peak := loadServer(t, 20*time.Millisecond, ok)
+	man := bench()
+	man.Measure = 12
+	rep, err := RunLoad(context.Background(), "id", "key", man, []byte("fake"), LoadOptions{Concurrency: 4, Policy: noRetry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(calls); got != 14 {
+		t.Errorf("calls = %d, want 14 (warmup 2 + measure 12)", got)
+	}
+	if p := atomic.LoadInt32(peak); p < 2 || p > 4 {
+		t.Errorf("peak in flight = %d, want 2..4", p)
+	}
+	if rep.Mode != "closed" || rep.Requests != 12 || rep.Errors != 0 || rep.Throughput <= 0 {
+		t.Errorf("report = %+v", rep)
+	}
+	if rep.Latency.P50 < 20 {
+		t.Errorf("client p50 = %vms, want at least the server's 20ms", rep.Latency.P50)
+	}
+	if rep.Queue == nil || rep.Queue.P50 != 40 || rep.Execution == nil || rep.Execution.Mean != 60 {
+		t.Errorf("queue / execution = %+v / %+v, want 40 / 60", rep.Queue, rep.Execution)
+	}
+	if len(rep.Results) != 3 || rep.Results[0].Value != 55 {
+		t.Errorf("worker results = %+v", rep.Results)
+	}
+}
+
+func TestRunLoad_CountsErrors(t *testing.T) {
+	// After warmup, every third request is shed with a 503.
+	loadServer(t, 0, func(n int32) int {
+		if n > 2 && n%3 == 0 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	man := bench()
+	man.Measure = 9
+	rep, err := RunLoad(context.Background(), "id", "key", man, []byte("fake"), LoadOptions{Concurrency: 1, Policy: noRetry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Requests != 9 || rep.Errors != 3 || rep.ErrorRate != 1.0/3 {
+		t.Errorf("requests=%d errors=%d rate=%v, want 9 / 3 / 0.33", rep.Requests, rep.Errors, rep.ErrorRate)
+	}
+	if len(rep.ErrorSamples) != 1 || !strings.Contains(rep.ErrorSamples[0], "HTTP 503") {
+		t.Errorf("error samples = %q", rep.ErrorSamples)
+	}
+}
+
+func TestRunLoad_StopsOnAuthError(t *testing.T) {
+	calls, _ := loadServer(t, 0, func(n int32) int {
+		if n > 2 {
+			return http.StatusUnauthorized
+		}
+		return http.StatusOK
+	})
+	man := bench()
+	_, err := RunLoad(context.Background(), "id", "key", man, []byte("fake"), LoadOptions{Concurrency: 1, Duration: time.Minute, Policy: noRetry})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("err = %v, want the 401", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("calls = %d, want 3 (stopped at the first 401)", got)
+	}
+}
+
+func TestRunLoad_OpenLoop(t *testing.T) {
+	// 100 req/s for 200ms schedules 20 starts; each takes 50ms, so a
+	// cap of 2 in flight must drop some of them.
+	loadServer(t, 50*time.Millisecond, ok)
+	man := bench()
+	man.Warmup = 0
+	rep, err := RunLoad(context.Background(), "id", "key", man, []byte("fake"), LoadOptions{
+		RPS: 100, Concurrency: 2, Duration: 200 * time.Millisecond, Policy: noRetry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Mode != "open" || rep.TargetRPS != 100 {
+		t.Errorf("mode = %s at %v", rep.Mode, rep.TargetRPS)
+	}
+	if rep.Requests+rep.Dropped != 20 || rep.Dropped == 0 {
+		t.Errorf("requests=%d dropped=%d, want 20 starts with some dropped", rep.Requests, rep.Dropped)
+	}
+	if want := float64(rep.Dropped) / 20; rep.ErrorRate != want {
+		t.Errorf("error rate = %v, want %v counting the dropped starts", rep.ErrorRate, want)
+	}
+}
+
+func TestRunLoad_InterruptReturnsPartialReport(t *testing.T) {
+	loadServer(t, 10*time.Millisecond, ok)
+	man := bench()
+	man.Warmup = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	rep, err := RunLoad(ctx, "id", "key", man, []byte("fake"), LoadOptions{Concurrency: 2, Duration: time.Minute, Policy: noRetry})
+	if err != nil {
+		t.Fatalf("err = %v, want the partial report", err)
+	}
+	if !rep.Interrupted || rep.Requests == 0 || rep.Errors != 0 {
+		t.Errorf("report = %+v, want interrupted with requests and no errors", rep)
+	}
+}
+
+func TestLoadReport_Rows(t *testing.T) {
+	rep := &LoadReport{
+		Latency: Distribution{P50: 10, P95: 20, P99: 30, Mean: 12},
+		Results: []Result{{Name: "p50_ms", Agg: "p50", Value: 5}},
+	}
+	var names []string
+	for _, r := range rep.Rows() {
+		names = append(names, r.Name+"/"+r.Agg)
+	}
+	want := "client_latency_ms/p50 client_latency_ms/p95 client_latency_ms/p99 client_latency_ms/mean p50_ms/p50"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("rows = %s\nwant   %s", got, want)
+	}
+}
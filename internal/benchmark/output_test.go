@@ -0,0 +1,73 @@
+package benchmark
+
+import (
+	"bytes"
+	"testing"
+
+	"iosuite.io/internal/output"
+	"iosuite.io/internal/output/outputtest"
+)
+
+// TestGolden_Report pins the `--output json` schema of a benchmark
+// run. A diff here is a breaking change for scripts, not a fixture
+// refresh.
+func TestGolden_Report(t *testing.T) {
+	outputtest.Golden(t, "report.golden.json", &Report{
+		Tool:       "real-esrgan",
+		EndpointID: "abc123",
+		Warmup:     3,
+		Measure:    10,
+		Manifest:   "https://raw.githubusercontent.com/ls-ads/real-esrgan-serve/main/deploy/benchmark.json",
+		Results: []Result{
+			{Name: "p50_latency_ms", Agg: "p50", Value: 19},
+			{Name: "mean_latency_ms", Agg: "mean", Value: 18.25},
+		},
+	})
+}
+
+// TestGolden_LoadReport pins the `load` object a --concurrency /
+// --rps run adds.
+func TestGolden_LoadReport(t *testing.T) {
+	results := []Result{{Name: "p50_latency_ms", Agg: "p50", Value: 19}}
+	outputtest.Golden(t, "load_report.golden.json", &Report{
+		Tool:       "real-esrgan",
+		EndpointID: "abc123",
+		Warmup:     3,
+		Measure:    120,
+		Manifest:   "https://raw.githubusercontent.com/ls-ads/real-esrgan-serve/main/deploy/benchmark.json",
+		Results:    results,
+		Load: &LoadReport{
+			Mode:         "closed",
+			Concurrency:  4,
+			Seconds:      60,
+			Requests:     120,
+			Errors:       2,
+			ErrorRate:    2.0 / 120,
+			Throughput:   118.0 / 60,
+			Latency:      Distribution{P50: 1900, P95: 2400, P99: 2600, Mean: 1950, Max: 2700},
+			Queue:        &Distribution{P50: 40, P95: 300, P99: 500, Mean: 80, Max: 610},
+			Execution:    &Distribution{P50: 1700, P95: 1800, P99: 1850, Mean: 1720, Max: 1900},
+			ErrorSamples: []string{"runpod https://api.runpod.ai/v2/abc123/runsync: HTTP 429: throttled"},
+			Results:      results,
+		},
+	})
+}
+
+// TestReport_TableUsesTheJSONShape: `-o table` lays out the same
+// Report as json and yaml, so its columns are the JSON keys.
+func TestReport_TableUsesTheJSONShape(t *testing.T) {
+	rep := &Report{
+		Tool:    "real-esrgan",
+		Results: []Result{{Name: "p50_latency_ms", Agg: "p50", Value: 19}},
+		Load:    &LoadReport{Mode: "closed", Latency: Distribution{P95: 2400}},
+	}
+	var buf bytes.Buffer
+	opts := output.Options{Format: output.Table, Columns: []string{"tool", "load.latency_ms.p95", "results"}, NoHeaders: true}
+	if err := output.Render(&buf, opts, rep, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `real-esrgan  2400  [{"agg":"p50","name":"p50_latency_ms","value":19}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("table = %q, want %q", got, want)
+	}
+}
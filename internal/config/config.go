@@ -1,19 +1,22 @@
 // Package config loads + saves the iosuite user config from
-// `~/.config/iosuite/config.toml`. Precedence is enforced by callers:
-// flag > env > config file > built-in defaults. We just provide the
-// "config file" layer.
+// `~/.config/iosuite/config.toml`. Precedence is flag > env > config
+// file > built-in defaults: callers apply their flags, and this
+// package the rest — the file in Load, IOSUITE_<SECTION>_<KEY>
+// environment overrides in SelectProfile (see EnvName).
 //
-// Format is a hand-rolled tiny TOML — three sections, all string
-// fields. Pulling in a full TOML library for this would be silly and
-// add a build-graph dependency for ten lines of K=V parsing.
+// The file is TOML v1.0, read by internal/toml (our own parser, so
+// the build graph stays dependency-free). Every setting is a string.
 package config
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"iosuite.io/internal/toml"
 )
 
 // Config is the on-disk shape. Empty strings mean "fall through to env
@@ -27,14 +30,40 @@ type Config struct {
 	// [runpod]
 	RunpodAPIKey     string
 	RunpodEndpointID string
+	RunpodTimeout    string // Go duration per API request, e.g. "90s"; empty = 60s
+
+	// [auth]
+	AuthHelper string // external credential helper; empty = encrypted file
+
+	// [serve] — defaults for `iosuite serve` flags, keyed as in the
+	// file (bind, port, poll_max, ...). Missing = the flag's default.
+	Serve map[string]string
+
+	// [runpod.routes] — model or tool name → endpoint id (or
+	// `name:<endpoint name>`) for `iosuite serve --provider runpod`.
+	RunpodRoutes map[string]string
+
+	// [profile.<name>] — named accounts layered over the fields
+	// above by WithProfile.
+	Profiles map[string]Profile
+	// Profile is the profile WithProfile applied; "" = none.
+	Profile string
+
+	// Where Load found things, for `config list --show-origin`.
+	path          string
+	lines         map[string]int // dotted key → line in path
+	profileSource string         // "flag" / "env" that chose Profile
+
+	credentials Credentials // see WithCredentials
 }
 
 // Defaults are baked-in fallbacks. Used when the config file is
 // missing OR a field is empty in the file.
 func Defaults() Config {
 	return Config{
-		Provider: "local",
-		Model:    "realesrgan-x4plus",
+		Provider:      "local",
+		Model:         "realesrgan-x4plus",
+		RunpodTimeout: "60s",
 	}
 }
 
@@ -51,6 +80,16 @@ func Path() (string, error) {
 	return filepath.Join(home, ".config", "iosuite", "config.toml"), nil
 }
 
+// Dir is the directory holding config.toml. Other per-user state
+// (deploy history) lives alongside it.
+func Dir() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
 // Load reads the config file and merges it on top of Defaults().
 // A missing file is not an error — first-time users just get defaults.
 func Load() (Config, error) {
@@ -68,6 +107,7 @@ func Load() (Config, error) {
 	}
 	defer f.Close()
 
+	cfg.path = path
 	if err := merge(&cfg, f); err != nil {
 		return cfg, fmt.Errorf("parse config %s: %w", path, err)
 	}
@@ -76,38 +116,72 @@ func Load() (Config, error) {
 
 // merge applies the contents of an open TOML stream onto cfg. Fields
 // with empty values in the file are ignored (so partial config files
-// fall through to defaults). The parser handles `# comment` lines,
-// `[section]` headers, and `key = "value"` / `key = value` forms.
-func merge(cfg *Config, r *os.File) error {
-	scanner := bufio.NewScanner(r)
-	section := ""
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+// fall through to defaults), as are keys iosuite doesn't know —
+// `iosuite config validate` reports those. Each applied key's line is
+// recorded for `config list --show-origin`.
+func merge(cfg *Config, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	doc, err := toml.Parse(data)
+	if err != nil {
+		return err
+	}
+	// A profile exists once its table does, even with no keys set yet.
+	if profiles, ok := doc.Root["profile"].(map[string]any); ok {
+		for name := range profiles {
+			applyProfile(cfg, name, "", "")
 		}
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			section = strings.TrimSpace(line[1 : len(line)-1])
+	}
+	for _, k := range doc.Keys {
+		if k.InArray {
 			continue
 		}
-		eq := strings.IndexByte(line, '=')
-		if eq < 0 {
+		section, key := splitPath(k.Path)
+		if _, isTable := k.Value.(map[string]any); isTable || checkKey(section, key) != nil {
 			continue
 		}
-		key := strings.TrimSpace(line[:eq])
-		val := strings.TrimSpace(line[eq+1:])
-		// Strip an inline `# comment` after the value so users can
-		// annotate without breaking parsing.
-		if h := strings.Index(val, " #"); h >= 0 {
-			val = strings.TrimSpace(val[:h])
+		val, ok := scalar(k.Value)
+		if !ok {
+			return fmt.Errorf("line %d: %s.%s must be a string, number or boolean, not %s", k.Line, section, key, toml.TypeName(k.Value))
 		}
-		val = strings.Trim(val, `"'`)
 		if val == "" {
 			continue
 		}
 		apply(cfg, section, key, val)
+		cfg.record(section, key, k.Line)
+	}
+	return nil
+}
+
+// scalar renders a TOML string, integer, float or boolean the way a
+// setting holds it, so `port = 8080` reads as "8080" and the value
+// checks see it like any other. Arrays, tables and date-times have
+// no setting to be.
+func scalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// record notes the line a key was read from.
+func (c *Config) record(section, key string, line int) {
+	if section == "" {
+		section = "default"
+	}
+	if c.lines == nil {
+		c.lines = map[string]int{}
 	}
-	return scanner.Err()
+	c.lines[section+"."+key] = line
 }
 
 func apply(cfg *Config, section, key, val string) {
@@ -127,6 +201,50 @@ func apply(cfg *Config, section, key, val string) {
 			cfg.RunpodAPIKey = val
 		case "endpoint_id":
 			cfg.RunpodEndpointID = val
+		case "timeout":
+			cfg.RunpodTimeout = val
 		}
+	case "auth":
+		if key == "helper" {
+			cfg.AuthHelper = val
+		}
+	case "serve":
+		if cfg.Serve == nil {
+			cfg.Serve = map[string]string{}
+		}
+		cfg.Serve[key] = val
+	case "runpod.routes":
+		if cfg.RunpodRoutes == nil {
+			cfg.RunpodRoutes = map[string]string{}
+		}
+		cfg.RunpodRoutes[key] = val
+	default:
+		if name, ok := strings.CutPrefix(section, "profile."); ok {
+			applyProfile(cfg, name, key, val)
+		}
+	}
+}
+
+// value is the field apply sets for a fixed section's key.
+func (c Config) value(section, key string) string {
+	switch section + "." + key {
+	case "default.provider":
+		return c.Provider
+	case "default.output_dir":
+		return c.OutputDir
+	case "default.model":
+		return c.Model
+	case "runpod.api_key":
+		return c.RunpodAPIKey
+	case "runpod.endpoint_id":
+		return c.RunpodEndpointID
+	case "runpod.timeout":
+		return c.RunpodTimeout
+	case "auth.helper":
+		return c.AuthHelper
+	}
+	if section == "serve" {
+		return c.Serve[key]
 	}
+	return ""
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,7 +25,7 @@ func TestLoad_MissingFileFallsBackToDefaults(t *testing.T) {
 		t.Fatalf("Load with no file should not error, got: %v", err)
 	}
 	def := Defaults()
-	if cfg != def {
+	if !reflect.DeepEqual(cfg, def) {
 		t.Errorf("Load with no file should return Defaults() exactly: got %+v want %+v", cfg, def)
 	}
 }
@@ -41,6 +43,7 @@ provider = "runpod"
 
 [runpod]
 endpoint_id = "abc123"
+timeout = "90s"
 `
 	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(body), 0o644); err != nil {
 		t.Fatal(err)
@@ -59,6 +62,9 @@ endpoint_id = "abc123"
 	if cfg.RunpodEndpointID != "abc123" {
 		t.Errorf("RunpodEndpointID = %q, want %q", cfg.RunpodEndpointID, "abc123")
 	}
+	if cfg.RunpodTimeout != "90s" {
+		t.Errorf("RunpodTimeout = %q, want %q", cfg.RunpodTimeout, "90s")
+	}
 }
 
 func TestLoad_StripsQuotesAndInlineComments(t *testing.T) {
@@ -86,3 +92,116 @@ output_dir = '/tmp/out'  # single-quote form
 		t.Errorf("OutputDir = %q, want %q", cfg.OutputDir, "/tmp/out")
 	}
 }
+
+func TestLoad_RunpodRoutes(t *testing.T) {
+	dir := t.TempDir()
+	cfgDir := filepath.Join(dir, "iosuite")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	body := `[runpod]
+endpoint_id = "default-ep"
+
+[runpod.routes]
+realesrgan-x4plus = "esrgan-ep"
+"ffmpeg" = "name:ffmpeg-cpu"   # resolved at serve start
+`
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"realesrgan-x4plus": "esrgan-ep",
+		"ffmpeg":            "name:ffmpeg-cpu",
+	}
+	if !reflect.DeepEqual(cfg.RunpodRoutes, want) {
+		t.Errorf("RunpodRoutes = %v, want %v", cfg.RunpodRoutes, want)
+	}
+	if cfg.RunpodEndpointID != "default-ep" {
+		t.Errorf("RunpodEndpointID = %q, want %q", cfg.RunpodEndpointID, "default-ep")
+	}
+}
+
+func TestLoad_FullTOML(t *testing.T) {
+	cfg := loadBody(t, `provider = "runpod"   # top-level keys are [default]
+
+[runpod]
+api_key = "abc # not a comment"
+endpoint_id = """
+ep-multi"""
+routes = { "ffmpeg" = "name:ffmpeg-cpu", esrgan = 'esrgan-ep' }
+`)
+	if cfg.Provider != "runpod" || cfg.RunpodAPIKey != "abc # not a comment" || cfg.RunpodEndpointID != "ep-multi" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	want := map[string]string{"ffmpeg": "name:ffmpeg-cpu", "esrgan": "esrgan-ep"}
+	if !reflect.DeepEqual(cfg.RunpodRoutes, want) {
+		t.Errorf("RunpodRoutes = %v, want %v", cfg.RunpodRoutes, want)
+	}
+}
+
+func TestLoad_ReportsSyntaxErrorPosition(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "iosuite"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	body := "[runpod]\ntimeout = 90s\n"
+	if err := os.WriteFile(filepath.Join(dir, "iosuite", "config.toml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "line 2, column 11") {
+		t.Errorf("err = %v, want line 2, column 11", err)
+	}
+}
+
+func TestLoad_NonStringSetting(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "iosuite"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "iosuite", "config.toml")
+	if err := os.WriteFile(path, []byte("[serve]\nport = 8080\nretry_max_attempts = 3\ngpu_id = -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Serve["port"] != "8080" || cfg.Serve["retry_max_attempts"] != "3" || cfg.Serve["gpu_id"] != "-1" {
+		t.Errorf("serve = %v, want the numbers as strings", cfg.Serve)
+	}
+	if err := os.WriteFile(path, []byte("[runpod]\ntimeout = [90]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "line 2: runpod.timeout must be a string, number or boolean, not array") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestWriteFile_KeepsModeNewIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iosuite", "config.toml")
+	if err := WriteFile(path, []byte("a = \"1\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+		t.Errorf("new file mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("a = \"2\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	fi, _ := os.Stat(path)
+	data, _ := os.ReadFile(path)
+	if fi.Mode().Perm() != 0o640 || string(data) != "a = \"2\"\n" {
+		t.Errorf("rewrite: mode %v, data %q", fi.Mode().Perm(), data)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"iosuite.io/internal/toml"
+)
+
+// Document is a config file held as text, edited in place so
+// comments, blank lines and key order survive `iosuite config set`.
+type Document struct {
+	data   []byte
+	parsed *toml.Document
+}
+
+// ParseDocument parses a config file for editing. A file that isn't
+// valid TOML is an error positioned at the offending line and column.
+func ParseDocument(data []byte) (*Document, error) {
+	parsed, err := toml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{data: data, parsed: parsed}, nil
+}
+
+// Bytes renders the document, newline-terminated.
+func (d *Document) Bytes() []byte {
+	if len(d.data) == 0 || d.data[len(d.data)-1] == '\n' {
+		return d.data
+	}
+	return append(d.data, '\n')
+}
+
+// Entry is one key set in the file. Keys before any header are
+// reported in section "default", which is how Load reads them.
+type Entry struct {
+	Section string
+	Key     string
+	Value   string
+	Line    int // 1-based
+}
+
+// Entries returns every scalar-valued key in file order, numbers
+// and booleans rendered as strings.
+func (d *Document) Entries() []Entry {
+	var out []Entry
+	for _, k := range d.parsed.Keys {
+		if s, ok := scalar(k.Value); ok && !k.InArray {
+			section, key := splitPath(k.Path)
+			out = append(out, Entry{Section: section, Key: key, Value: s, Line: k.Line})
+		}
+	}
+	return out
+}
+
+// splitPath turns a key's path from the root into a section and key
+// the way `iosuite config` names them.
+func splitPath(path []string) (section, key string) {
+	if len(path) == 1 {
+		return "default", path[0]
+	}
+	return strings.Join(path[:len(path)-1], "."), path[len(path)-1]
+}
+
+// find returns key's record in section, or nil.
+func (d *Document) find(section, key string) *toml.Key {
+	for i, k := range d.parsed.Keys {
+		if sec, name := splitPath(k.Path); sec == section && name == key && !k.InArray {
+			return &d.parsed.Keys[i]
+		}
+	}
+	return nil
+}
+
+// insertionPoint returns where a new key in section goes — after the
+// section's last key, else after its header — and the table the line
+// will sit under, which the key must be written relative to. ok is
+// false when the section has neither.
+func (d *Document) insertionPoint(section string) (at int, table []string, ok bool) {
+	for _, t := range d.parsed.Tables {
+		if !t.Array && strings.Join(t.Path, ".") == section {
+			at, table, ok = t.End, t.Path, true
+		}
+	}
+	for _, k := range d.parsed.Keys {
+		if sec, _ := splitPath(k.Path); sec == section && !k.Inline && !k.InArray && k.End > at {
+			at, table, ok = d.lineEnd(k.End), k.Table, true
+		}
+	}
+	return at, table, ok
+}
+
+// lineEnd returns the offset just past the newline ending the line
+// that holds off.
+func (d *Document) lineEnd(off int) int {
+	if i := strings.IndexByte(string(d.data[off:]), '\n'); i >= 0 {
+		return off + i + 1
+	}
+	return len(d.data)
+}
+
+// lineStart returns the offset of the start of the line holding off.
+func (d *Document) lineStart(off int) int {
+	return strings.LastIndexByte(string(d.data[:off]), '\n') + 1
+}
+
+// Set writes key = value into section: in place (keeping the line's
+// indentation and inline comment) when the key exists, after the
+// section's last key when the section does, else in a new section at
+// the end of the file.
+func (d *Document) Set(section, key, value string) error {
+	quoted := quote(value)
+	if k := d.find(section, key); k != nil {
+		return d.splice(k.Start, k.End, quoted)
+	}
+	if at, table, ok := d.insertionPoint(section); ok {
+		path := append(strings.Split(section, "."), key)
+		if section == "default" && len(table) == 0 {
+			path = []string{key}
+		}
+		line := formatPath(path[len(table):]) + " = " + quoted + "\n"
+		if at == len(d.data) && at > 0 && d.data[at-1] != '\n' {
+			line = "\n" + line
+		}
+		return d.splice(at, at, line)
+	}
+	var b strings.Builder
+	if len(d.data) > 0 {
+		if d.data[len(d.data)-1] != '\n' {
+			b.WriteString("\n")
+		}
+		if !strings.HasSuffix(string(d.data), "\n\n") {
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&b, "[%s]\n%s = %s\n", formatPath(strings.Split(section, ".")), formatKey(key), quoted)
+	return d.splice(len(d.data), len(d.data), b.String())
+}
+
+// Unset removes key from section, reporting whether it was there.
+func (d *Document) Unset(section, key string) (bool, error) {
+	k := d.find(section, key)
+	if k == nil {
+		return false, nil
+	}
+	if k.Inline {
+		return false, fmt.Errorf("%s.%s is inside an inline table; remove it with `iosuite config edit`", section, key)
+	}
+	keyStart := d.lineStart(k.Start)
+	return true, d.splice(keyStart, d.lineEnd(k.End), "")
+}
+
+// splice replaces data[from:to] with s, keeping the edit only if the
+// result still parses.
+func (d *Document) splice(from, to int, s string) error {
+	data := slices.Concat(d.data[:from], []byte(s), d.data[to:])
+	parsed, err := toml.Parse(data)
+	if err != nil {
+		return fmt.Errorf("edit would leave the file invalid (%v); make it with `iosuite config edit`", err)
+	}
+	d.data, d.parsed = data, parsed
+	return nil
+}
+
+// quote renders value as a TOML basic string.
+func quote(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formatKey quotes keys that aren't bare TOML keys (route names can
+// be anything).
+func formatKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return quote(key)
+		}
+	}
+	return key
+}
+
+func formatPath(path []string) string {
+	parts := make([]string, len(path))
+	for i, k := range path {
+		parts[i] = formatKey(k)
+	}
+	return strings.Join(parts, ".")
+}
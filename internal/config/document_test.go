@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editBody = `# iosuite config
+provider = "local"
+
+[runpod]
+api_key = "k"   # rotate monthly
+  endpoint_id = 'ep1'
+
+[runpod.routes]
+realesrgan-x4plus = "esrgan-ep"
+`
+
+func parseDoc(t *testing.T, body string) *Document {
+	t.Helper()
+	d, err := ParseDocument([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDocument_SetInPlaceKeepsCommentsAndIndent(t *testing.T) {
+	d := parseDoc(t, editBody)
+	if err := d.Set("runpod", "api_key", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("runpod", "endpoint_id", "ep2"); err != nil {
+		t.Fatal(err)
+	}
+	want := `# iosuite config
+provider = "local"
+
+[runpod]
+api_key = "new"   # rotate monthly
+  endpoint_id = "ep2"
+
+[runpod.routes]
+realesrgan-x4plus = "esrgan-ep"
+`
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDocument_SetInsertsIntoSectionOrAppendsOne(t *testing.T) {
+	d := parseDoc(t, editBody)
+	for _, kv := range [][3]string{
+		{"default", "model", "x2"},          // after the headerless default keys
+		{"runpod", "timeout", "90s"},        // after the section's last key
+		{"runpod.routes", "ffmpeg.v2", "b"}, // quoted: not a bare key
+		{"profile.prod", "api_key", "pk"},   // new section
+	} {
+		if err := d.Set(kv[0], kv[1], kv[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `# iosuite config
+provider = "local"
+model = "x2"
+
+[runpod]
+api_key = "k"   # rotate monthly
+  endpoint_id = 'ep1'
+timeout = "90s"
+
+[runpod.routes]
+realesrgan-x4plus = "esrgan-ep"
+"ffmpeg.v2" = "b"
+
+[profile.prod]
+api_key = "pk"
+`
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDocument_SetIntoEmptyFile(t *testing.T) {
+	d := parseDoc(t, "")
+	if err := d.Set("default", "provider", "runpod"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(d.Bytes()), "[default]\nprovider = \"runpod\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDocument_Unset(t *testing.T) {
+	d := parseDoc(t, editBody)
+	if found, err := d.Unset("runpod", "endpoint_id"); !found || err != nil {
+		t.Fatalf("Unset = %v, %v", found, err)
+	}
+	for _, k := range [][2]string{{"runpod", "endpoint_id"}, {"default", "api_key"}} {
+		if found, _ := d.Unset(k[0], k[1]); found {
+			t.Errorf("Unset reported %s.%s, which isn't there", k[0], k[1])
+		}
+	}
+	if got := d.Entries(); len(got) != 3 || got[1] != (Entry{Section: "runpod", Key: "api_key", Value: "k", Line: 5}) {
+		t.Errorf("Entries = %+v", got)
+	}
+}
+
+func TestDocument_SetQuoting(t *testing.T) {
+	d := parseDoc(t, "")
+	for _, v := range []string{`say "hi" # not a comment`, "a\nb", `C:\path`} {
+		if err := d.Set("default", "model", v); err != nil {
+			t.Fatal(err)
+		}
+		if got := d.Entries()[0].Value; got != v {
+			t.Errorf("Set(%q) reads back as %q", v, got)
+		}
+	}
+	if got := string(d.Bytes()); got != "[default]\nmodel = \"C:\\\\path\"\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDocument_SetUnderDottedKeys(t *testing.T) {
+	d := parseDoc(t, `[runpod]
+api_key = "k"
+routes.ffmpeg = "a"
+`)
+	if err := d.Set("runpod.routes", "esrgan", "b"); err != nil {
+		t.Fatal(err)
+	}
+	want := `[runpod]
+api_key = "k"
+routes.ffmpeg = "a"
+routes.esrgan = "b"
+`
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDocument_InlineTables(t *testing.T) {
+	d := parseDoc(t, "[runpod]\nroutes = { ffmpeg = \"a\" }   # keep\n")
+	if err := d.Set("runpod.routes", "ffmpeg", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(d.Bytes()); got != "[runpod]\nroutes = { ffmpeg = \"b\" }   # keep\n" {
+		t.Errorf("in-place edit: got %q", got)
+	}
+	if err := d.Set("runpod.routes", "esrgan", "c"); err == nil || !strings.Contains(err.Error(), "config edit") {
+		t.Errorf("adding to an inline table: err = %v", err)
+	}
+	if _, err := d.Unset("runpod.routes", "ffmpeg"); err == nil {
+		t.Error("removing from an inline table should point at config edit")
+	}
+}
+
+func TestParseDocument_SyntaxError(t *testing.T) {
+	_, err := ParseDocument([]byte("[runpod]\napi_key = \"k\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2, column 13") {
+		t.Errorf("err = %v, want a line 2, column 13 error", err)
+	}
+}
+
+func TestDocument_RoundTripsThroughLoad(t *testing.T) {
+	d := parseDoc(t, editBody)
+	if err := d.Set("runpod", "timeout", "90s"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := loadBody(t, string(d.Bytes()))
+	if cfg.RunpodTimeout != "90s" || cfg.RunpodAPIKey != "k" || cfg.RunpodEndpointID != "ep1" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iosuite", "config.toml")
+	if err := WriteFile(path, []byte("a = 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("new file mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("a = 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	fi, _ = os.Stat(path)
+	if string(data) != "a = 2\n" || fi.Mode().Perm() != 0o640 {
+		t.Errorf("rewrite: %q mode %v, want new contents and the old mode", data, fi.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
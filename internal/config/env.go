@@ -0,0 +1,87 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"strings"
+)
+
+// EnvName is the variable that overrides a fixed setting:
+// IOSUITE_<SECTION>_<KEY>, e.g. IOSUITE_SERVE_POLL_MAX for
+// serve.poll_max. Routes and profile keys have none.
+func EnvName(dotted string) string {
+	return "IOSUITE_" + strings.ToUpper(strings.ReplaceAll(dotted, ".", "_"))
+}
+
+// envVar is one variable a setting is read from.
+type envVar struct {
+	name string
+	// belowFile: used only when the config file doesn't set the key.
+	belowFile bool
+	note      string
+}
+
+// envVars lists the variables for a setting, highest precedence
+// first: EnvName, then the names iosuite read before it had one,
+// which keep their old precedence.
+func envVars(dotted string) []envVar {
+	vars := []envVar{{name: EnvName(dotted)}}
+	switch dotted {
+	case "runpod.api_key":
+		vars[0].note = "a selected profile's api_key or stored key beats it"
+		vars = append(vars, envVar{name: "RUNPOD_API_KEY", note: "as " + EnvName(dotted)})
+	case "runpod.endpoint_id":
+		vars = append(vars, envVar{name: "RUNPOD_ENDPOINT_ID", belowFile: true, note: "below the config file"})
+	case "serve.poll_max":
+		vars = append(vars, envVar{name: "IOSUITE_POLL_MAX", note: "as " + EnvName(dotted)})
+	}
+	return vars
+}
+
+// fromEnv applies the first of s's variables that is set.
+func fromEnv(s *Setting) {
+	for _, v := range envVars(s.Key) {
+		if v.belowFile && s.Origin != OriginDefault {
+			continue
+		}
+		if val := os.Getenv(v.name); val != "" {
+			s.Value, s.Origin, s.Source = val, OriginEnv, "$"+v.name
+			return
+		}
+	}
+}
+
+// EnvVar is one environment variable `iosuite config list --env`
+// documents.
+type EnvVar struct {
+	Name    string `json:"name"`
+	Setting string `json:"setting"`
+	Value   string `json:"value,omitempty"` // as set now; secrets masked
+	Note    string `json:"note,omitempty"`
+}
+
+// EnvVars lists every variable that overrides a setting, in
+// `config list` order.
+func EnvVars() []EnvVar {
+	var out []EnvVar
+	for _, key := range settingKeys {
+		for _, v := range envVars(key) {
+			s := Setting{Value: os.Getenv(v.name), Secret: key == "runpod.api_key"}
+			out = append(out, EnvVar{Name: v.name, Setting: key, Value: s.Masked().Value, Note: v.note})
+		}
+	}
+	return out
+}
+
+// withEnv copies the environment overrides Lookup finds into c's
+// fields, so code reading them sees what `config list` reports.
+func (c Config) withEnv() Config {
+	c.Serve = maps.Clone(c.Serve)
+	for _, dotted := range settingKeys {
+		if s, _ := c.Lookup(dotted); s.Origin == OriginEnv {
+			section, key := SplitKey(dotted)
+			apply(&c, section, key, s.Value)
+		}
+	}
+	return c
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"testing"
+)
+
+const serveBody = `[default]
+provider = "local"
+
+[runpod]
+endpoint_id = "file-ep"
+
+[serve]
+bind     = "127.0.0.1"
+poll_max = "5m"
+`
+
+func TestEnvName(t *testing.T) {
+	for key, want := range map[string]string{
+		"default.provider": "IOSUITE_DEFAULT_PROVIDER",
+		"runpod.timeout":   "IOSUITE_RUNPOD_TIMEOUT",
+		"serve.poll_max":   "IOSUITE_SERVE_POLL_MAX",
+	} {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSelectProfile_AppliesEnv(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("IOSUITE_DEFAULT_PROVIDER", "runpod")
+	t.Setenv("IOSUITE_SERVE_BIND", "0.0.0.0")
+	t.Setenv("IOSUITE_SERVE_PORT", "9000")
+	loaded := loadBody(t, serveBody)
+	cfg, err := loaded.SelectProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Provider != "runpod" || cfg.Serve["bind"] != "0.0.0.0" || cfg.Serve["port"] != "9000" {
+		t.Errorf("fields = %q %v", cfg.Provider, cfg.Serve)
+	}
+	if loaded.Serve["bind"] != "127.0.0.1" {
+		t.Error("applying the environment changed the loaded config's [serve] table")
+	}
+	m := settingMap(t, cfg)
+	for key, want := range map[string][3]string{
+		"default.provider": {"runpod", OriginEnv, "$IOSUITE_DEFAULT_PROVIDER"},
+		"serve.bind":       {"0.0.0.0", OriginEnv, "$IOSUITE_SERVE_BIND"},
+		"serve.poll_max":   {"5m", OriginFile, ""},
+		"serve.mode":       {"", OriginDefault, ""},
+	} {
+		s := m[key]
+		if s.Value != want[0] || s.Origin != want[1] || (want[2] != "" && s.Source != want[2]) {
+			t.Errorf("%s = %+v, want %q from %s %s", key, s, want[0], want[1], want[2])
+		}
+	}
+}
+
+func TestLookup_EnvAliases(t *testing.T) {
+	for _, tc := range []struct {
+		name, key  string
+		env        map[string]string
+		want, from string
+	}{
+		{"legacy endpoint id is below the file", "runpod.endpoint_id",
+			map[string]string{"RUNPOD_ENDPOINT_ID": "legacy-ep"}, "file-ep", ""},
+		{"new endpoint id beats the file", "runpod.endpoint_id",
+			map[string]string{"RUNPOD_ENDPOINT_ID": "legacy-ep", "IOSUITE_RUNPOD_ENDPOINT_ID": "env-ep"}, "env-ep", "$IOSUITE_RUNPOD_ENDPOINT_ID"},
+		{"old poll max name", "serve.poll_max",
+			map[string]string{"IOSUITE_POLL_MAX": "20m"}, "20m", "$IOSUITE_POLL_MAX"},
+		{"new poll max name wins", "serve.poll_max",
+			map[string]string{"IOSUITE_POLL_MAX": "20m", "IOSUITE_SERVE_POLL_MAX": "30m"}, "30m", "$IOSUITE_SERVE_POLL_MAX"},
+		{"api key", "runpod.api_key",
+			map[string]string{"RUNPOD_API_KEY": "old", "IOSUITE_RUNPOD_API_KEY": "new"}, "new", "$IOSUITE_RUNPOD_API_KEY"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, v := range []string{"RUNPOD_ENDPOINT_ID", "IOSUITE_RUNPOD_ENDPOINT_ID", "IOSUITE_POLL_MAX", "IOSUITE_SERVE_POLL_MAX", "RUNPOD_API_KEY", "IOSUITE_RUNPOD_API_KEY"} {
+				t.Setenv(v, tc.env[v])
+			}
+			s, err := loadBody(t, serveBody).Lookup(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Value != tc.want || s.Source != tc.from && tc.from != "" {
+				t.Errorf("%s = %+v, want %q from %s", tc.key, s, tc.want, tc.from)
+			}
+		})
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	t.Setenv("RUNPOD_API_KEY", "rpa_secretkey")
+	vars := map[string]EnvVar{}
+	for _, v := range EnvVars() {
+		vars[v.Name] = v
+	}
+	for name, setting := range map[string]string{
+		"IOSUITE_DEFAULT_OUTPUT_DIR":       "default.output_dir",
+		"IOSUITE_AUTH_HELPER":              "auth.helper",
+		"IOSUITE_SERVE_RETRY_BUDGET":       "serve.retry_budget",
+		"RUNPOD_ENDPOINT_ID":               "runpod.endpoint_id",
+		"IOSUITE_POLL_MAX":                 "serve.poll_max",
+		"IOSUITE_SERVE_RETRY_MAX_ATTEMPTS": "serve.retry_max_attempts",
+	} {
+		if v, ok := vars[name]; !ok || v.Setting != setting {
+			t.Errorf("%s = %+v, want setting %s", name, v, setting)
+		}
+	}
+	if v := vars["RUNPOD_API_KEY"].Value; v != "****tkey" {
+		t.Errorf("RUNPOD_API_KEY value = %q, want masked", v)
+	}
+}
+
+func TestCheckValue_Serve(t *testing.T) {
+	for _, tc := range []struct {
+		key, val string
+		ok       bool
+	}{
+		{"port", "8312", true},
+		{"port", "70000", false},
+		{"gpu_id", "-1", true},
+		{"gpu_id", "gpu0", false},
+		{"mode", "stream", true},
+		{"mode", "async", false},
+		{"poll_max", "10m", true},
+		{"poll_max", "ten", false},
+		{"retry_max_attempts", "0", false},
+		{"breaker_threshold", "3", true},
+		{"breaker_threshold", "0", false},
+		{"breaker_cooldown", "1m", true},
+		{"breaker_cooldown", "soon", false},
+		{"bind", "0.0.0.0", true},
+	} {
+		if err := CheckValue("serve", tc.key, tc.val); (err == nil) != tc.ok {
+			t.Errorf("CheckValue(serve, %s, %q) = %v", tc.key, tc.val, err)
+		}
+	}
+}
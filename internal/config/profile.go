@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProfileEnv selects a profile when --profile isn't passed.
+const ProfileEnv = "IOSUITE_PROFILE"
+
+// Profile is one `[profile.<name>]` section: a separate RunPod
+// account (staging, prod) with its own key and default endpoint.
+// Empty fields inherit the top-level value.
+//
+//	[profile.staging]
+//	api_key     = "..."
+//	endpoint_id = "abc123"
+//
+//	[profile.staging.routes]
+//	realesrgan-x4plus = "name:real-esrgan-rtx-4090"
+type Profile struct {
+	Provider         string
+	RunpodAPIKey     string
+	RunpodEndpointID string
+	RunpodTimeout    string
+	RunpodRoutes     map[string]string
+}
+
+// ProfileNames returns the declared profiles, sorted.
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectedProfile is the profile to apply: flagVal when set, else
+// $IOSUITE_PROFILE, else "" (none).
+func SelectedProfile(flagVal string) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	return os.Getenv(ProfileEnv)
+}
+
+// WithProfile returns c with profile name's non-empty fields over the
+// top-level ones and Profile set. Routes replace the top-level table
+// rather than merging with it, so one account's endpoint ids never
+// leak into another's. name "" returns c unchanged; an undeclared
+// name is an error listing the declared ones.
+func (c Config) WithProfile(name string) (Config, error) {
+	if name == "" {
+		return c, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		known := "none declared"
+		if names := c.ProfileNames(); len(names) > 0 {
+			known = "declared: " + strings.Join(names, ", ")
+		}
+		return c, fmt.Errorf("unknown profile %q (%s)", name, known)
+	}
+	if p.Provider != "" {
+		c.Provider = p.Provider
+	}
+	if p.RunpodAPIKey != "" {
+		c.RunpodAPIKey = p.RunpodAPIKey
+	}
+	if p.RunpodEndpointID != "" {
+		c.RunpodEndpointID = p.RunpodEndpointID
+	}
+	if p.RunpodTimeout != "" {
+		c.RunpodTimeout = p.RunpodTimeout
+	}
+	if p.RunpodRoutes != nil {
+		c.RunpodRoutes = p.RunpodRoutes
+	}
+	c.Profile = name
+	return c, nil
+}
+
+// ActiveProfile returns the applied profile, and false when none is.
+func (c Config) ActiveProfile() (Profile, bool) {
+	if c.Profile == "" {
+		return Profile{}, false
+	}
+	p, ok := c.Profiles[c.Profile]
+	return p, ok
+}
+
+// applyProfile handles keys under [profile.<name>] and
+// [profile.<name>.routes]. An empty key just declares the profile.
+func applyProfile(cfg *Config, section, key, val string) {
+	name, sub, _ := strings.Cut(section, ".")
+	name = strings.Trim(name, `"`)
+	if name == "" {
+		return
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	p := cfg.Profiles[name]
+	if key == "" {
+		cfg.Profiles[name] = p
+		return
+	}
+	switch sub {
+	case "":
+		switch key {
+		case "provider":
+			p.Provider = val
+		case "api_key":
+			p.RunpodAPIKey = val
+		case "endpoint_id":
+			p.RunpodEndpointID = val
+		case "timeout":
+			p.RunpodTimeout = val
+		}
+	case "routes":
+		if p.RunpodRoutes == nil {
+			p.RunpodRoutes = map[string]string{}
+		}
+		p.RunpodRoutes[key] = val
+	}
+	cfg.Profiles[name] = p
+}
+
+// ProfileSummary is one row of `iosuite config profiles`. The API
+// key itself is never listed, only whether the profile sets one.
+type ProfileSummary struct {
+	Name       string `json:"name"`
+	Active     bool   `json:"active"`
+	Provider   string `json:"provider"`
+	EndpointID string `json:"endpoint_id"`
+	APIKeySet  bool   `json:"api_key_set"`
+	Routes     int    `json:"routes"`
+}
+
+// ProfileSummaries lists the declared profiles, marking active (the
+// name WithProfile was or would be given) as such.
+func (c Config) ProfileSummaries(active string) []ProfileSummary {
+	out := make([]ProfileSummary, 0, len(c.Profiles))
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		out = append(out, ProfileSummary{
+			Name:       name,
+			Active:     name == active,
+			Provider:   p.Provider,
+			EndpointID: p.RunpodEndpointID,
+			APIKeySet:  p.RunpodAPIKey != "",
+			Routes:     len(p.RunpodRoutes),
+		})
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadBody(t *testing.T, body string) Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfgDir := filepath.Join(dir, "iosuite")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+const profilesBody = `[default]
+provider = "local"
+
+[runpod]
+api_key     = "base-key"
+endpoint_id = "base-ep"
+
+[runpod.routes]
+realesrgan-x4plus = "base-route"
+
+[profile.staging]
+provider    = "runpod"
+api_key     = "staging-key"
+endpoint_id = "staging-ep"
+timeout     = "90s"
+
+[profile.staging.routes]
+ffmpeg = "name:ffmpeg-staging"
+
+[profile.prod]
+api_key = "prod-key"
+
+[profile.empty]
+`
+
+func TestLoad_Profiles(t *testing.T) {
+	cfg := loadBody(t, profilesBody)
+	if got, want := cfg.ProfileNames(), []string{"empty", "prod", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileNames = %v, want %v", got, want)
+	}
+	if cfg.Profile != "" || cfg.RunpodAPIKey != "base-key" {
+		t.Errorf("Load applied a profile: %+v", cfg)
+	}
+}
+
+func TestWithProfile_Overlays(t *testing.T) {
+	cfg, err := loadBody(t, profilesBody).WithProfile("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "staging" || cfg.Provider != "runpod" || cfg.RunpodAPIKey != "staging-key" ||
+		cfg.RunpodEndpointID != "staging-ep" || cfg.RunpodTimeout != "90s" {
+		t.Errorf("staging = %+v", cfg)
+	}
+	// Routes replace, never merge: base endpoint ids belong to another
+	// account.
+	if want := map[string]string{"ffmpeg": "name:ffmpeg-staging"}; !reflect.DeepEqual(cfg.RunpodRoutes, want) {
+		t.Errorf("RunpodRoutes = %v, want %v", cfg.RunpodRoutes, want)
+	}
+}
+
+func TestWithProfile_InheritsUnsetFields(t *testing.T) {
+	cfg, err := loadBody(t, profilesBody).WithProfile("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RunpodAPIKey != "prod-key" || cfg.RunpodEndpointID != "base-ep" || cfg.Provider != "local" {
+		t.Errorf("prod = %+v", cfg)
+	}
+	if p, ok := cfg.ActiveProfile(); !ok || p.RunpodAPIKey != "prod-key" {
+		t.Errorf("ActiveProfile = %+v, %t", p, ok)
+	}
+}
+
+func TestWithProfile_Unknown(t *testing.T) {
+	_, err := loadBody(t, profilesBody).WithProfile("qa")
+	if err == nil || !strings.Contains(err.Error(), `"qa"`) || !strings.Contains(err.Error(), "empty, prod, staging") {
+		t.Fatalf("err = %v", err)
+	}
+	_, err = Defaults().WithProfile("qa")
+	if err == nil || !strings.Contains(err.Error(), "none declared") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSelectedProfile(t *testing.T) {
+	t.Setenv(ProfileEnv, "prod")
+	if got := SelectedProfile(""); got != "prod" {
+		t.Errorf("env: got %q", got)
+	}
+	if got := SelectedProfile("staging"); got != "staging" {
+		t.Errorf("flag should win: got %q", got)
+	}
+}
+
+func TestProfileSummaries(t *testing.T) {
+	got := loadBody(t, profilesBody).ProfileSummaries("prod")
+	want := []ProfileSummary{
+		{Name: "empty"},
+		{Name: "prod", Active: true, APIKeySet: true},
+		{Name: "staging", Provider: "runpod", EndpointID: "staging-ep", APIKeySet: true, Routes: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileSummaries =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"iosuite.io/internal/toml"
+)
+
+// sectionKeys are the keys each fixed section may hold. Keep in step
+// with apply.
+var sectionKeys = map[string][]string{
+	"default": {"provider", "output_dir", "model"},
+	"runpod":  {"api_key", "endpoint_id", "timeout"},
+	"auth":    {"helper"},
+	"serve": {"bind", "port", "gpu_id", "mode", "poll_max", "poll_initial",
+		"poll_interval_max", "retry_max_attempts", "retry_budget",
+		"breaker_threshold", "breaker_cooldown"},
+}
+
+// profileKeys are the keys a [profile.<name>] section may hold. Keep
+// in step with applyProfile.
+var profileKeys = []string{"provider", "api_key", "endpoint_id", "timeout"}
+
+// Providers are the values `provider` accepts.
+var Providers = []string{"local", "runpod"}
+
+// ServeModes are the values `serve.mode` accepts.
+var ServeModes = []string{"sync", "stream"}
+
+// sectionKind classifies a section name: fixed, a routes table (any
+// key), a profile, or unknown ("").
+func sectionKind(section string) string {
+	if _, ok := sectionKeys[section]; ok {
+		return "fixed"
+	}
+	if section == "runpod.routes" {
+		return "routes"
+	}
+	if rest, ok := strings.CutPrefix(section, "profile."); ok {
+		name, sub, hasSub := strings.Cut(rest, ".")
+		switch {
+		case name == "":
+			return ""
+		case !hasSub:
+			return "profile"
+		case sub == "routes":
+			return "routes"
+		}
+	}
+	return ""
+}
+
+// ParseKey splits a dotted setting name as `iosuite config` takes it
+// into section and key (see SplitKey). Unknown names are an error
+// with a did-you-mean suggestion.
+func ParseKey(dotted string) (section, key string, err error) {
+	section, key = SplitKey(dotted)
+	if err := checkKey(section, key); err != nil {
+		return "", "", err
+	}
+	return section, key, nil
+}
+
+// SplitKey splits a dotted setting name without checking it:
+// "runpod.timeout", "runpod.routes.<name>", "profile.<name>.api_key",
+// "profile.<name>.routes.<name>". A bare key is a [default] one.
+func SplitKey(dotted string) (section, key string) {
+	parts := strings.Split(dotted, ".")
+	switch {
+	case len(parts) == 1:
+		return "default", parts[0]
+	case len(parts) >= 3 && parts[0] == "runpod" && parts[1] == "routes":
+		return "runpod.routes", strings.Join(parts[2:], ".")
+	case len(parts) >= 4 && parts[0] == "profile" && parts[2] == "routes":
+		return strings.Join(parts[:3], "."), strings.Join(parts[3:], ".")
+	}
+	return strings.Join(parts[:len(parts)-1], "."), parts[len(parts)-1]
+}
+
+// checkKey reports whether key may appear in section.
+func checkKey(section, key string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	var known []string
+	switch sectionKind(section) {
+	case "routes":
+		return nil
+	case "fixed":
+		known = sectionKeys[section]
+	case "profile":
+		known = profileKeys
+	default:
+		return checkSection(section)
+	}
+	for _, k := range known {
+		if k == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key %q in [%s]%s", key, section, didYouMean(key, known))
+}
+
+// checkSection reports an unknown section, or nil.
+func checkSection(section string) error {
+	if sectionKind(section) != "" {
+		return nil
+	}
+	return fmt.Errorf("unknown section [%s]%s", section, didYouMean(section, knownSections(section)))
+}
+
+// CheckValue validates the values that have a closed set or a format.
+func CheckValue(section, key, val string) error {
+	switch key {
+	case "provider":
+		if sectionKind(section) == "routes" {
+			return nil
+		}
+		for _, p := range Providers {
+			if val == p {
+				return nil
+			}
+		}
+		return fmt.Errorf("provider %q: want %s", val, strings.Join(Providers, " or "))
+	case "timeout":
+		if sectionKind(section) == "routes" {
+			return nil
+		}
+		if d, err := time.ParseDuration(val); err != nil || d <= 0 {
+			return fmt.Errorf("timeout %q: want a positive duration like 90s", val)
+		}
+	}
+	if section == "serve" {
+		return checkServeValue(key, val)
+	}
+	return nil
+}
+
+// checkServeValue validates a [serve] value the way its flag would
+// parse it.
+func checkServeValue(key, val string) error {
+	switch key {
+	case "port":
+		if n, err := strconv.Atoi(val); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("port %q: want 1-65535", val)
+		}
+	case "gpu_id":
+		if n, err := strconv.Atoi(val); err != nil || n < -1 {
+			return fmt.Errorf("gpu_id %q: want a device index, or -1 for CPU", val)
+		}
+	case "retry_max_attempts", "breaker_threshold":
+		if n, err := strconv.Atoi(val); err != nil || n < 1 {
+			return fmt.Errorf("%s %q: want a positive integer", key, val)
+		}
+	case "mode":
+		if !slices.Contains(ServeModes, val) {
+			return fmt.Errorf("mode %q: want %s", val, strings.Join(ServeModes, " or "))
+		}
+	case "poll_max", "poll_initial", "poll_interval_max", "retry_budget", "breaker_cooldown":
+		if d, err := time.ParseDuration(val); err != nil || d < 0 {
+			return fmt.Errorf("%s %q: want a duration like 10m", key, val)
+		}
+	}
+	return nil
+}
+
+// knownSections lists section names to suggest for a misspelt one,
+// keeping the profile name the user typed.
+func knownSections(typed string) []string {
+	out := []string{"runpod.routes"}
+	for s := range sectionKeys {
+		out = append(out, s)
+	}
+	if _, rest, ok := strings.Cut(typed, "."); ok {
+		name, _, _ := strings.Cut(rest, ".")
+		out = append(out, "profile."+name, "profile."+name+".routes")
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Issue is one problem `iosuite config validate` found. Column is
+// set for syntax errors.
+type Issue struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// Validate checks a config file: a TOML syntax error (the first one;
+// parsing stops there), else unknown sections and keys (with
+// suggestions), values that aren't scalars and bad values. Load
+// ignores unknown names; this is how users find out.
+func Validate(data []byte) []Issue {
+	doc, err := toml.Parse(data)
+	if err != nil {
+		var perr *toml.Error
+		if errors.As(err, &perr) {
+			return []Issue{{Line: perr.Line, Column: perr.Column, Message: perr.Msg}}
+		}
+		return []Issue{{Line: 1, Message: err.Error()}}
+	}
+	var issues []Issue
+	badSection := map[string]bool{}
+	for _, t := range doc.Tables {
+		section := strings.Join(t.Path, ".")
+		if err := checkSection(section); err != nil && !badSection[section] {
+			badSection[section] = true
+			issues = append(issues, Issue{Line: t.Line, Message: err.Error()})
+		}
+	}
+	for _, k := range doc.Keys {
+		if _, isTable := k.Value.(map[string]any); isTable {
+			continue // its members are checked
+		}
+		section, key := splitPath(k.Path)
+		if badSection[section] {
+			continue // reported once, at the header
+		}
+		if err := checkSection(section); err != nil {
+			badSection[section] = true
+			issues = append(issues, Issue{Line: k.Line, Message: err.Error()})
+			continue
+		}
+		if err := checkKey(section, key); err != nil {
+			issues = append(issues, Issue{Line: k.Line, Message: err.Error()})
+			continue
+		}
+		val, ok := scalar(k.Value)
+		if !ok {
+			issues = append(issues, Issue{Line: k.Line, Message: fmt.Sprintf("%s must be a string, number or boolean, not %s", key, toml.TypeName(k.Value))})
+			continue
+		}
+		if err := CheckValue(section, key, val); err != nil {
+			issues = append(issues, Issue{Line: k.Line, Message: err.Error()})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// didYouMean suggests the closest candidate, or "".
+func didYouMean(typed string, candidates []string) string {
+	best, bestDist := "", len(typed)/2+1
+	if bestDist < 2 {
+		bestDist = 2
+	}
+	for _, c := range candidates {
+		if d := editDistance(typed, c); d < bestDist || (d == bestDist && best == "") {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	for in, want := range map[string][2]string{
+		"provider":                        {"default", "provider"},
+		"default.model":                   {"default", "model"},
+		"runpod.timeout":                  {"runpod", "timeout"},
+		"runpod.routes.realesrgan-x4plus": {"runpod.routes", "realesrgan-x4plus"},
+		"runpod.routes.ffmpeg.v2":         {"runpod.routes", "ffmpeg.v2"},
+		"profile.prod.api_key":            {"profile.prod", "api_key"},
+		"profile.prod.routes.ffmpeg":      {"profile.prod.routes", "ffmpeg"},
+	} {
+		sec, key, err := ParseKey(in)
+		if err != nil || sec != want[0] || key != want[1] {
+			t.Errorf("ParseKey(%q) = %q, %q, %v; want %q, %q", in, sec, key, err, want[0], want[1])
+		}
+	}
+}
+
+func TestParseKey_SuggestsClosest(t *testing.T) {
+	for in, want := range map[string]string{
+		"runpod.tiemout":        `unknown key "tiemout" in [runpod] (did you mean "timeout"?)`,
+		"modle":                 `unknown key "modle" in [default] (did you mean "model"?)`,
+		"runpd.api_key":         `unknown section [runpd] (did you mean "runpod"?)`,
+		"profile.prod.apikey":   `unknown key "apikey" in [profile.prod] (did you mean "api_key"?)`,
+		"runpod.something_else": `unknown key "something_else" in [runpod]`,
+	} {
+		_, _, err := ParseKey(in)
+		if err == nil || err.Error() != want {
+			t.Errorf("ParseKey(%q) err = %v, want %s", in, err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	body := `# ok
+provider = "aws"
+modle = "x"
+
+[runpod]
+api_key = "k"
+timeout = "soon"
+endpoint_id = [42]
+
+[runpod.routez]
+a = "b"
+c = "d"
+
+[profile.prod]
+endpoint = "ep"
+
+[profile.prod.routes]
+anything = "goes"
+
+[serve]
+port = 8080
+breaker_threshold = 0
+`
+	got := Validate([]byte(body))
+	want := []Issue{
+		{Line: 2, Message: `provider "aws": want local or runpod`},
+		{Line: 3, Message: `unknown key "modle" in [default] (did you mean "model"?)`},
+		{Line: 7, Message: `timeout "soon": want a positive duration like 90s`},
+		{Line: 8, Message: `endpoint_id must be a string, number or boolean, not array`},
+		{Line: 10, Message: `unknown section [runpod.routez] (did you mean "runpod.routes"?)`},
+		{Line: 15, Message: `unknown key "endpoint" in [profile.prod] (did you mean "endpoint_id"?)`},
+		{Line: 22, Message: `breaker_threshold "0": want a positive integer`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate =\n%s\nwant\n%s", issuesString(got), issuesString(want))
+	}
+	if issues := Validate([]byte(profilesBody)); issues != nil {
+		t.Errorf("valid file reported: %v", issues)
+	}
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	body := `[runpod]
+api_key = "k"
+api_key = "k2"
+`
+	got := Validate([]byte(body))
+	want := []Issue{{Line: 3, Column: 1, Message: "duplicate key api_key"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate = %v, want %v", got, want)
+	}
+	if got[0].String() != "line 3, column 1: duplicate key api_key" {
+		t.Errorf("String = %q", got[0].String())
+	}
+}
+
+func issuesString(issues []Issue) string {
+	var b strings.Builder
+	for _, is := range issues {
+		b.WriteString(is.String() + "\n")
+	}
+	return b.String()
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{{"", "abc", 3}, {"timeout", "tiemout", 2}, {"model", "modle", 2}, {"same", "same", 0}} {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
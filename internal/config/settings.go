@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Where a setting's effective value came from.
+const (
+	OriginFlag    = "flag"
+	OriginEnv     = "env"
+	OriginFile    = "file"
+	OriginDefault = "default"
+	// OriginCredentials is a key `iosuite auth login` stored.
+	OriginCredentials = "credentials"
+)
+
+// Credentials is where `iosuite auth login` keeps API keys, one per
+// profile ("" = none selected). internal/auth implements it; it is an
+// interface here so config doesn't depend on the stores.
+type Credentials interface {
+	Get(profile string) (key string, found bool, err error)
+	String() string
+}
+
+// WithCredentials returns c with runpod.api_key also resolved from
+// src. A stored key sits just above the config-file key it replaces:
+// a profile's stored key beats everything but --runpod-api-key, and
+// the unprofiled one beats the file but not $RUNPOD_API_KEY.
+func (c Config) WithCredentials(src Credentials) Config {
+	c.credentials = src
+	return c
+}
+
+// Setting is one effective value as `iosuite config list` reports it.
+type Setting struct {
+	Key    string `json:"key"` // dotted, as `config get` takes it
+	Value  string `json:"value"`
+	Origin string `json:"origin"`           // OriginFlag … OriginDefault
+	Source string `json:"source,omitempty"` // --flag, $VAR or path:line
+	Secret bool   `json:"-"`                // mask when listing
+}
+
+// Masked returns s with a secret value reduced to its last four
+// characters.
+func (s Setting) Masked() Setting {
+	if s.Secret && s.Value != "" {
+		tail := s.Value
+		if len(tail) > 4 {
+			tail = tail[len(tail)-4:]
+		}
+		s.Value = "****" + tail
+	}
+	return s
+}
+
+// SelectProfile applies the profile chosen by flagVal (the global
+// --profile) or $IOSUITE_PROFILE, remembering which for --show-origin,
+// then the environment overrides on top.
+func (c Config) SelectProfile(flagVal string) (Config, error) {
+	name, source := flagVal, OriginFlag
+	if name == "" {
+		name, source = os.Getenv(ProfileEnv), OriginEnv
+	}
+	cfg, err := c.WithProfile(name)
+	if err != nil {
+		return c, err
+	}
+	if name != "" {
+		cfg.profileSource = source
+	}
+	return cfg.withEnv(), nil
+}
+
+// settingKeys are the fixed settings `config list` always shows, in
+// display order.
+var settingKeys = []string{
+	"default.provider", "default.output_dir", "default.model",
+	"runpod.api_key", "runpod.endpoint_id", "runpod.timeout",
+	"auth.helper",
+	"serve.bind", "serve.port", "serve.gpu_id", "serve.mode",
+	"serve.poll_max", "serve.poll_initial", "serve.poll_interval_max",
+	"serve.retry_max_attempts", "serve.retry_budget",
+	"serve.breaker_threshold", "serve.breaker_cooldown",
+}
+
+// Settings returns every effective setting: the selected profile,
+// the fixed keys, then the routes in use.
+func (c Config) Settings() []Setting {
+	out := []Setting{c.profileSetting()}
+	for _, k := range settingKeys {
+		s, _ := c.Lookup(k)
+		out = append(out, s)
+	}
+	routes := make([]string, 0, len(c.RunpodRoutes))
+	for k := range c.RunpodRoutes {
+		routes = append(routes, k)
+	}
+	sort.Strings(routes)
+	for _, k := range routes {
+		s, _ := c.Lookup("runpod.routes." + k)
+		out = append(out, s)
+	}
+	return out
+}
+
+// Lookup resolves one dotted setting the way the CLI does: its
+// IOSUITE_<SECTION>_<KEY> variable (or an older alias, see
+// EnvVars) beats the file, which beats the default. Two exceptions:
+// a selected profile's api_key beats the environment, and stored
+// credentials slot in as WithCredentials describes. profile.* keys
+// read the file as written.
+//
+// A credential store that fails (a wrong passphrase, a broken
+// helper) returns the setting resolved without it, and the error.
+func (c Config) Lookup(dotted string) (Setting, error) {
+	if dotted == "profile" {
+		return c.profileSetting(), nil
+	}
+	section, key, err := ParseKey(dotted)
+	if err != nil {
+		return Setting{}, err
+	}
+	s := Setting{Key: section + "." + key}
+	if sectionKind(section) == "profile" || strings.HasPrefix(section, "profile.") {
+		return c.profileFileSetting(s, section, key), nil
+	}
+	switch {
+	case s.Key == "runpod.api_key":
+		s.Secret = true
+		c.fromFile(&s, c.RunpodAPIKey, "api_key")
+		_, inProfile := c.profileLine("api_key")
+		if !inProfile {
+			fromEnv(&s)
+		}
+		return s, c.fromCredentials(&s, inProfile)
+	case sectionKind(section) == "fixed":
+		c.fromFile(&s, c.value(section, key), key)
+		fromEnv(&s)
+	default: // runpod.routes.<name>
+		s.Value = c.RunpodRoutes[key]
+		s.Origin = OriginDefault
+		// A profile's routes table replaces the top-level one whole.
+		line, ok := c.lines[s.Key]
+		if p, active := c.ActiveProfile(); active && p.RunpodRoutes != nil {
+			line, ok = c.profileLine("routes." + key)
+		}
+		if ok {
+			s.Origin, s.Source = OriginFile, c.at(line)
+		}
+	}
+	return s, nil
+}
+
+func (c Config) profileSetting() Setting {
+	s := Setting{Key: "profile", Value: c.Profile, Origin: OriginDefault}
+	switch c.profileSource {
+	case OriginFlag:
+		s.Origin, s.Source = OriginFlag, "--profile"
+	case OriginEnv:
+		s.Origin, s.Source = OriginEnv, "$"+ProfileEnv
+	}
+	return s
+}
+
+// fromFile fills s with value, from the selected profile's line or
+// the top-level one when the file set it, else as a default.
+func (c Config) fromFile(s *Setting, value, key string) {
+	s.Value, s.Origin = value, OriginDefault
+	if line, ok := c.profileLine(key); ok {
+		s.Origin, s.Source = OriginFile, c.at(line)
+	} else if line, ok := c.lines[s.Key]; ok {
+		s.Origin, s.Source = OriginFile, c.at(line)
+	}
+}
+
+// profileLine is the line the selected profile set key on.
+func (c Config) profileLine(key string) (int, bool) {
+	if c.Profile == "" {
+		return 0, false
+	}
+	line, ok := c.lines["profile."+c.Profile+"."+key]
+	return line, ok
+}
+
+func (c Config) profileFileSetting(s Setting, section, key string) Setting {
+	name, sub, _ := strings.Cut(strings.TrimPrefix(section, "profile."), ".")
+	p := c.Profiles[name]
+	s.Origin = OriginDefault
+	if sub == "routes" {
+		s.Value = p.RunpodRoutes[key]
+	} else {
+		switch key {
+		case "provider":
+			s.Value = p.Provider
+		case "api_key":
+			s.Value, s.Secret = p.RunpodAPIKey, true
+		case "endpoint_id":
+			s.Value = p.RunpodEndpointID
+		case "timeout":
+			s.Value = p.RunpodTimeout
+		}
+	}
+	if line, ok := c.lines[s.Key]; ok {
+		s.Origin, s.Source = OriginFile, c.at(line)
+	}
+	return s
+}
+
+// fromCredentials applies a stored key over s (see WithCredentials).
+func (c Config) fromCredentials(s *Setting, inProfile bool) error {
+	if c.credentials == nil {
+		return nil
+	}
+	use := func(profile string) (bool, error) {
+		key, found, err := c.credentials.Get(profile)
+		if err != nil || !found {
+			return false, err
+		}
+		s.Value, s.Origin, s.Source = key, OriginCredentials, c.credentials.String()
+		return true, nil
+	}
+	if c.Profile != "" {
+		if found, err := use(c.Profile); found || err != nil || inProfile {
+			return err
+		}
+	}
+	if s.Origin == OriginEnv {
+		return nil
+	}
+	_, err := use("")
+	return err
+}
+
+// File is the config file Load read, "" when there was none.
+func (c Config) File() string { return c.path }
+
+// PlaintextKeys lists the API keys the config file holds in the
+// clear, as dotted settings.
+func (c Config) PlaintextKeys() []string {
+	var out []string
+	for k := range c.lines {
+		if strings.HasSuffix(k, ".api_key") {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// at renders a file position, with $HOME shortened to ~.
+func (c Config) at(line int) string {
+	path := c.path
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = filepath.Join("~", rel)
+		}
+	}
+	return fmt.Sprintf("%s:%d", path, line)
+}
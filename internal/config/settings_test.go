@@ -0,0 +1,152 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func settingMap(t *testing.T, cfg Config) map[string]Setting {
+	t.Helper()
+	m := map[string]Setting{}
+	for _, s := range cfg.Settings() {
+		m[s.Key] = s
+	}
+	return m
+}
+
+func TestSettings_Origins(t *testing.T) {
+	t.Setenv("RUNPOD_API_KEY", "")
+	t.Setenv("IOSUITE_RUNPOD_TIMEOUT", "2m")
+	t.Setenv(ProfileEnv, "")
+	cfg, err := loadBody(t, profilesBody).SelectProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := settingMap(t, cfg)
+	for key, want := range map[string][2]string{
+		"profile":                         {"", OriginDefault},
+		"default.provider":                {"local", OriginFile},
+		"default.model":                   {"realesrgan-x4plus", OriginDefault},
+		"runpod.api_key":                  {"base-key", OriginFile},
+		"runpod.timeout":                  {"2m", OriginEnv},
+		"runpod.routes.realesrgan-x4plus": {"base-route", OriginFile},
+	} {
+		if s := m[key]; s.Value != want[0] || s.Origin != want[1] {
+			t.Errorf("%s = %q from %s, want %q from %s", key, s.Value, s.Origin, want[0], want[1])
+		}
+	}
+	if src := m["default.provider"].Source; !strings.HasSuffix(src, "config.toml:2") {
+		t.Errorf("provider source = %q, want …config.toml:2", src)
+	}
+	if src := m["runpod.timeout"].Source; src != "$IOSUITE_RUNPOD_TIMEOUT" {
+		t.Errorf("timeout source = %q", src)
+	}
+}
+
+func TestSettings_ProfileKeyBeatsEnv(t *testing.T) {
+	t.Setenv("RUNPOD_API_KEY", "env-key")
+	t.Setenv(ProfileEnv, "staging")
+	cfg, err := loadBody(t, profilesBody).SelectProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := settingMap(t, cfg)
+	if s := m["profile"]; s.Value != "staging" || s.Origin != OriginEnv {
+		t.Errorf("profile = %+v", s)
+	}
+	if s := m["runpod.api_key"]; s.Value != "staging-key" || !strings.HasSuffix(s.Source, "config.toml:13") {
+		t.Errorf("api_key = %+v, want the profile's (line 13)", s)
+	}
+	if _, ok := m["runpod.routes.realesrgan-x4plus"]; ok {
+		t.Error("profile routes should replace the top-level table")
+	}
+
+	// prod sets only a key; its endpoint id is inherited from the base
+	// table and reported at that line.
+	cfg, err = loadBody(t, profilesBody).SelectProfile("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = settingMap(t, cfg)
+	if s := m["runpod.api_key"]; s.Value != "prod-key" || s.Origin != OriginFile {
+		t.Errorf("prod api_key = %+v", s)
+	}
+	if s := m["profile"]; s.Origin != OriginFlag || s.Source != "--profile" {
+		t.Errorf("profile = %+v, want from --profile", s)
+	}
+	if s := m["runpod.endpoint_id"]; s.Value != "base-ep" || !strings.HasSuffix(s.Source, "config.toml:6") {
+		t.Errorf("prod endpoint_id = %+v, want inherited from line 6", s)
+	}
+}
+
+func TestSettings_EnvKeyWithoutProfile(t *testing.T) {
+	t.Setenv("RUNPOD_API_KEY", "env-key")
+	cfg := loadBody(t, profilesBody)
+	s, err := cfg.Lookup("runpod.api_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Value != "env-key" || s.Origin != OriginEnv {
+		t.Errorf("api_key = %+v", s)
+	}
+	if got := s.Masked().Value; got != "****-key" {
+		t.Errorf("masked = %q", got)
+	}
+}
+
+func TestLookup_ProfileKeysReadTheFile(t *testing.T) {
+	s, err := loadBody(t, profilesBody).Lookup("profile.staging.timeout")
+	if err != nil || s.Value != "90s" || s.Origin != OriginFile {
+		t.Errorf("profile.staging.timeout = %+v, %v", s, err)
+	}
+	if _, err := Defaults().Lookup("runpod.tiemout"); err == nil {
+		t.Error("unknown key looked up")
+	}
+}
+
+type fakeCredentials map[string]string
+
+func (f fakeCredentials) Get(profile string) (string, bool, error) {
+	key, ok := f[profile]
+	return key, ok, nil
+}
+
+func (f fakeCredentials) String() string { return "test store" }
+
+func TestLookup_StoredCredentials(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	creds := fakeCredentials{"": "stored-base", "staging": "stored-staging"}
+	for _, tc := range []struct {
+		name, env, profile string
+		want, origin       string
+	}{
+		{"beats the file", "", "", "stored-base", OriginCredentials},
+		{"loses to env", "env-key", "", "env-key", OriginEnv},
+		{"profile key beats env", "env-key", "staging", "stored-staging", OriginCredentials},
+		// prod sets its own key in the file, which the unprofiled
+		// stored key must not replace.
+		{"profile file key beats base", "", "prod", "prod-key", OriginFile},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("RUNPOD_API_KEY", tc.env)
+			cfg, err := loadBody(t, profilesBody).WithCredentials(creds).SelectProfile(tc.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := cfg.Lookup("runpod.api_key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Value != tc.want || s.Origin != tc.origin {
+				t.Errorf("api_key = %q from %s, want %q from %s", s.Value, s.Origin, tc.want, tc.origin)
+			}
+		})
+	}
+}
+
+func TestPlaintextKeys(t *testing.T) {
+	got := strings.Join(loadBody(t, profilesBody).PlaintextKeys(), " ")
+	if want := "profile.prod.api_key profile.staging.api_key runpod.api_key"; got != want {
+		t.Errorf("PlaintextKeys = %q, want %q", got, want)
+	}
+}
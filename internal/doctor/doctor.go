@@ -64,13 +64,22 @@ func Run(w io.Writer, cfg config.Config) bool {
 
 	// 5. Optional: RunPod creds. Only relevant when the user wants
 	// to use the runpod provider; not having them is a warning.
-	if cfg.RunpodAPIKey == "" && os.Getenv("RUNPOD_API_KEY") == "" {
+	key, err := cfg.Lookup("runpod.api_key")
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "  ⚠  runpod credentials  stored key unreadable: %v\n", err)
+	case key.Value == "":
 		fmt.Fprintf(w, "  ⚠  runpod credentials not configured (only matters for --provider runpod)\n")
-	} else {
-		fmt.Fprintf(w, "  ✓  runpod credentials configured\n")
+		fmt.Fprintf(w, "      → iosuite auth login\n")
+	default:
+		fmt.Fprintf(w, "  ✓  runpod credentials configured (%s)\n", key.Origin)
 	}
+	plaintextKeyCheck(w, cfg)
 
 	// 6. Provider sanity
+	if cfg.Profile != "" {
+		fmt.Fprintf(w, "  ℹ  profile           %s\n", cfg.Profile)
+	}
 	switch cfg.Provider {
 	case "local", "runpod":
 		fmt.Fprintf(w, "  ℹ  default provider  %s\n", cfg.Provider)
@@ -87,3 +96,20 @@ func Run(w io.Writer, cfg config.Config) bool {
 	}
 	return allOK
 }
+
+// plaintextKeyCheck warns when config.toml holds an API key in the
+// clear and other users can read it. Windows file modes don't reflect
+// ACLs, so the check is skipped there.
+func plaintextKeyCheck(w io.Writer, cfg config.Config) {
+	keys := cfg.PlaintextKeys()
+	if len(keys) == 0 || runtime.GOOS == "windows" {
+		return
+	}
+	fi, err := os.Stat(cfg.File())
+	if err != nil || fi.Mode().Perm()&0o077 == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  ⚠  config.toml holds %s in plaintext and is readable by others (mode %#o)\n", strings.Join(keys, ", "), fi.Mode().Perm())
+	fmt.Fprintf(w, "      → iosuite auth login, then iosuite config unset %s\n", keys[0])
+	fmt.Fprintf(w, "      → or at least: chmod 600 %s\n", cfg.File())
+}
@@ -0,0 +1,280 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"iosuite.io/internal/fleet"
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
+)
+
+// Apply actions, as reported per endpoint in ApplyResult.Action.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+	ActionFailed  = "failed"
+	ActionSkipped = "skipped"
+)
+
+// ManifestResolver fetches the deploy manifest for one fleet entry:
+// from path when set, else by tool + version via the registry.
+// Returns the manifest and where it came from. The cobra layer owns
+// this (it's the same resolution `endpoint deploy` does) so this
+// package stays free of registry / network policy.
+type ManifestResolver func(ctx context.Context, tool, version, path string) (*manifest.Manifest, string, error)
+
+// ApplyInput captures everything `iosuite endpoint apply` needs.
+type ApplyInput struct {
+	Fleet     *fleet.Fleet
+	APIKey    string
+	UserAgent string
+	Resolve   ManifestResolver
+	// API is the admin backend, as DeployInput.API.
+	API string
+
+	// Prune deletes iosuite-created endpoints (those running their own
+	// `<name>-tmpl` template) that the fleet doesn't declare, along
+	// with that template. Endpoints iosuite didn't create and
+	// `<name>-canary` endpoints of a live rollout are never touched.
+	// Skipped when any create / update failed, so a broken fleet file
+	// can't take down the endpoints it failed to replace.
+	Prune bool
+
+	// ConfirmPrune, when set, is shown the endpoints Prune would
+	// delete and decides whether it goes ahead. Declining skips the
+	// prune. Nil means go ahead (`--yes`).
+	ConfirmPrune func(doomed []ApplyResult) (bool, error)
+
+	// Deployed, when set, is called after each successful create /
+	// update with the manifest that was used. The CLI records deploy
+	// history through it.
+	Deployed func(e fleet.Endpoint, man *manifest.Manifest, res *DeployResult)
+}
+
+// ApplyResult is one endpoint's outcome. The JSON tags are the
+// `--output json` schema.
+type ApplyResult struct {
+	Name       string `json:"name"`
+	Action     string `json:"action"` // created | updated | deleted | failed | skipped
+	EndpointID string `json:"endpoint_id,omitempty"`
+	Template   string `json:"template,omitempty"` // deleted with a pruned endpoint
+	Tool       string `json:"tool,omitempty"`
+	GPUClass   string `json:"gpu_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// applyStep is one planned change: deploy a fleet entry, or (spec
+// nil) delete an endpoint the fleet no longer declares, and its
+// template when set.
+type applyStep struct {
+	spec       *fleet.Endpoint
+	name       string
+	existingID string
+	template   string
+}
+
+// planApply works out what Apply will do, in fleet order followed by
+// prunes in account order. templates is only read when pruning.
+func planApply(f *fleet.Fleet, existing []runpod.Endpoint, templates []runpod.Template, prune bool) []applyStep {
+	byName := make(map[string]string, len(existing))
+	for _, e := range existing {
+		if _, dup := byName[e.Name]; !dup {
+			byName[e.Name] = e.ID // first match wins, as in FindEndpoint
+		}
+	}
+	declared := make(map[string]bool, len(f.Endpoints))
+	var steps []applyStep
+	for i := range f.Endpoints {
+		spec := &f.Endpoints[i]
+		name := spec.ResolvedName()
+		declared[name] = true
+		steps = append(steps, applyStep{spec: spec, name: name, existingID: byName[name]})
+	}
+	if prune {
+		for _, e := range existing {
+			if declared[e.Name] || ownTemplate(e, templates) == nil {
+				continue
+			}
+			// A canary mid-rollout: Canary deletes it itself.
+			if base, ok := strings.CutSuffix(e.Name, "-canary"); ok && (declared[base] || byName[base] != "") {
+				continue
+			}
+			step := applyStep{name: e.Name, existingID: e.ID}
+			if t := ownTemplate(e, templates); pairedTemplateConflict(e, existing, t) == "" {
+				step.template = t.Name
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Apply converges the account to the fleet: each declared endpoint
+// goes through Deploy's find-or-save flow, then (with Prune) the
+// undeclared iosuite-created ones are deleted once ConfirmPrune
+// agrees. A failure on one endpoint is recorded
+// in its result and the rest still run. The error return is only for
+// failures that stop everything (bad input, listing the account).
+func Apply(ctx context.Context, in ApplyInput) ([]ApplyResult, error) {
+	if in.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	if in.Fleet == nil || in.Resolve == nil {
+		return nil, fmt.Errorf("ApplyInput.Fleet and ApplyInput.Resolve are required")
+	}
+	rp, err := runpod.NewAdmin(in.API, in.APIKey, in.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := rp.ListEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list endpoints: %w", err)
+	}
+	var templates []runpod.Template
+	if in.Prune {
+		if templates, err = rp.ListTemplates(ctx); err != nil {
+			return nil, fmt.Errorf("list templates: %w", err)
+		}
+	}
+
+	var results []ApplyResult
+	var prunes []applyStep
+	failed := false
+	for _, step := range planApply(in.Fleet, existing, templates, in.Prune) {
+		if step.spec == nil {
+			prunes = append(prunes, step)
+			continue
+		}
+
+		spec := step.spec
+		res := ApplyResult{Name: step.name, Tool: spec.Tool, GPUClass: spec.GPUClass}
+		dep, err := applyOne(ctx, in, spec)
+		switch {
+		case err != nil:
+			failed = true
+			res.Action, res.Error = ActionFailed, err.Error()
+			res.EndpointID = step.existingID
+		case step.existingID == "":
+			res.Action, res.EndpointID = ActionCreated, dep.EndpointID
+		default:
+			res.Action, res.EndpointID = ActionUpdated, dep.EndpointID
+		}
+		results = append(results, res)
+	}
+	if len(prunes) == 0 {
+		return results, nil
+	}
+
+	doomed := make([]ApplyResult, len(prunes))
+	for i, step := range prunes {
+		doomed[i] = ApplyResult{Name: step.name, EndpointID: step.existingID, Template: step.template}
+	}
+	skip := ""
+	switch {
+	case failed:
+		skip = "not pruned: an earlier create / update failed"
+	case in.ConfirmPrune != nil:
+		ok, err := in.ConfirmPrune(doomed)
+		switch {
+		case err != nil:
+			skip = "not pruned: " + err.Error()
+		case !ok:
+			skip = "not pruned: not confirmed"
+		}
+	}
+	for _, res := range doomed {
+		switch {
+		case skip != "":
+			res.Action, res.Error, res.Template = ActionSkipped, skip, ""
+		default:
+			res.Action = ActionDeleted
+			err := rp.DeleteEndpoint(ctx, res.EndpointID)
+			if err == nil && res.Template != "" {
+				if terr := rp.DeleteTemplate(ctx, res.Template); terr != nil {
+					err = fmt.Errorf("endpoint deleted, but template %s: %w", res.Template, terr)
+				}
+			}
+			if err != nil {
+				res.Action, res.Error = ActionFailed, err.Error()
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func applyOne(ctx context.Context, in ApplyInput, spec *fleet.Endpoint) (*DeployResult, error) {
+	man, src, err := in.Resolve(ctx, spec.Tool, spec.Version, in.Fleet.ManifestPath(*spec))
+	if err != nil {
+		return nil, err
+	}
+	res, err := Deploy(ctx, DeployInput{
+		Provider:          ProviderRunPod,
+		Tool:              spec.Tool,
+		GPUClass:          spec.GPUClass,
+		Name:              spec.Name,
+		APIKey:            in.APIKey,
+		Manifest:          man,
+		WorkersMax:        spec.WorkersMax,
+		IdleTimeoutS:      spec.IdleTimeoutS,
+		Flashboot:         spec.Flashboot,
+		MinCudaVersion:    spec.MinCudaVersion,
+		WorkersMin:        spec.WorkersMin,
+		ScalerType:        spec.ScalerType,
+		ScalerValue:       spec.ScalerValue,
+		UserAgent:         in.UserAgent,
+		ExecutionTimeoutS: spec.ExecutionTimeoutS,
+		RegistryAuth:      spec.RegistryAuth,
+		NoRegistryAuth:    spec.NoRegistryAuth,
+		NetworkVolume:     spec.NetworkVolume,
+		DataCenters:       spec.DataCenters,
+		API:               in.API,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.ManifestSource = src
+	if in.Deployed != nil {
+		in.Deployed(*spec, man, res)
+	}
+	return res, nil
+}
+
+// PrintApply writes one line per endpoint plus a summary.
+func PrintApply(w io.Writer, results []ApplyResult) {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Action]++
+		id := r.EndpointID
+		if id == "" {
+			id = "-"
+		}
+		fmt.Fprintf(w, "  %-8s  %-28s  %s\n", r.Action, r.Name, id)
+		if r.Action == ActionDeleted && r.Template != "" {
+			fmt.Fprintf(w, "            and template %s\n", r.Template)
+		}
+		if r.Error != "" {
+			fmt.Fprintf(w, "            %s\n", r.Error)
+		}
+	}
+	fmt.Fprintf(w, "\n%d created, %d updated, %d deleted, %d failed",
+		counts[ActionCreated], counts[ActionUpdated], counts[ActionDeleted], counts[ActionFailed])
+	if counts[ActionSkipped] > 0 {
+		fmt.Fprintf(w, ", %d prune skipped", counts[ActionSkipped])
+	}
+	fmt.Fprintln(w)
+}
+
+// ApplyFailed reports whether any result failed, for the exit code.
+func ApplyFailed(results []ApplyResult) bool {
+	for _, r := range results {
+		if r.Action == ActionFailed {
+			return true
+		}
+	}
+	return false
+}
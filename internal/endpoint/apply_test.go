@@ -0,0 +1,101 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"iosuite.io/internal/fleet"
+	"iosuite.io/internal/runpod"
+)
+
+func testFleet() *fleet.Fleet {
+	return &fleet.Fleet{Endpoints: []fleet.Endpoint{
+		{Tool: "real-esrgan", GPUClass: "rtx-4090"},
+		{Tool: "real-esrgan", GPUClass: "l40s", Name: "esrgan-big"},
+	}}
+}
+
+func TestPlanApply_CreateUpdateAndPrune(t *testing.T) {
+	existing := []runpod.Endpoint{
+		{ID: "ep1", Name: "real-esrgan-rtx-4090", TemplateID: "t1"},
+		{ID: "ep9", Name: "old-experiment", TemplateID: "t9"},
+	}
+	templates := []runpod.Template{
+		{ID: "t1", Name: "real-esrgan-rtx-4090-tmpl"},
+		{ID: "t9", Name: "old-experiment-tmpl"},
+	}
+
+	steps := planApply(testFleet(), existing, templates, false)
+	if len(steps) != 2 {
+		t.Fatalf("without prune: %d steps, want 2", len(steps))
+	}
+	if steps[0].name != "real-esrgan-rtx-4090" || steps[0].existingID != "ep1" {
+		t.Errorf("step 0 = %+v, want an update of ep1", steps[0])
+	}
+	if steps[1].name != "esrgan-big" || steps[1].existingID != "" {
+		t.Errorf("step 1 = %+v, want a create", steps[1])
+	}
+
+	steps = planApply(testFleet(), existing, templates, true)
+	if len(steps) != 3 {
+		t.Fatalf("with prune: %d steps, want 3", len(steps))
+	}
+	if last := steps[2]; last.spec != nil || last.existingID != "ep9" || last.template != "old-experiment-tmpl" {
+		t.Errorf("prune step = %+v, want delete of ep9 and its template", last)
+	}
+}
+
+func TestPlanApply_PruneOnlyOwnedEndpoints(t *testing.T) {
+	existing := []runpod.Endpoint{
+		{ID: "ep1", Name: "real-esrgan-rtx-4090", TemplateID: "t1"},
+		// a live canary rollout of a declared endpoint
+		{ID: "ep2", Name: "real-esrgan-rtx-4090-canary", TemplateID: "t2"},
+		// created in the console, running someone else's template
+		{ID: "ep3", Name: "hand-made", TemplateID: "t1"},
+		// iosuite-created, but its template is shared
+		{ID: "ep4", Name: "old", TemplateID: "t4"},
+		{ID: "ep5", Name: "old-copy", TemplateID: "t4"},
+	}
+	templates := []runpod.Template{
+		{ID: "t1", Name: "real-esrgan-rtx-4090-tmpl"},
+		{ID: "t2", Name: "real-esrgan-rtx-4090-canary-tmpl"},
+		{ID: "t4", Name: "old-tmpl"},
+	}
+	var pruned []string
+	for _, s := range planApply(testFleet(), existing, templates, true) {
+		if s.spec == nil {
+			pruned = append(pruned, s.name+"/"+s.template)
+		}
+	}
+	if got := strings.Join(pruned, ","); got != "old/" {
+		t.Errorf("pruned = %s, want only old, keeping its shared template", got)
+	}
+}
+
+func TestApply_RejectsMissingAPIKey(t *testing.T) {
+	_, err := Apply(context.Background(), ApplyInput{Fleet: testFleet()})
+	if err == nil || !strings.Contains(err.Error(), "RUNPOD_API_KEY") {
+		t.Fatalf("expected API key error, got %v", err)
+	}
+}
+
+func TestPrintApply_Summary(t *testing.T) {
+	var buf bytes.Buffer
+	results := []ApplyResult{
+		{Name: "real-esrgan-rtx-4090", Action: ActionUpdated, EndpointID: "ep1"},
+		{Name: "esrgan-big", Action: ActionFailed, Error: "gpu-class \"l40s\" not declared"},
+		{Name: "old-experiment", Action: ActionSkipped, EndpointID: "ep9"},
+	}
+	PrintApply(&buf, results)
+	out := buf.String()
+	for _, want := range []string{"updated", "ep1", "gpu-class", "0 created, 1 updated, 0 deleted, 1 failed, 1 prune skipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !ApplyFailed(results) {
+		t.Error("ApplyFailed should be true with a failed result")
+	}
+}
@@ -0,0 +1,291 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"iosuite.io/internal/benchmark"
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/retry"
+	"iosuite.io/internal/runpod"
+)
+
+// Deploy strategies for `endpoint deploy --strategy`.
+const (
+	StrategyDirect = "direct"
+	StrategyCanary = "canary"
+)
+
+// DefaultMaxRegressionPct is the --canary-max-regression default: how
+// much slower than the live endpoint a canary may be, per metric, and
+// still be promoted.
+const DefaultMaxRegressionPct = 10.0
+
+// canaryTeardownTimeout bounds deleting the canary once the rollout's
+// own context is done, e.g. after Ctrl-C.
+const canaryTeardownTimeout = 2 * time.Minute
+
+// CanaryThresholds decide whether a canary is promoted. Every
+// benchmark metric is treated as lower-is-better (the serve modules
+// publish latencies).
+type CanaryThresholds struct {
+	// MaxRegressionPct is the largest allowed increase of a metric
+	// over the live endpoint's value, in percent. 0 = no slower at all.
+	MaxRegressionPct float64
+	// Metrics limits the comparison to these metric names. Empty =
+	// every metric in the benchmark manifest.
+	Metrics []string
+}
+
+// CanaryInput is a deploy plus what's needed to benchmark it.
+type CanaryInput struct {
+	Deploy     DeployInput
+	Bench      *manifest.BenchmarkManifest
+	BenchInput []byte // the benchmark's input resource, already fetched
+	Policy     retry.Policy
+	Thresholds CanaryThresholds
+
+	// KeepCanary leaves a failed canary running for debugging. A
+	// promoted or interrupted canary is always deleted.
+	KeepCanary bool
+	// Progress receives one line per step; nil discards.
+	Progress io.Writer
+}
+
+// CanaryMetric is one metric, live endpoint vs canary.
+type CanaryMetric struct {
+	Name     string  `json:"name"`
+	Agg      string  `json:"agg"`
+	Baseline float64 `json:"baseline"`
+	Canary   float64 `json:"canary"`
+	DeltaPct float64 `json:"delta_pct"`
+	Pass     bool    `json:"pass"`
+}
+
+// CanaryResult is the rollout outcome. The JSON tags are the
+// `--output json` schema.
+type CanaryResult struct {
+	EndpointName     string         `json:"endpoint_name"`
+	EndpointID       string         `json:"endpoint_id"`
+	CanaryName       string         `json:"canary_name"`
+	CanaryEndpointID string         `json:"canary_endpoint_id"`
+	MaxRegressionPct float64        `json:"max_regression_pct"`
+	Metrics          []CanaryMetric `json:"metrics"`
+	Promoted         bool           `json:"promoted"`
+	Reason           string         `json:"reason,omitempty"` // why it wasn't promoted
+	CanaryDeleted    bool           `json:"canary_deleted"`
+	TeardownError    string         `json:"teardown_error,omitempty"`
+
+	// Deploy is the primary's update, when promoted.
+	Deploy *DeployResult `json:"deploy,omitempty"`
+}
+
+// canarySpec is spec renamed to run alongside the primary: same
+// image, env and settings, its own endpoint and template.
+func canarySpec(spec *Spec) *Spec {
+	c := *spec
+	c.Name = spec.Name + "-canary"
+	c.TemplateName = c.Name + "-tmpl"
+	return &c
+}
+
+// Canary rolls a deploy out through a parallel `<name>-canary`
+// endpoint: deploy the new spec there, benchmark it and the live
+// endpoint with the same workload, and only if every compared metric
+// is within the thresholds update the primary. The canary is torn
+// down either way (unless KeepCanary on failure), including when ctx
+// is cancelled part-way: teardown runs on a context of its own.
+//
+// A canary that loses the comparison, or whose benchmark fails, is
+// not an error: the result says so with Promoted false. The error
+// return is for failures around it (bad input, no live endpoint,
+// canary deploy).
+func Canary(ctx context.Context, in CanaryInput) (*CanaryResult, error) {
+	spec, err := Desired(in.Deploy)
+	if err != nil {
+		return nil, err
+	}
+	if in.Bench == nil {
+		return nil, fmt.Errorf("CanaryInput.Bench is required — the cobra layer resolves the benchmark manifest")
+	}
+	progress := in.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+	th := in.Thresholds
+
+	rp, err := runpod.NewAdmin(in.Deploy.API, in.Deploy.APIKey, in.Deploy.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	live, err := rp.FindEndpoint(ctx, spec.Name)
+	if err != nil {
+		return nil, fmt.Errorf("look up endpoint: %w", err)
+	}
+	if live == nil {
+		return nil, fmt.Errorf("no live endpoint named %q to compare a canary against — deploy it with --strategy direct first", spec.Name)
+	}
+
+	cspec := canarySpec(spec)
+	res := &CanaryResult{
+		EndpointName:     spec.Name,
+		EndpointID:       live.ID,
+		CanaryName:       cspec.Name,
+		MaxRegressionPct: th.MaxRegressionPct,
+		Metrics:          []CanaryMetric{},
+	}
+	fmt.Fprintf(progress, "canary: deploying %s (%s)\n", cspec.Name, cspec.Image)
+	cdep, err := ApplySpec(ctx, in.Deploy.API, in.Deploy.APIKey, in.Deploy.UserAgent, cspec)
+	if err != nil {
+		// The template, and on an interrupt the endpoint too, may have
+		// been saved before the deploy failed.
+		tctx, cancel := teardownContext(ctx)
+		defer cancel()
+		if ep, ferr := rp.FindEndpoint(tctx, cspec.Name); ferr != nil {
+			fmt.Fprintf(progress, "canary: cleanup lookup of %s: %v\n", cspec.Name, ferr)
+		} else if ep != nil {
+			if derr := rp.DeleteEndpoint(tctx, ep.ID); derr != nil {
+				fmt.Fprintf(progress, "canary: cleanup of endpoint %s: %v\n", ep.ID, derr)
+			}
+		}
+		if terr := rp.DeleteTemplate(tctx, cspec.TemplateName); terr != nil {
+			fmt.Fprintf(progress, "canary: cleanup of template %s: %v\n", cspec.TemplateName, terr)
+		}
+		return nil, fmt.Errorf("deploy canary: %w", err)
+	}
+	res.CanaryEndpointID = cdep.EndpointID
+
+	res.Metrics, res.Reason = runCanaryBenchmarks(ctx, in, th, live.ID, cdep.EndpointID, progress)
+	if ctx.Err() != nil {
+		res.Reason = fmt.Sprintf("interrupted: %v", context.Cause(ctx))
+	}
+	if res.Reason == "" {
+		fmt.Fprintf(progress, "canary: within thresholds; promoting to %s\n", spec.Name)
+		dep, err := ApplySpec(ctx, in.Deploy.API, in.Deploy.APIKey, in.Deploy.UserAgent, spec)
+		if err != nil {
+			res.Reason = fmt.Sprintf("promote: %v", err)
+		} else {
+			res.Promoted, res.Deploy = true, dep
+		}
+	}
+
+	if !res.Promoted && in.KeepCanary && ctx.Err() == nil {
+		fmt.Fprintf(progress, "canary: kept %s (%s) for inspection\n", cspec.Name, cdep.EndpointID)
+		return res, nil
+	}
+	fmt.Fprintf(progress, "canary: deleting %s\n", cspec.Name)
+	tctx, cancel := teardownContext(ctx)
+	defer cancel()
+	if err := rp.DeleteEndpoint(tctx, cdep.EndpointID); err != nil {
+		res.TeardownError = fmt.Sprintf("delete endpoint %s: %v", cdep.EndpointID, err)
+	} else if err := rp.DeleteTemplate(tctx, cspec.TemplateName); err != nil {
+		res.TeardownError = fmt.Sprintf("delete template %s: %v", cspec.TemplateName, err)
+	} else {
+		res.CanaryDeleted = true
+	}
+	return res, nil
+}
+
+// teardownContext is ctx without its cancellation, so the canary is
+// still deleted after an interrupt, bounded by canaryTeardownTimeout.
+func teardownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), canaryTeardownTimeout)
+}
+
+// runCanaryBenchmarks runs the workload against the live endpoint
+// then the canary and compares them. A non-empty reason means "don't
+// promote".
+func runCanaryBenchmarks(ctx context.Context, in CanaryInput, th CanaryThresholds, liveID, canaryID string, progress io.Writer) ([]CanaryMetric, string) {
+	fmt.Fprintf(progress, "canary: benchmarking live endpoint %s\n", liveID)
+	baseline, err := benchmark.Run(ctx, liveID, in.Deploy.APIKey, in.Bench, in.BenchInput, in.Policy)
+	if err != nil {
+		return []CanaryMetric{}, fmt.Sprintf("benchmark live endpoint: %v", err)
+	}
+	fmt.Fprintf(progress, "canary: benchmarking canary %s\n", canaryID)
+	candidate, err := benchmark.Run(ctx, canaryID, in.Deploy.APIKey, in.Bench, in.BenchInput, in.Policy)
+	if err != nil {
+		return []CanaryMetric{}, fmt.Sprintf("benchmark canary: %v", err)
+	}
+	return compareCanary(baseline, candidate, th)
+}
+
+// compareCanary is the pure half of the promotion decision.
+func compareCanary(baseline, candidate []benchmark.Result, th CanaryThresholds) ([]CanaryMetric, string) {
+	want := map[string]bool{}
+	for _, m := range th.Metrics {
+		want[m] = true
+	}
+	byName := make(map[string]benchmark.Result, len(candidate))
+	for _, r := range candidate {
+		byName[r.Name] = r
+	}
+
+	seen := map[string]bool{}
+	metrics := []CanaryMetric{}
+	var failed []string
+	for _, b := range baseline {
+		if len(want) > 0 && !want[b.Name] {
+			continue
+		}
+		seen[b.Name] = true
+		c, ok := byName[b.Name]
+		if !ok {
+			return metrics, fmt.Sprintf("canary benchmark has no metric %q", b.Name)
+		}
+		m := CanaryMetric{Name: b.Name, Agg: b.Agg, Baseline: b.Value, Canary: c.Value}
+		if b.Value > 0 {
+			m.DeltaPct = (c.Value - b.Value) / b.Value * 100
+			m.Pass = m.DeltaPct <= th.MaxRegressionPct
+		} else {
+			// No baseline to be a percentage of; only "no worse" passes.
+			m.Pass = c.Value <= b.Value
+		}
+		if !m.Pass {
+			failed = append(failed, b.Name)
+		}
+		metrics = append(metrics, m)
+	}
+	for _, name := range th.Metrics {
+		if !seen[name] {
+			return metrics, fmt.Sprintf("benchmark has no metric %q (--canary-metrics)", name)
+		}
+	}
+	if len(metrics) == 0 {
+		return metrics, "benchmark produced no metrics to compare"
+	}
+	if len(failed) > 0 {
+		return metrics, fmt.Sprintf("regressed more than %g%%: %v", th.MaxRegressionPct, failed)
+	}
+	return metrics, ""
+}
+
+// PrintCanary writes the comparison table and the outcome.
+func PrintCanary(w io.Writer, r *CanaryResult) {
+	fmt.Fprintf(w, "canary %s vs %s (max regression %g%%)\n", r.CanaryName, r.EndpointName, r.MaxRegressionPct)
+	for _, m := range r.Metrics {
+		verdict := "ok"
+		if !m.Pass {
+			verdict = "FAIL"
+		}
+		fmt.Fprintf(w, "  %-24s %12.2f → %-12.2f %+7.1f%%  %s\n", m.Name, m.Baseline, m.Canary, m.DeltaPct, verdict)
+	}
+	fmt.Fprintln(w)
+	switch {
+	case r.Promoted:
+		fmt.Fprintf(w, "promoted: %s now runs the new template\n\n", r.EndpointName)
+		PrintDeploy(w, r.Deploy)
+	default:
+		fmt.Fprintf(w, "not promoted: %s\n", r.Reason)
+		fmt.Fprintf(w, "%s is unchanged\n", r.EndpointName)
+	}
+	switch {
+	case r.CanaryDeleted:
+		fmt.Fprintf(w, "canary %s deleted\n", r.CanaryName)
+	case r.TeardownError != "":
+		fmt.Fprintf(w, "canary %s NOT deleted: %s\n", r.CanaryName, r.TeardownError)
+	default:
+		fmt.Fprintf(w, "canary %s kept: %s\n", r.CanaryName, r.CanaryEndpointID)
+	}
+}
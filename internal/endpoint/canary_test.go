@@ -0,0 +1,135 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"iosuite.io/internal/benchmark"
+)
+
+func TestCanarySpec(t *testing.T) {
+	spec := planSpec(t)
+	c := canarySpec(spec)
+	if c.Name != "real-esrgan-rtx-4090-canary" || c.TemplateName != "real-esrgan-rtx-4090-canary-tmpl" {
+		t.Errorf("canary names = %q / %q", c.Name, c.TemplateName)
+	}
+	if c.Image != spec.Image || c.GPUPool != spec.GPUPool {
+		t.Errorf("canary should keep the spec's settings: %+v", c)
+	}
+	if spec.Name != "real-esrgan-rtx-4090" {
+		t.Errorf("canarySpec mutated the primary spec: %q", spec.Name)
+	}
+}
+
+func TestCompareCanary(t *testing.T) {
+	baseline := []benchmark.Result{
+		{Name: "p50_latency_ms", Agg: "p50", Value: 100},
+		{Name: "p95_latency_ms", Agg: "p95", Value: 200},
+	}
+	cases := []struct {
+		name       string
+		candidate  []benchmark.Result
+		th         CanaryThresholds
+		wantReason string // "" = promote
+	}{
+		{
+			name:      "faster",
+			candidate: []benchmark.Result{{Name: "p50_latency_ms", Value: 90}, {Name: "p95_latency_ms", Value: 180}},
+			th:        CanaryThresholds{MaxRegressionPct: 10},
+		},
+		{
+			name:      "within threshold",
+			candidate: []benchmark.Result{{Name: "p50_latency_ms", Value: 110}, {Name: "p95_latency_ms", Value: 200}},
+			th:        CanaryThresholds{MaxRegressionPct: 10},
+		},
+		{
+			name:       "regressed",
+			candidate:  []benchmark.Result{{Name: "p50_latency_ms", Value: 100}, {Name: "p95_latency_ms", Value: 260}},
+			th:         CanaryThresholds{MaxRegressionPct: 10},
+			wantReason: "p95_latency_ms",
+		},
+		{
+			name:      "regressed metric not selected",
+			candidate: []benchmark.Result{{Name: "p50_latency_ms", Value: 100}, {Name: "p95_latency_ms", Value: 260}},
+			th:        CanaryThresholds{MaxRegressionPct: 10, Metrics: []string{"p50_latency_ms"}},
+		},
+		{
+			name:       "zero tolerance",
+			candidate:  []benchmark.Result{{Name: "p50_latency_ms", Value: 101}, {Name: "p95_latency_ms", Value: 200}},
+			th:         CanaryThresholds{},
+			wantReason: "p50_latency_ms",
+		},
+		{
+			name:       "unknown selected metric",
+			candidate:  []benchmark.Result{{Name: "p50_latency_ms", Value: 100}, {Name: "p95_latency_ms", Value: 200}},
+			th:         CanaryThresholds{MaxRegressionPct: 10, Metrics: []string{"throughput"}},
+			wantReason: `no metric "throughput"`,
+		},
+		{
+			name:       "canary missing a metric",
+			candidate:  []benchmark.Result{{Name: "p50_latency_ms", Value: 100}},
+			th:         CanaryThresholds{MaxRegressionPct: 10},
+			wantReason: `canary benchmark has no metric "p95_latency_ms"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics, reason := compareCanary(baseline, tc.candidate, tc.th)
+			if tc.wantReason == "" && reason != "" {
+				t.Fatalf("reason = %q, want promote (metrics %+v)", reason, metrics)
+			}
+			if tc.wantReason != "" && !strings.Contains(reason, tc.wantReason) {
+				t.Fatalf("reason = %q, want it to mention %q", reason, tc.wantReason)
+			}
+		})
+	}
+
+	metrics, _ := compareCanary(baseline, []benchmark.Result{{Name: "p50_latency_ms", Value: 110}, {Name: "p95_latency_ms", Value: 150}}, CanaryThresholds{MaxRegressionPct: 5})
+	if len(metrics) != 2 || metrics[0].DeltaPct != 10 || metrics[0].Pass || metrics[1].DeltaPct != -25 || !metrics[1].Pass {
+		t.Errorf("metrics = %+v", metrics)
+	}
+}
+
+func TestCanary_RequiresBenchmark(t *testing.T) {
+	in := CanaryInput{Deploy: DeployInput{
+		Provider: ProviderRunPod, APIKey: "test", Tool: "real-esrgan", GPUClass: "rtx-4090", Manifest: validManifest(),
+	}}
+	if _, err := Canary(context.Background(), in); err == nil || !strings.Contains(err.Error(), "Bench") {
+		t.Fatalf("expected benchmark manifest error, got %v", err)
+	}
+}
+
+func TestPrintCanary(t *testing.T) {
+	r := &CanaryResult{
+		EndpointName: "esrgan", CanaryName: "esrgan-canary", CanaryEndpointID: "ep2",
+		MaxRegressionPct: 10,
+		Metrics: []CanaryMetric{
+			{Name: "p50_latency_ms", Baseline: 100, Canary: 130, DeltaPct: 30},
+		},
+		Reason:        "regressed more than 10%: [p50_latency_ms]",
+		CanaryDeleted: true,
+	}
+	var buf bytes.Buffer
+	PrintCanary(&buf, r)
+	out := buf.String()
+	for _, want := range []string{"+30.0%", "FAIL", "not promoted", "esrgan is unchanged", "esrgan-canary deleted"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTeardownContext_OutlivesCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tctx, stop := teardownContext(ctx)
+	defer stop()
+	if err := tctx.Err(); err != nil {
+		t.Fatalf("teardown context is done with its parent: %v", err)
+	}
+	if _, ok := tctx.Deadline(); !ok {
+		t.Error("teardown context has no deadline")
+	}
+}
@@ -1,4 +1,4 @@
-// Package endpoint implements `iosuite endpoint deploy/list/destroy`.
+// Package endpoint implements `iosuite endpoint deploy/list/destroy/prune/status`.
 //
 // Manages remote provider endpoints (RunPod first; vast.ai / Modal
 // later) so users can stand up the GPU side of the stack with one
@@ -27,6 +27,7 @@ package endpoint
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -42,6 +43,10 @@ const (
 	ProviderRunPod = "runpod"
 )
 
+// ErrNoAPIKey is returned by every RunPod command run without a key,
+// naming the ways to supply one, recommended first.
+var ErrNoAPIKey = errors.New("RunPod API key required: run `iosuite auth login`, or use --runpod-api-key, RUNPOD_API_KEY or [runpod] api_key in config")
+
 // DeployInput captures everything `iosuite endpoint deploy` needs.
 // Manifest is required — the caller resolves it before calling
 // Deploy(). All per-tool knowledge lives there, not here.
@@ -66,32 +71,98 @@ type DeployInput struct {
 	// the manifest's value (which itself may be empty for tools
 	// that don't pin a driver).
 	MinCudaVersion string
-	UserAgent      string // surfaced to RunPod logs; iosuite/<version>
+	// WorkersMin is *int for the same reason as Flashboot: an
+	// explicit 0 must override a manifest that keeps a worker warm.
+	WorkersMin *int
+	// ScalerType / ScalerValue / ExecutionTimeoutS override the
+	// manifest's when non-zero.
+	ScalerType        string
+	ScalerValue       int
+	ExecutionTimeoutS int
+	// RegistryAuth (credential name), NetworkVolume (id or name) and
+	// DataCenters override the manifest's when non-empty. They're
+	// resolved to ids against the account at save time.
+	RegistryAuth  string
+	NetworkVolume string
+	DataCenters   []string
+	// NoRegistryAuth drops the manifest's registry credential, and
+	// with it one the live template has (--no-registry-auth).
+	NoRegistryAuth bool
+	// Env overrides the manifest's env by key (--env-file, then
+	// --env). Secrets maps env keys to RunPod secret names (--secret)
+	// and wins over both. Override values are masked in output.
+	Env       []manifest.EnvVar
+	Secrets   map[string]string
+	UserAgent string // surfaced to RunPod logs; iosuite/<version>
+	// API picks the admin backend that saves the template and
+	// endpoint: runpod.APIGraphQL (default, "") or runpod.APIREST.
+	API string
 }
 
-// DeployResult carries the outputs of a successful deploy.
+// DeployResult carries the outputs of a successful deploy. The JSON
+// tags are the `--output json` schema scripts depend on; the golden
+// file in testdata pins them.
 type DeployResult struct {
-	EndpointID     string
-	EndpointName   string
-	TemplateID     string
-	Image          string
-	GPUPool        string
-	Flashboot      bool
-	MinCudaVersion string
-	ManifestSource string // URL or filepath the manifest came from; informational
+	EndpointID     string `json:"endpoint_id"`
+	EndpointName   string `json:"endpoint_name"`
+	TemplateID     string `json:"template_id"`
+	Image          string `json:"image"`
+	GPUPool        string `json:"gpu_pool"`
+	Flashboot      bool   `json:"flashboot"`
+	MinCudaVersion string `json:"min_cuda_version"`
+	ManifestSource string `json:"manifest_source"` // URL or filepath the manifest came from; informational
+
+	// Spec is the full desired state that was saved, for the deploy
+	// history. Not part of the output schema.
+	Spec *Spec `json:"-"`
 }
 
-// Deploy runs the full create-or-update flow. Idempotent: re-running
-// with the same name updates the existing template + endpoint
-// in-place, picking up image / scaler changes between iosuite
-// releases. Caller writes the human-friendly output (we return
-// structured data so they can format it).
-func Deploy(ctx context.Context, in DeployInput) (*DeployResult, error) {
+// ListEntry is one row of `iosuite endpoint list`. Its own type
+// (rather than runpod.Endpoint) so the output schema doesn't move
+// when RunPod's GraphQL field names do.
+type ListEntry struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	TemplateID string `json:"template_id"`
+}
+
+// Spec is the desired state Deploy converges a template + endpoint
+// to: the manifest with per-call overrides applied and the RunPod
+// client's defaults filled in. Plan diffs it against what's live.
+type Spec struct {
+	Name              string          `json:"name"`
+	TemplateName      string          `json:"template_name"`
+	Image             string          `json:"image"`
+	ContainerDiskGB   int             `json:"container_disk_gb"`
+	Env               []runpod.EnvVar `json:"env"`
+	GPUPool           string          `json:"gpu_pool"`
+	WorkersMin        int             `json:"workers_min"`
+	WorkersMax        int             `json:"workers_max"`
+	IdleTimeoutS      int             `json:"idle_timeout_s"`
+	Flashboot         bool            `json:"flashboot"`
+	MinCudaVersion    string          `json:"min_cuda_version"`
+	ScalerType        string          `json:"scaler_type"`
+	ScalerValue       int             `json:"scaler_value"`
+	ExecutionTimeoutS int             `json:"execution_timeout_s"` // 0 = RunPod's default
+	RegistryAuth      string          `json:"registry_auth"`       // credential name; "" = public image
+	NetworkVolume     string          `json:"network_volume"`      // id or name; "" = none
+	DataCenters       []string        `json:"data_centers"`        // nil = anywhere (or the volume's)
+	// SensitiveEnv lists the Env keys whose values came from
+	// per-deploy overrides; Masked() hides them for printing.
+	SensitiveEnv []string `json:"sensitive_env,omitempty"`
+}
+
+// Desired validates in and resolves it to a Spec without touching
+// the network.
+func Desired(in DeployInput) (*Spec, error) {
 	if in.Provider != ProviderRunPod {
 		return nil, fmt.Errorf("provider %q is not supported (only 'runpod' is implemented)", in.Provider)
 	}
 	if in.APIKey == "" {
-		return nil, fmt.Errorf("RunPod API key required (--runpod-api-key, RUNPOD_API_KEY, or [runpod] api_key in config)")
+		return nil, ErrNoAPIKey
+	}
+	if in.API != "" && !runpod.ValidAPI(in.API) {
+		return nil, fmt.Errorf("--api %q: want %s or %s", in.API, runpod.APIGraphQL, runpod.APIREST)
 	}
 	if in.Manifest == nil {
 		return nil, fmt.Errorf("DeployInput.Manifest is required — the cobra layer should resolve it via internal/registry + internal/manifest before calling Deploy")
@@ -115,22 +186,130 @@ func Deploy(ctx context.Context, in DeployInput) (*DeployResult, error) {
 		// endpoint instead of cluttering the account with copies.
 		name = fmt.Sprintf("%s-%s", in.Tool, in.GPUClass)
 	}
-	templateName := name + "-tmpl"
 
-	rp := runpod.NewClient(in.APIKey, in.UserAgent)
+	flashboot := m.Endpoint.FlashbootDefault
+	if in.Flashboot != nil {
+		flashboot = *in.Flashboot
+	}
+	minCuda := m.Endpoint.MinCudaVersion
+	if in.MinCudaVersion != "" {
+		minCuda = in.MinCudaVersion
+	}
+	workersMax := defaultIfZero(defaultIfZero(in.WorkersMax, m.Endpoint.WorkersMaxDefault), runpod.DefaultWorkersMax)
+	workersMin := m.Endpoint.WorkersMinDefault
+	if in.WorkersMin != nil {
+		workersMin = *in.WorkersMin
+	}
+	if workersMin < 0 || workersMin > workersMax {
+		return nil, fmt.Errorf("workers-min must be between 0 and workers-max (%d), got %d", workersMax, workersMin)
+	}
+	scalerType := m.Endpoint.ScalerType
+	if in.ScalerType != "" {
+		scalerType = in.ScalerType
+	}
+	if scalerType == "" {
+		scalerType = runpod.DefaultScalerType
+	}
+	// The manifest package leaves provider values to the provider:
+	// a bad endpoint.scaler_type is caught here, named as such.
+	if !runpod.ValidScalerType(scalerType) {
+		what := "scaler-type"
+		if in.ScalerType == "" {
+			what = "manifest endpoint.scaler_type"
+		}
+		return nil, fmt.Errorf("%s must be %s or %s, got %q", what, runpod.ScalerQueueDelay, runpod.ScalerRequestCount, scalerType)
+	}
+	if in.ScalerValue < 0 || in.ExecutionTimeoutS < 0 {
+		return nil, fmt.Errorf("scaler-value and execution-timeout must be >= 0")
+	}
+	registryAuth := m.RegistryAuth
+	if in.RegistryAuth != "" {
+		registryAuth = in.RegistryAuth
+	}
+	if in.NoRegistryAuth {
+		if in.RegistryAuth != "" {
+			return nil, fmt.Errorf("registry-auth and no-registry-auth are mutually exclusive")
+		}
+		registryAuth = ""
+	}
+	volume := m.Endpoint.NetworkVolume
+	if in.NetworkVolume != "" {
+		volume = in.NetworkVolume
+	}
+	dataCenters := m.Endpoint.DataCenters
+	if len(in.DataCenters) > 0 {
+		dataCenters = in.DataCenters
+	}
+	env, sensitive, err := mergeEnv(m.Env, in.Env, in.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{
+		Name:            name,
+		TemplateName:    name + "-tmpl",
+		Image:           m.Image,
+		ContainerDiskGB: defaultIfZero(m.Endpoint.ContainerDiskGB, runpod.DefaultContainerDiskGB),
+		Env:             env,
+		GPUPool:         pool,
+		WorkersMin:      workersMin,
+		WorkersMax:      workersMax,
+		IdleTimeoutS:    defaultIfZero(defaultIfZero(in.IdleTimeoutS, m.Endpoint.IdleTimeoutSDefault), runpod.DefaultIdleTimeoutS),
+		Flashboot:       flashboot,
+		MinCudaVersion:  minCuda,
+		ScalerType:      scalerType,
+		ScalerValue:     defaultIfZero(defaultIfZero(in.ScalerValue, m.Endpoint.ScalerValue), runpod.DefaultScalerValue),
+		// No client default: 0 leaves RunPod's own limit in place.
+		ExecutionTimeoutS: defaultIfZero(in.ExecutionTimeoutS, m.Endpoint.ExecutionTimeoutS),
+		RegistryAuth:      registryAuth,
+		NetworkVolume:     volume,
+		DataCenters:       dataCenters,
+		SensitiveEnv:      sensitive,
+	}, nil
+}
+
+// Deploy runs the full create-or-update flow. Idempotent: re-running
+// with the same name updates the existing template + endpoint
+// in-place, picking up image / scaler changes between iosuite
+// releases. Caller writes the human-friendly output (we return
+// structured data so they can format it).
+func Deploy(ctx context.Context, in DeployInput) (*DeployResult, error) {
+	spec, err := Desired(in)
+	if err != nil {
+		return nil, err
+	}
+	return ApplySpec(ctx, in.API, in.APIKey, in.UserAgent, spec)
+}
+
+// ApplySpec saves spec through the find-or-save template + endpoint
+// flow, over the admin API named api ("" = GraphQL). Deploy builds
+// the spec from a manifest; rollback replays one recorded in the
+// deploy history.
+func ApplySpec(ctx context.Context, api, apiKey, userAgent string, spec *Spec) (*DeployResult, error) {
+	if apiKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	rp, err := runpod.NewAdmin(api, apiKey, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := resolveRefs(ctx, rp, spec)
+	if err != nil {
+		return nil, err
+	}
 
 	// Template — find or save. Image + disk + env all come from the
 	// manifest so a tool bump (e.g. new image tag, new env) lands by
 	// pushing a new manifest, not by patching iosuite.
-	existingTmpl, err := rp.FindTemplate(ctx, templateName)
+	existingTmpl, err := rp.FindTemplate(ctx, spec.TemplateName)
 	if err != nil {
 		return nil, fmt.Errorf("look up template: %w", err)
 	}
 	tmplInput := runpod.SaveTemplateInput{
-		Name:            templateName,
-		Image:           m.Image,
-		ContainerDiskGB: m.Endpoint.ContainerDiskGB,
-		Env:             toRunpodEnv(m.Env),
+		Name:            spec.TemplateName,
+		Image:           spec.Image,
+		ContainerDiskGB: spec.ContainerDiskGB,
+		Env:             spec.Env,
+		RegistryAuthID:  refs.registryAuthID,
 	}
 	if existingTmpl != nil {
 		tmplInput.ExistingID = existingTmpl.ID
@@ -142,27 +321,24 @@ func Deploy(ctx context.Context, in DeployInput) (*DeployResult, error) {
 
 	// Endpoint — find or save. Defaults from the manifest, overridable
 	// per call.
-	existing, err := rp.FindEndpoint(ctx, name)
+	existing, err := rp.FindEndpoint(ctx, spec.Name)
 	if err != nil {
 		return nil, fmt.Errorf("look up endpoint: %w", err)
 	}
-	flashboot := m.Endpoint.FlashbootDefault
-	if in.Flashboot != nil {
-		flashboot = *in.Flashboot
-	}
-	minCuda := m.Endpoint.MinCudaVersion
-	if in.MinCudaVersion != "" {
-		minCuda = in.MinCudaVersion
-	}
 	epInput := runpod.SaveEndpointInput{
-		Name:           name,
-		TemplateID:     templateID,
-		GPUPool:        pool,
-		WorkersMin:     0,
-		WorkersMax:     defaultIfZero(in.WorkersMax, m.Endpoint.WorkersMaxDefault),
-		IdleTimeoutS:   defaultIfZero(in.IdleTimeoutS, m.Endpoint.IdleTimeoutSDefault),
-		Flashboot:      flashboot,
-		MinCudaVersion: minCuda,
+		Name:              spec.Name,
+		TemplateID:        templateID,
+		GPUPool:           spec.GPUPool,
+		WorkersMin:        spec.WorkersMin,
+		WorkersMax:        spec.WorkersMax,
+		IdleTimeoutS:      spec.IdleTimeoutS,
+		Flashboot:         spec.Flashboot,
+		MinCudaVersion:    spec.MinCudaVersion,
+		ScalerType:        spec.ScalerType,
+		ScalerValue:       spec.ScalerValue,
+		ExecutionTimeoutS: spec.ExecutionTimeoutS,
+		NetworkVolumeID:   refs.networkVolumeID,
+		Locations:         refs.locations,
 	}
 	if existing != nil {
 		epInput.ExistingID = existing.ID
@@ -174,58 +350,154 @@ func Deploy(ctx context.Context, in DeployInput) (*DeployResult, error) {
 
 	return &DeployResult{
 		EndpointID:     endpointID,
-		EndpointName:   name,
+		EndpointName:   spec.Name,
 		TemplateID:     templateID,
-		Image:          m.Image,
-		GPUPool:        pool,
-		Flashboot:      flashboot,
-		MinCudaVersion: minCuda,
+		Image:          spec.Image,
+		GPUPool:        spec.GPUPool,
+		Flashboot:      spec.Flashboot,
+		MinCudaVersion: spec.MinCudaVersion,
+		Spec:           spec,
 	}, nil
 }
 
 // List returns every serverless endpoint on the configured account.
 // Round 2 supports RunPod only; the cobra layer enforces that.
-func List(ctx context.Context, provider, apiKey, userAgent string) ([]runpod.Endpoint, error) {
+func List(ctx context.Context, provider, apiKey, userAgent string) ([]ListEntry, error) {
 	if provider != ProviderRunPod {
 		return nil, fmt.Errorf("provider %q is not supported", provider)
 	}
 	if apiKey == "" {
-		return nil, fmt.Errorf("RunPod API key required (--runpod-api-key, RUNPOD_API_KEY, or [runpod] api_key in config)")
+		return nil, ErrNoAPIKey
 	}
 	rp := runpod.NewClient(apiKey, userAgent)
-	return rp.ListEndpoints(ctx)
+	eps, err := rp.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ListEntry, len(eps))
+	for i, e := range eps {
+		out[i] = ListEntry{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID}
+	}
+	return out, nil
+}
+
+// PrintList writes the human-friendly endpoint list.
+func PrintList(w io.Writer, entries []ListEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "(no endpoints on this account)")
+		return
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "  %s  %s  template=%s\n", e.ID, e.Name, e.TemplateID)
+	}
+}
+
+// DestroyResult is what `iosuite endpoint destroy` removed. The JSON
+// tags are the `--output json` schema.
+type DestroyResult struct {
+	Deleted string `json:"deleted"` // endpoint id
+	Name    string `json:"name"`
+	// TemplateDeleted is the paired `<name>-tmpl` template, when it
+	// was deleted too.
+	TemplateDeleted string `json:"template_deleted,omitempty"`
+	// TemplateKept says why a paired template was left in place.
+	TemplateKept string `json:"template_kept,omitempty"`
 }
 
 // Destroy deletes the endpoint with the given id (or, when id is
-// empty and name is provided, the endpoint matching that name).
-// Returns the ID it actually deleted so the caller can confirm.
-func Destroy(ctx context.Context, provider, apiKey, userAgent, id, name string) (string, error) {
+// empty and name is provided, the endpoint matching that name), then
+// the `<name>-tmpl` template Deploy created for it unless
+// keepTemplate. A template another endpoint also uses is never
+// deleted, and failing to delete the template doesn't fail Destroy —
+// the endpoint is already gone; the result says what was left.
+func Destroy(ctx context.Context, provider, apiKey, userAgent, id, name string, keepTemplate bool) (*DestroyResult, error) {
 	if provider != ProviderRunPod {
-		return "", fmt.Errorf("provider %q is not supported", provider)
+		return nil, fmt.Errorf("provider %q is not supported", provider)
 	}
 	if apiKey == "" {
-		return "", fmt.Errorf("RunPod API key required (--runpod-api-key, RUNPOD_API_KEY, or [runpod] api_key in config)")
+		return nil, ErrNoAPIKey
+	}
+	if id == "" && name == "" {
+		return nil, fmt.Errorf("must provide either an endpoint id or --name")
 	}
 	rp := runpod.NewClient(apiKey, userAgent)
 
-	target := id
-	if target == "" {
-		if name == "" {
-			return "", fmt.Errorf("must provide either an endpoint id or --name")
+	all, err := rp.ListEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list endpoints: %w", err)
+	}
+	target := findEndpoint(all, id, name)
+	if target == nil {
+		if id != "" {
+			return nil, fmt.Errorf("no endpoint with id %q on this account", id)
 		}
-		ep, err := rp.FindEndpoint(ctx, name)
+		return nil, fmt.Errorf("no endpoint named %q on this account", name)
+	}
+	res := &DestroyResult{Deleted: target.ID, Name: target.Name}
+
+	var tmpl *runpod.Template
+	if keepTemplate {
+		res.TemplateKept = "--keep-template given"
+	} else {
+		tmpl, err = rp.FindTemplate(ctx, target.Name+"-tmpl")
 		if err != nil {
-			return "", fmt.Errorf("look up endpoint by name: %w", err)
+			return nil, fmt.Errorf("look up template: %w", err)
+		}
+		res.TemplateKept = pairedTemplateConflict(*target, all, tmpl)
+	}
+
+	if err := rp.DeleteEndpoint(ctx, target.ID); err != nil {
+		return nil, fmt.Errorf("delete endpoint %s: %w", target.ID, err)
+	}
+	if tmpl != nil && res.TemplateKept == "" {
+		if err := rp.DeleteTemplate(ctx, tmpl.Name); err != nil {
+			res.TemplateKept = fmt.Sprintf("delete template %s: %v", tmpl.Name, err)
+		} else {
+			res.TemplateDeleted = tmpl.Name
 		}
-		if ep == nil {
-			return "", fmt.Errorf("no endpoint named %q on this account", name)
+	}
+	return res, nil
+}
+
+// findEndpoint picks the endpoint by id, else the first named name
+// (as FindEndpoint does).
+func findEndpoint(all []runpod.Endpoint, id, name string) *runpod.Endpoint {
+	for i := range all {
+		if (id != "" && all[i].ID == id) || (id == "" && all[i].Name == name) {
+			return &all[i]
+		}
+	}
+	return nil
+}
+
+// pairedTemplateConflict reports why tmpl — the template named
+// `<ep.Name>-tmpl`, nil when there is none — must not be deleted
+// along with ep, or "" when it can be: it has to be the template ep
+// actually runs, and no other endpoint may use it.
+func pairedTemplateConflict(ep runpod.Endpoint, all []runpod.Endpoint, tmpl *runpod.Template) string {
+	if tmpl == nil {
+		return ""
+	}
+	if tmpl.ID != ep.TemplateID {
+		return fmt.Sprintf("%s is not the template %s runs", tmpl.Name, ep.Name)
+	}
+	for _, other := range all {
+		if other.ID != ep.ID && other.TemplateID == tmpl.ID {
+			return fmt.Sprintf("%s is also used by endpoint %s", tmpl.Name, other.Name)
 		}
-		target = ep.ID
 	}
-	if err := rp.DeleteEndpoint(ctx, target); err != nil {
-		return "", fmt.Errorf("delete endpoint %s: %w", target, err)
+	return ""
+}
+
+// PrintDestroy writes what Destroy removed.
+func PrintDestroy(w io.Writer, r *DestroyResult) {
+	fmt.Fprintf(w, "deleted endpoint: %s (%s)\n", r.Deleted, r.Name)
+	switch {
+	case r.TemplateDeleted != "":
+		fmt.Fprintf(w, "deleted template: %s\n", r.TemplateDeleted)
+	case r.TemplateKept != "":
+		fmt.Fprintf(w, "kept template: %s\n", r.TemplateKept)
 	}
-	return target, nil
 }
 
 // PrintDeploy writes a human-friendly summary of a Deploy result.
@@ -250,6 +522,58 @@ func PrintDeploy(w io.Writer, r *DeployResult) {
 	fmt.Fprintf(w, "  export RUNPOD_ENDPOINT_ID=%s\n", r.EndpointID)
 }
 
+// accountRefs are a Spec's by-name references resolved to the ids
+// RunPod stores.
+type accountRefs struct {
+	registryAuthID  string
+	networkVolumeID string
+	locations       string // comma-separated data center ids
+}
+
+// resolveRefs looks up the registry credential and network volume a
+// spec names. A volume pins workers to its data center: with no data
+// centers given that becomes the constraint, and data centers that
+// exclude it are an error (workers there could never mount it).
+func resolveRefs(ctx context.Context, rp runpod.Admin, spec *Spec) (accountRefs, error) {
+	var refs accountRefs
+	if spec.RegistryAuth != "" {
+		auth, err := rp.FindRegistryAuth(ctx, spec.RegistryAuth)
+		if err != nil {
+			return refs, fmt.Errorf("registry auth: %w", err)
+		}
+		refs.registryAuthID = auth.ID
+	}
+	dcs := spec.DataCenters
+	if spec.NetworkVolume != "" {
+		vol, err := rp.FindNetworkVolume(ctx, spec.NetworkVolume)
+		if err != nil {
+			return refs, fmt.Errorf("network volume: %w", err)
+		}
+		refs.networkVolumeID = vol.ID
+		if err := checkVolumeDataCenter(vol, dcs); err != nil {
+			return refs, err
+		}
+		if len(dcs) == 0 && vol.DataCenterID != "" {
+			dcs = []string{vol.DataCenterID}
+		}
+	}
+	refs.locations = strings.Join(dcs, ",")
+	return refs, nil
+}
+
+func checkVolumeDataCenter(vol *runpod.NetworkVolume, dcs []string) error {
+	if len(dcs) == 0 || vol.DataCenterID == "" {
+		return nil
+	}
+	for _, dc := range dcs {
+		if dc == vol.DataCenterID {
+			return nil
+		}
+	}
+	return fmt.Errorf("network volume %s (%s) is in data center %s, but workers are restricted to %s — add %s or drop the data center constraint",
+		vol.Name, vol.ID, vol.DataCenterID, strings.Join(dcs, ","), vol.DataCenterID)
+}
+
 func defaultIfZero(v, def int) int {
 	if v == 0 {
 		return def
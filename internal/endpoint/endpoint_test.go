@@ -2,10 +2,13 @@ package endpoint
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
 	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
 )
 
 func validManifest() *manifest.Manifest {
@@ -47,10 +50,10 @@ func TestDeploy_RejectsMissingAPIKey(t *testing.T) {
 		GPUClass: "rtx-4090",
 		Manifest: validManifest(),
 	})
-	if err == nil {
-		t.Fatal("expected error for missing API key, got nil")
+	if !errors.Is(err, ErrNoAPIKey) {
+		t.Fatalf("err = %v, want ErrNoAPIKey", err)
 	}
-	for _, want := range []string{"runpod-api-key", "RUNPOD_API_KEY", "config"} {
+	for _, want := range []string{"iosuite auth login", "runpod-api-key", "RUNPOD_API_KEY", "config"} {
 		if !strings.Contains(err.Error(), want) {
 			t.Errorf("error should mention %q: %v", want, err)
 		}
@@ -92,3 +95,126 @@ func TestDeploy_RejectsUnknownGPUClass(t *testing.T) {
 		t.Errorf("error should list valid GPU classes from the manifest: %v", err)
 	}
 }
+
+func TestDesired_Scaling(t *testing.T) {
+	in := DeployInput{
+		Provider: ProviderRunPod,
+		APIKey:   "test",
+		Tool:     "real-esrgan",
+		GPUClass: "rtx-4090",
+		Manifest: validManifest(),
+	}
+	spec, err := Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.WorkersMin != 0 || spec.ScalerType != "QUEUE_DELAY" || spec.ScalerValue != 4 || spec.ExecutionTimeoutS != 0 {
+		t.Errorf("defaults = %+v", spec)
+	}
+
+	in.Manifest.Endpoint.WorkersMinDefault = 1
+	in.Manifest.Endpoint.ScalerType = "REQUEST_COUNT"
+	in.Manifest.Endpoint.ScalerValue = 3
+	in.Manifest.Endpoint.ExecutionTimeoutS = 120
+	spec, err = Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.WorkersMin != 1 || spec.ScalerType != "REQUEST_COUNT" || spec.ScalerValue != 3 || spec.ExecutionTimeoutS != 120 {
+		t.Errorf("manifest values = %+v", spec)
+	}
+
+	// An explicit --workers-min 0 beats the manifest's warm worker.
+	zero := 0
+	in.WorkersMin = &zero
+	in.ScalerType, in.ScalerValue, in.ExecutionTimeoutS = "QUEUE_DELAY", 8, 60
+	spec, err = Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.WorkersMin != 0 || spec.ScalerType != "QUEUE_DELAY" || spec.ScalerValue != 8 || spec.ExecutionTimeoutS != 60 {
+		t.Errorf("flag overrides = %+v", spec)
+	}
+
+	in.RegistryAuth, in.NoRegistryAuth = "", true
+	spec, err = Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.RegistryAuth != "" {
+		t.Errorf("no-registry-auth kept %q, want the manifest's dropped", spec.RegistryAuth)
+	}
+	in.RegistryAuth = "ghcr-fork"
+	if _, err := Desired(in); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("both flags: err = %v", err)
+	}
+}
+
+func TestDesired_RejectsBadScaling(t *testing.T) {
+	five := 5
+	cases := []struct {
+		name string
+		mod  func(*DeployInput)
+		want string
+	}{
+		{"workers-min above max", func(in *DeployInput) { in.WorkersMin = &five }, "workers-min"},
+		{"unknown scaler", func(in *DeployInput) { in.ScalerType = "CPU" }, "scaler-type"},
+		{"unknown manifest scaler", func(in *DeployInput) { in.Manifest.Endpoint.ScalerType = "GPU_UTIL" }, "endpoint.scaler_type"},
+		{"negative scaler value", func(in *DeployInput) { in.ScalerValue = -1 }, "scaler-value"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := DeployInput{
+				Provider: ProviderRunPod,
+				APIKey:   "test",
+				Tool:     "real-esrgan",
+				GPUClass: "rtx-4090",
+				Manifest: validManifest(),
+			}
+			tc.mod(&in)
+			_, err := Desired(in)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestDesired_AccountRefsPrecedence(t *testing.T) {
+	m := validManifest()
+	m.RegistryAuth = "ghcr-ro"
+	m.Endpoint.NetworkVolume = "weights"
+	m.Endpoint.DataCenters = []string{"EU-RO-1"}
+	in := DeployInput{Provider: ProviderRunPod, APIKey: "test", Tool: "real-esrgan", GPUClass: "rtx-4090", Manifest: m}
+
+	spec, err := Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.RegistryAuth != "ghcr-ro" || spec.NetworkVolume != "weights" || !reflect.DeepEqual(spec.DataCenters, []string{"EU-RO-1"}) {
+		t.Errorf("manifest values = %+v", spec)
+	}
+
+	in.RegistryAuth, in.NetworkVolume, in.DataCenters = "ghcr-fork", "vol9", []string{"US-KS-2", "US-TX-3"}
+	spec, err = Desired(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.RegistryAuth != "ghcr-fork" || spec.NetworkVolume != "vol9" || !reflect.DeepEqual(spec.DataCenters, []string{"US-KS-2", "US-TX-3"}) {
+		t.Errorf("flag overrides = %+v", spec)
+	}
+}
+
+func TestCheckVolumeDataCenter(t *testing.T) {
+	vol := &runpod.NetworkVolume{ID: "vol1", Name: "weights", DataCenterID: "EU-RO-1"}
+	if err := checkVolumeDataCenter(vol, nil); err != nil {
+		t.Errorf("no constraint: %v", err)
+	}
+	if err := checkVolumeDataCenter(vol, []string{"US-KS-2", "EU-RO-1"}); err != nil {
+		t.Errorf("volume's data center included: %v", err)
+	}
+	err := checkVolumeDataCenter(vol, []string{"US-KS-2"})
+	if err == nil || !strings.Contains(err.Error(), "EU-RO-1") {
+		t.Errorf("expected an error naming the volume's data center, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package endpoint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
+)
+
+// MaskedValue replaces sensitive env values in everything iosuite
+// prints. The deploy history keeps the real values (rollback needs
+// them); its file is 0600.
+const MaskedValue = "(sensitive)"
+
+var (
+	envKeyRe     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	secretNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// SecretRef is the template env value that makes RunPod inject the
+// account secret `name` when a worker starts. The value never passes
+// through iosuite.
+func SecretRef(name string) string {
+	return "{{ RUNPOD_SECRET_" + name + " }}"
+}
+
+// mergeEnv layers the template env, lowest precedence first:
+//
+//  1. the manifest's env (public, versioned with the serve repo)
+//  2. overrides (--env-file, then --env; the caller orders them)
+//  3. secrets, KEY → RunPod secret name (--secret)
+//
+// A later layer replaces an earlier one's value for the same key in
+// place, so the manifest's ordering survives. Keys first set by an
+// override are appended in the order given; secrets sorted by key.
+// Returns the env and the keys whose values must be masked: every
+// override key (it may carry a credential) — but not secret refs,
+// which are just names.
+func mergeEnv(base, overrides []manifest.EnvVar, secrets map[string]string) ([]runpod.EnvVar, []string, error) {
+	env := toRunpodEnv(base)
+	index := make(map[string]int, len(env))
+	for i, e := range env {
+		index[e.Key] = i
+	}
+	set := func(k, v string) {
+		if i, ok := index[k]; ok {
+			env[i].Value = v
+			return
+		}
+		index[k] = len(env)
+		env = append(env, runpod.EnvVar{Key: k, Value: v})
+	}
+
+	sensitive := map[string]bool{}
+	for _, o := range overrides {
+		if !envKeyRe.MatchString(o.Key) {
+			return nil, nil, fmt.Errorf("env key %q is not a valid variable name", o.Key)
+		}
+		set(o.Key, o.Value)
+		sensitive[o.Key] = true
+	}
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := secrets[k]
+		if !envKeyRe.MatchString(k) {
+			return nil, nil, fmt.Errorf("secret env key %q is not a valid variable name", k)
+		}
+		if !secretNameRe.MatchString(name) {
+			return nil, nil, fmt.Errorf("secret name %q for %s: RunPod secret names are letters, digits, '_' and '-'", name, k)
+		}
+		set(k, SecretRef(name))
+		delete(sensitive, k)
+	}
+
+	masked := make([]string, 0, len(sensitive))
+	for k := range sensitive {
+		masked = append(masked, k)
+	}
+	sort.Strings(masked)
+	if len(masked) == 0 {
+		masked = nil
+	}
+	return env, masked, nil
+}
+
+// Masked returns a copy of s with its sensitive env values replaced
+// by MaskedValue, for printing.
+func (s Spec) Masked() Spec {
+	if len(s.SensitiveEnv) == 0 {
+		return s
+	}
+	env := make([]runpod.EnvVar, len(s.Env))
+	for i, e := range s.Env {
+		if s.isSensitive(e.Key) {
+			e.Value = MaskedValue
+		}
+		env[i] = e
+	}
+	s.Env = env
+	return s
+}
+
+func (s *Spec) isSensitive(key string) bool {
+	for _, k := range s.SensitiveEnv {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEnvFile reads a dotenv-style file: KEY=VALUE per line, blank
+// lines and `#` comments ignored, an optional leading `export`, and
+// values optionally wrapped in single quotes (literal) or double
+// quotes (Go escapes like \n). Returned in file order.
+func ParseEnvFile(r io.Reader, source string) ([]manifest.EnvVar, error) {
+	var out []manifest.EnvVar
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		k, v, ok := strings.Cut(text, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !envKeyRe.MatchString(k) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", source, line)
+		}
+		v = strings.TrimSpace(v)
+		switch {
+		case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+			v = v[1 : len(v)-1]
+		case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+			uq, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s: bad quoted value: %w", source, line, k, err)
+			}
+			v = uq
+		}
+		out = append(out, manifest.EnvVar{Key: k, Value: v})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", source, err)
+	}
+	return out, nil
+}
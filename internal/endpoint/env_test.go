@@ -0,0 +1,115 @@
+package endpoint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
+)
+
+func TestMergeEnv_Precedence(t *testing.T) {
+	base := []manifest.EnvVar{{Key: "MODEL", Value: "x4"}, {Key: "TILE", Value: "0"}, {Key: "S3_KEY", Value: "public-default"}}
+	overrides := []manifest.EnvVar{
+		{Key: "TILE", Value: "1"},       // from --env-file
+		{Key: "S3_BUCKET", Value: "b1"}, // new key, appended
+		{Key: "TILE", Value: "2"},       // --env after the file wins
+	}
+	secrets := map[string]string{"S3_KEY": "s3_key", "API_TOKEN": "token"}
+
+	env, masked, err := mergeEnv(base, overrides, secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []runpod.EnvVar{
+		{Key: "MODEL", Value: "x4"},
+		{Key: "TILE", Value: "2"},
+		{Key: "S3_KEY", Value: "{{ RUNPOD_SECRET_s3_key }}"},
+		{Key: "S3_BUCKET", Value: "b1"},
+		{Key: "API_TOKEN", Value: "{{ RUNPOD_SECRET_token }}"},
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("env =\n %+v\nwant\n %+v", env, want)
+	}
+	// Overrides are masked; secret refs and manifest values aren't.
+	if !reflect.DeepEqual(masked, []string{"S3_BUCKET", "TILE"}) {
+		t.Errorf("masked = %v", masked)
+	}
+}
+
+func TestMergeEnv_RejectsBadNames(t *testing.T) {
+	if _, _, err := mergeEnv(nil, []manifest.EnvVar{{Key: "1BAD", Value: "x"}}, nil); err == nil {
+		t.Error("expected error for env key starting with a digit")
+	}
+	if _, _, err := mergeEnv(nil, nil, map[string]string{"OK": "has space"}); err == nil || !strings.Contains(err.Error(), "secret name") {
+		t.Errorf("expected secret name error, got %v", err)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	body := `# output uploads
+export S3_BUCKET=outputs
+S3_KEY = 'abc#123'
+GREETING="hello\nworld"
+
+EMPTY=
+`
+	got, err := ParseEnvFile(strings.NewReader(body), "prod.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []manifest.EnvVar{
+		{Key: "S3_BUCKET", Value: "outputs"},
+		{Key: "S3_KEY", Value: "abc#123"},
+		{Key: "GREETING", Value: "hello\nworld"},
+		{Key: "EMPTY", Value: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	_, err = ParseEnvFile(strings.NewReader("OK=1\nnot a pair\n"), "prod.env")
+	if err == nil || !strings.Contains(err.Error(), "prod.env:2") {
+		t.Errorf("expected line-numbered error, got %v", err)
+	}
+}
+
+func TestSpecMasked(t *testing.T) {
+	spec := Spec{
+		Env:          []runpod.EnvVar{{Key: "MODEL", Value: "x4"}, {Key: "S3_KEY", Value: "hunter2"}},
+		SensitiveEnv: []string{"S3_KEY"},
+	}
+	m := spec.Masked()
+	if m.Env[0].Value != "x4" || m.Env[1].Value != MaskedValue {
+		t.Errorf("masked env = %+v", m.Env)
+	}
+	if spec.Env[1].Value != "hunter2" {
+		t.Error("Masked modified the original spec")
+	}
+}
+
+func TestDiffSpec_MasksSensitiveEnv(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	spec.Env = append(spec.Env, runpod.EnvVar{Key: "S3_KEY", Value: "new-secret"})
+	spec.SensitiveEnv = []string{"S3_KEY"}
+	tmpl.Env = append(tmpl.Env, runpod.EnvVar{Key: "S3_KEY", Value: "old-secret"})
+
+	p := diffSpec(spec, accountRefs{}, tmpl, ep)
+	if len(p.Changes) != 1 || p.Changes[0].Old != MaskedValue || p.Changes[0].New != MaskedValue {
+		t.Fatalf("changes = %+v, want one masked env change", p.Changes)
+	}
+}
+
+func TestDiffSpec_MasksLiveEnvTheSpecDoesNotDeclare(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	// Set by an --env override on an earlier deploy, absent now.
+	tmpl.Env = append(tmpl.Env, runpod.EnvVar{Key: "S3_KEY", Value: "old-secret"})
+
+	p := diffSpec(spec, accountRefs{}, tmpl, ep)
+	if len(p.Changes) != 1 || p.Changes[0].Old != MaskedValue || p.Changes[0].New != "" {
+		t.Fatalf("changes = %+v, want the removed value masked", p.Changes)
+	}
+}
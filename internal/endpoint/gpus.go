@@ -0,0 +1,103 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
+)
+
+// GPUEntry is one row of `iosuite endpoint gpus`: a GPU class the
+// tool's manifest declares, the RunPod pool --gpu-class maps it to,
+// and that pool's stock and price right now.
+type GPUEntry struct {
+	GPUClass string `json:"gpu_class"`
+	Pool     string `json:"pool"`
+	Stock    string `json:"stock"` // runpod.StockHigh … StockNone, StockUnknown
+	// PricePerSecUSD is the cheapest in-stock GPU type's rate (see
+	// runpod.PoolPricePerSecond); 0 = not priced.
+	PricePerSecUSD float64  `json:"price_per_sec_usd"`
+	GPUTypes       []string `json:"gpu_types"`
+}
+
+// GPUs lists every GPU class in m's gpu_pools with live stock and
+// price from RunPod's GPU catalogue, sorted by class.
+func GPUs(ctx context.Context, provider, apiKey, userAgent string, m *manifest.Manifest) ([]GPUEntry, error) {
+	if provider != ProviderRunPod {
+		return nil, fmt.Errorf("provider %q is not supported", provider)
+	}
+	if apiKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	catalogue, err := runpod.NewClient(apiKey, userAgent).ListGPUTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read GPU catalogue: %w", err)
+	}
+	return newGPUEntries(m.GPUPools, catalogue), nil
+}
+
+func newGPUEntries(pools map[string]string, catalogue []runpod.GPUType) []GPUEntry {
+	out := make([]GPUEntry, 0, len(pools))
+	for cls, pool := range pools {
+		out = append(out, GPUEntry{
+			GPUClass:       cls,
+			Pool:           pool,
+			Stock:          runpod.PoolStock(catalogue, pool),
+			PricePerSecUSD: runpod.PoolPricePerSecond(catalogue, pool),
+			GPUTypes:       runpod.PoolGPUTypes(pool),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GPUClass < out[j].GPUClass })
+	return out
+}
+
+// StockWarning returns a warning when the pool in's --gpu-class maps
+// to has no stock, else "". Best effort: a failed lookup (or a GPU
+// class the manifest doesn't declare, which deploy reports itself)
+// returns "" so the check never blocks a deploy.
+func StockWarning(ctx context.Context, in DeployInput) string {
+	if in.Provider != ProviderRunPod || in.APIKey == "" || in.Manifest == nil {
+		return ""
+	}
+	pool, ok := in.Manifest.GPUPools[in.GPUClass]
+	if !ok {
+		return ""
+	}
+	catalogue, err := runpod.NewClient(in.APIKey, in.UserAgent).ListGPUTypes(ctx)
+	if err != nil {
+		return ""
+	}
+	return stockWarning(in.GPUClass, pool, catalogue)
+}
+
+func stockWarning(class, pool string, catalogue []runpod.GPUType) string {
+	if runpod.PoolStock(catalogue, pool) != runpod.StockNone {
+		return ""
+	}
+	return fmt.Sprintf("GPU pool %s (--gpu-class %s) is out of stock on RunPod; workers will queue until capacity frees up "+
+		"(see `iosuite endpoint gpus` for alternatives)", pool, class)
+}
+
+// PrintGPUs writes the human-friendly GPU table.
+func PrintGPUs(w io.Writer, entries []GPUEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "(manifest declares no GPU classes)")
+		return
+	}
+	fmt.Fprintf(w, "  %-12s %-14s %-8s %-12s %s\n", "CLASS", "POOL", "STOCK", "$/SEC", "GPU TYPES")
+	for _, e := range entries {
+		price := "-"
+		if e.PricePerSecUSD > 0 {
+			price = fmt.Sprintf("%.6f", e.PricePerSecUSD)
+		}
+		types := strings.Join(e.GPUTypes, ", ")
+		if types == "" {
+			types = "(pool not known to iosuite)"
+		}
+		fmt.Fprintf(w, "  %-12s %-14s %-8s %-12s %s\n", e.GPUClass, e.Pool, e.Stock, price, types)
+	}
+}
@@ -0,0 +1,74 @@
+package endpoint
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"iosuite.io/internal/output/outputtest"
+	"iosuite.io/internal/runpod"
+)
+
+func gpuCatalogue() []runpod.GPUType {
+	stock := func(s string) *string { return &s }
+	g4090 := runpod.GPUType{ID: "NVIDIA GeForce RTX 4090", SecurePrice: 0.72}
+	g4090.LowestPrice.StockStatus = stock(runpod.StockMedium)
+	gL40S := runpod.GPUType{ID: "NVIDIA L40S", SecurePrice: 0.90}
+	return []runpod.GPUType{g4090, gL40S}
+}
+
+func TestNewGPUEntries(t *testing.T) {
+	got := newGPUEntries(map[string]string{"rtx-4090": "ADA_24", "l40s": "ADA_48_PRO", "mystery": "NOPE_1"}, gpuCatalogue())
+	if len(got) != 3 || got[0].GPUClass != "l40s" || got[1].GPUClass != "mystery" || got[2].GPUClass != "rtx-4090" {
+		t.Fatalf("entries not sorted by class: %+v", got)
+	}
+	if e := got[2]; e.Pool != "ADA_24" || e.Stock != runpod.StockMedium || math.Abs(e.PricePerSecUSD-0.0002) > 1e-12 ||
+		len(e.GPUTypes) != 1 || e.GPUTypes[0] != "NVIDIA GeForce RTX 4090" {
+		t.Errorf("rtx-4090 = %+v", e)
+	}
+	if e := got[0]; e.Stock != runpod.StockNone || math.Abs(e.PricePerSecUSD-0.00025) > 1e-12 {
+		t.Errorf("l40s = %+v, want out of stock at $0.9/hr", e)
+	}
+	if e := got[1]; e.Stock != runpod.StockUnknown || e.GPUTypes != nil {
+		t.Errorf("mystery = %+v", e)
+	}
+}
+
+func TestStockWarning(t *testing.T) {
+	if w := stockWarning("rtx-4090", "ADA_24", gpuCatalogue()); w != "" {
+		t.Errorf("in-stock pool warned: %s", w)
+	}
+	w := stockWarning("l40s", "ADA_48_PRO", gpuCatalogue())
+	if !strings.Contains(w, "ADA_48_PRO (--gpu-class l40s) is out of stock") {
+		t.Errorf("warning = %q", w)
+	}
+	if w := stockWarning("mystery", "NOPE_1", gpuCatalogue()); w != "" {
+		t.Errorf("unknown pool warned: %s", w)
+	}
+}
+
+func TestPrintGPUs(t *testing.T) {
+	var buf bytes.Buffer
+	PrintGPUs(&buf, newGPUEntries(map[string]string{"rtx-4090": "ADA_24", "mystery": "NOPE_1"}, gpuCatalogue()))
+	out := buf.String()
+	for _, want := range []string{
+		"CLASS        POOL           STOCK    $/SEC",
+		"rtx-4090     ADA_24         Medium   0.000200     NVIDIA GeForce RTX 4090",
+		"mystery      NOPE_1         Unknown  -            (pool not known to iosuite)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGolden_GPUs(t *testing.T) {
+	outputtest.Golden(t, "gpus.golden.json", []GPUEntry{{
+		GPUClass:       "rtx-4090",
+		Pool:           "ADA_24",
+		Stock:          runpod.StockMedium,
+		PricePerSecUSD: 0.0002,
+		GPUTypes:       []string{"NVIDIA GeForce RTX 4090"},
+	}})
+}
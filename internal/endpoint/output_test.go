@@ -0,0 +1,83 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+
+	"iosuite.io/internal/output/outputtest"
+	"iosuite.io/internal/runpod"
+)
+
+func TestGolden_DeployResult(t *testing.T) {
+	outputtest.Golden(t, "deploy_result.golden.json", &DeployResult{
+		EndpointID:     "abc123",
+		EndpointName:   "real-esrgan-rtx-4090",
+		TemplateID:     "tmpl456",
+		Image:          "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.2",
+		GPUPool:        "ADA_24",
+		Flashboot:      true,
+		MinCudaVersion: "12.8",
+		ManifestSource: "https://raw.githubusercontent.com/ls-ads/real-esrgan-serve/runpod-trt-0.2.2/deploy/runpod.json",
+	})
+}
+
+func TestGolden_List(t *testing.T) {
+	outputtest.Golden(t, "list.golden.json", []ListEntry{
+		{ID: "abc123", Name: "real-esrgan-rtx-4090", TemplateID: "tmpl456"},
+		{ID: "def789", Name: "real-esrgan-l40s", TemplateID: "tmpl012"},
+	})
+}
+
+func TestGolden_Status(t *testing.T) {
+	outputtest.Golden(t, "status.golden.json", newStatusResult(
+		&runpod.EndpointDetail{
+			ID: "abc123", Name: "real-esrgan-rtx-4090", TemplateID: "tmpl456",
+			GPUIDs: "ADA_24", WorkersMax: 3, IdleTimeout: 30,
+			FlashBootType: "FLASHBOOT", MinCudaVersion: "12.8",
+			ScalerType: "QUEUE_DELAY", ScalerValue: 4,
+			Template: &runpod.Template{ImageName: "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.2"},
+		},
+		&runpod.Health{
+			Jobs:    runpod.HealthJobs{InQueue: 3, InProgress: 2, Completed: 12, Failed: 1},
+			Workers: runpod.HealthWorkers{Idle: 1, Ready: 1, Running: 2, Initializing: 1},
+		},
+		time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	))
+}
+
+func TestGolden_Apply(t *testing.T) {
+	outputtest.Golden(t, "apply.golden.json", []ApplyResult{
+		{Name: "real-esrgan-rtx-4090", Action: ActionUpdated, EndpointID: "abc123", Tool: "real-esrgan", GPUClass: "rtx-4090"},
+		{Name: "esrgan-big", Action: ActionFailed, Tool: "real-esrgan", GPUClass: "l40s", Error: "save endpoint: runpod graphql: HTTP 500"},
+		{Name: "old-experiment", Action: ActionDeleted, EndpointID: "def789"},
+	})
+}
+
+func TestGolden_Canary(t *testing.T) {
+	outputtest.Golden(t, "canary.golden.json", &CanaryResult{
+		EndpointName:     "real-esrgan-rtx-4090",
+		EndpointID:       "abc123",
+		CanaryName:       "real-esrgan-rtx-4090-canary",
+		CanaryEndpointID: "can456",
+		MaxRegressionPct: 10,
+		Metrics: []CanaryMetric{
+			{Name: "p50_latency_ms", Agg: "p50", Baseline: 20, Canary: 19, DeltaPct: -5, Pass: true},
+		},
+		Promoted:      true,
+		CanaryDeleted: true,
+		Deploy: &DeployResult{
+			EndpointID:   "abc123",
+			EndpointName: "real-esrgan-rtx-4090",
+			TemplateID:   "tmpl456",
+			Image:        "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.3",
+			GPUPool:      "ADA_24",
+			Flashboot:    true,
+		},
+	})
+}
+
+func TestGolden_Volumes(t *testing.T) {
+	outputtest.Golden(t, "volumes.golden.json", []VolumeEntry{
+		{ID: "vol123", Name: "esrgan-weights", SizeGB: 50, DataCenter: "EU-RO-1"},
+	})
+}
@@ -0,0 +1,230 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"iosuite.io/internal/runpod"
+)
+
+// Plan is what Deploy would change, computed without saving
+// anything: the desired Spec diffed field by field against the live
+// template and endpoint. Backs `endpoint deploy --dry-run` and
+// `endpoint diff`.
+type Plan struct {
+	EndpointName   string   `json:"endpoint_name"`
+	EndpointID     string   `json:"endpoint_id,omitempty"` // empty = would be created
+	TemplateName   string   `json:"template_name"`
+	TemplateID     string   `json:"template_id,omitempty"` // empty = would be created
+	CreateTemplate bool     `json:"create_template"`
+	CreateEndpoint bool     `json:"create_endpoint"`
+	Changes        []Change `json:"changes"`
+}
+
+// Change is one field that differs. Old is empty for a resource
+// that doesn't exist yet; New is empty for a removed env var.
+type Change struct {
+	Resource string `json:"resource"` // template | endpoint
+	Field    string `json:"field"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+// HasChanges reports whether applying the plan would change anything.
+func (p *Plan) HasChanges() bool {
+	return p.CreateTemplate || p.CreateEndpoint || len(p.Changes) > 0
+}
+
+// PlanDeploy fetches the live template + endpoint that Deploy would
+// update and diffs them against Desired(in).
+func PlanDeploy(ctx context.Context, in DeployInput) (*Plan, error) {
+	spec, err := Desired(in)
+	if err != nil {
+		return nil, err
+	}
+	rp := runpod.NewClient(in.APIKey, in.UserAgent)
+	refs, err := resolveRefs(ctx, rp, spec)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := rp.FindTemplate(ctx, spec.TemplateName)
+	if err != nil {
+		return nil, fmt.Errorf("look up template: %w", err)
+	}
+	ep, err := rp.FindEndpointDetail(ctx, spec.Name)
+	if err != nil {
+		return nil, fmt.Errorf("look up endpoint: %w", err)
+	}
+	return diffSpec(spec, refs, tmpl, ep), nil
+}
+
+// diffSpec is the pure half of PlanDeploy. refs are spec's resolved
+// account references; tmpl / ep are nil when the resource doesn't
+// exist yet.
+func diffSpec(spec *Spec, refs accountRefs, tmpl *runpod.Template, ep *runpod.EndpointDetail) *Plan {
+	p := &Plan{
+		EndpointName:   spec.Name,
+		TemplateName:   spec.TemplateName,
+		CreateTemplate: tmpl == nil,
+		CreateEndpoint: ep == nil,
+		Changes:        []Change{},
+	}
+	add := func(resource, field, old, new string) {
+		if old != new {
+			p.Changes = append(p.Changes, Change{Resource: resource, Field: field, Old: old, New: new})
+		}
+	}
+
+	var cur runpod.Template
+	if tmpl != nil {
+		cur = *tmpl
+		p.TemplateID = tmpl.ID
+	}
+	add("template", "image", cur.ImageName, spec.Image)
+	add("template", "container_disk_gb", itoaIf(tmpl != nil, cur.ContainerDiskGB), strconv.Itoa(spec.ContainerDiskGB))
+	add("template", "registry_auth_id", cur.RegistryAuthID, refs.registryAuthID)
+	// Only values the spec takes from the manifest are known to be
+	// printable. A live value is masked unless the spec declares the
+	// same key as one: a key set by hand in the console, or by an
+	// --env override since dropped, may well hold a credential.
+	plain := map[string]bool{}
+	for _, e := range spec.Env {
+		plain[e.Key] = !spec.isSensitive(e.Key)
+	}
+	for _, c := range diffEnv(cur.Env, spec.Env) {
+		// diffEnv compared the real values; only the printed ones
+		// are masked, so a changed secret still shows as a change.
+		if !plain[c.Field] {
+			c.Old = maskIfSet(c.Old)
+		}
+		if spec.isSensitive(c.Field) {
+			c.New = maskIfSet(c.New)
+		}
+		c.Resource, c.Field = "template", "env."+c.Field
+		p.Changes = append(p.Changes, c)
+	}
+
+	var live runpod.EndpointDetail
+	if ep != nil {
+		live = *ep
+		p.EndpointID = ep.ID
+	}
+	// A re-created template gets a new id, so the endpoint would be
+	// re-pointed; an existing one keeps its id.
+	if ep != nil && tmpl != nil {
+		add("endpoint", "template_id", live.TemplateID, tmpl.ID)
+	} else if ep != nil {
+		add("endpoint", "template_id", live.TemplateID, "(new template)")
+	}
+	add("endpoint", "gpu_pool", live.GPUIDs, spec.GPUPool)
+	add("endpoint", "workers_min", itoaIf(ep != nil, live.WorkersMin), strconv.Itoa(spec.WorkersMin))
+	add("endpoint", "workers_max", itoaIf(ep != nil, live.WorkersMax), strconv.Itoa(spec.WorkersMax))
+	add("endpoint", "idle_timeout_s", itoaIf(ep != nil, live.IdleTimeout), strconv.Itoa(spec.IdleTimeoutS))
+	oldFlash := ""
+	if ep != nil {
+		oldFlash = strconv.FormatBool(live.FlashBootType == "FLASHBOOT")
+	}
+	add("endpoint", "flashboot", oldFlash, strconv.FormatBool(spec.Flashboot))
+	add("endpoint", "min_cuda_version", live.MinCudaVersion, spec.MinCudaVersion)
+	add("endpoint", "scaler_type", live.ScalerType, spec.ScalerType)
+	add("endpoint", "scaler_value", itoaIf(ep != nil, live.ScalerValue), strconv.Itoa(spec.ScalerValue))
+	// 0 is sent too, and clears a live timeout back to RunPod's default.
+	add("endpoint", "execution_timeout_s", itoaIf(ep != nil, live.ExecutionTimeoutMs/1000), strconv.Itoa(spec.ExecutionTimeoutS))
+	add("endpoint", "network_volume_id", live.NetworkVolumeID, refs.networkVolumeID)
+	add("endpoint", "data_centers", live.Locations, refs.locations)
+	return p
+}
+
+func maskIfSet(v string) string {
+	if v == "" {
+		return ""
+	}
+	return MaskedValue
+}
+
+func itoaIf(ok bool, v int) string {
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// diffEnv compares env lists by key, sorted, so reordering the
+// manifest's env doesn't show up as a change.
+func diffEnv(old, new []runpod.EnvVar) []Change {
+	o := make(map[string]string, len(old))
+	for _, e := range old {
+		o[e.Key] = e.Value
+	}
+	n := make(map[string]string, len(new))
+	for _, e := range new {
+		n[e.Key] = e.Value
+	}
+	keys := make([]string, 0, len(o)+len(n))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	for k := range n {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var out []Change
+	for _, k := range keys {
+		if o[k] != n[k] {
+			out = append(out, Change{Field: k, Old: o[k], New: n[k]})
+		}
+	}
+	return out
+}
+
+// PrintPlan writes a terraform-flavoured summary: `+` create, `~`
+// update, one line per changed field.
+func PrintPlan(w io.Writer, p *Plan) {
+	if !p.HasChanges() {
+		fmt.Fprintf(w, "endpoint %s is up to date — no changes.\n", p.EndpointName)
+		return
+	}
+	header := func(resource, name, id string, create bool) {
+		switch {
+		case create:
+			fmt.Fprintf(w, "+ %s %s (create)\n", resource, name)
+		default:
+			fmt.Fprintf(w, "~ %s %s (%s)\n", resource, name, id)
+		}
+	}
+	for _, r := range []struct {
+		resource, name, id string
+		create             bool
+	}{
+		{"template", p.TemplateName, p.TemplateID, p.CreateTemplate},
+		{"endpoint", p.EndpointName, p.EndpointID, p.CreateEndpoint},
+	} {
+		var fields []Change
+		for _, c := range p.Changes {
+			if c.Resource == r.resource {
+				fields = append(fields, c)
+			}
+		}
+		if !r.create && len(fields) == 0 {
+			continue
+		}
+		header(r.resource, r.name, r.id, r.create)
+		for _, c := range fields {
+			switch {
+			case r.create:
+				fmt.Fprintf(w, "    %-20s %q\n", c.Field, c.New)
+			case c.New == "":
+				fmt.Fprintf(w, "  - %-20s %q\n", c.Field, c.Old)
+			case c.Old == "":
+				fmt.Fprintf(w, "  + %-20s %q\n", c.Field, c.New)
+			default:
+				fmt.Fprintf(w, "    %-20s %q → %q\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package endpoint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"iosuite.io/internal/manifest"
+	"iosuite.io/internal/runpod"
+)
+
+func planSpec(t *testing.T) *Spec {
+	t.Helper()
+	m := validManifest()
+	m.Env = []manifest.EnvVar{{Key: "MODEL", Value: "realesrgan-x4plus"}, {Key: "TILE", Value: "1"}}
+	spec, err := Desired(DeployInput{
+		Provider: ProviderRunPod,
+		APIKey:   "test",
+		Tool:     "real-esrgan",
+		GPUClass: "rtx-4090",
+		Manifest: m,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// liveMatching returns a template + endpoint already in the state
+// spec describes.
+func liveMatching(spec *Spec) (*runpod.Template, *runpod.EndpointDetail) {
+	tmpl := &runpod.Template{
+		ID: "tmpl1", Name: spec.TemplateName, ImageName: spec.Image,
+		ContainerDiskGB: spec.ContainerDiskGB,
+		// Reordered on purpose: env order isn't a change.
+		Env: []runpod.EnvVar{{Key: "TILE", Value: "1"}, {Key: "MODEL", Value: "realesrgan-x4plus"}},
+	}
+	ep := &runpod.EndpointDetail{
+		ID: "ep1", Name: spec.Name, TemplateID: "tmpl1", GPUIDs: spec.GPUPool,
+		WorkersMin: spec.WorkersMin, WorkersMax: spec.WorkersMax, IdleTimeout: spec.IdleTimeoutS,
+		FlashBootType: "FLASHBOOT", MinCudaVersion: spec.MinCudaVersion,
+		ScalerType: spec.ScalerType, ScalerValue: spec.ScalerValue,
+	}
+	return tmpl, ep
+}
+
+func TestDiffSpec_NoChanges(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	p := diffSpec(spec, accountRefs{}, tmpl, ep)
+	if p.HasChanges() {
+		t.Errorf("expected no changes, got %+v", p.Changes)
+	}
+	var buf bytes.Buffer
+	PrintPlan(&buf, p)
+	if !strings.Contains(buf.String(), "no changes") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestDiffSpec_FieldLevelChanges(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	tmpl.ImageName = "ghcr.io/ls-ads/real-esrgan-serve:old"
+	tmpl.Env = []runpod.EnvVar{{Key: "MODEL", Value: "realesrgan-x2plus"}, {Key: "DEBUG", Value: "1"}}
+	ep.WorkersMax = 1
+	ep.FlashBootType = "OFF"
+
+	p := diffSpec(spec, accountRefs{}, tmpl, ep)
+	got := map[string]Change{}
+	for _, c := range p.Changes {
+		got[c.Resource+"."+c.Field] = c
+	}
+	want := map[string][2]string{
+		"template.image":       {"ghcr.io/ls-ads/real-esrgan-serve:old", spec.Image},
+		"template.env.MODEL":   {"realesrgan-x2plus", "realesrgan-x4plus"},
+		"template.env.DEBUG":   {MaskedValue, ""}, // not from the manifest, so never printed
+		"template.env.TILE":    {"", "1"},
+		"endpoint.workers_max": {"1", "2"},
+		"endpoint.flashboot":   {"false", "true"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("changes = %+v, want %d of them", p.Changes, len(want))
+	}
+	for k, w := range want {
+		c, ok := got[k]
+		if !ok || c.Old != w[0] || c.New != w[1] {
+			t.Errorf("%s = %+v, want %q → %q", k, c, w[0], w[1])
+		}
+	}
+	if p.CreateTemplate || p.CreateEndpoint {
+		t.Error("nothing should be created")
+	}
+}
+
+func TestDiffSpec_CreateWhenMissing(t *testing.T) {
+	spec := planSpec(t)
+	p := diffSpec(spec, accountRefs{}, nil, nil)
+	if !p.CreateTemplate || !p.CreateEndpoint || !p.HasChanges() {
+		t.Fatalf("expected both resources to be created: %+v", p)
+	}
+	var buf bytes.Buffer
+	PrintPlan(&buf, p)
+	out := buf.String()
+	for _, want := range []string{"+ template real-esrgan-rtx-4090-tmpl (create)", "+ endpoint real-esrgan-rtx-4090 (create)", `gpu_pool             "ADA_24"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDiffSpec_Scaling(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	spec.WorkersMin, spec.ScalerType, spec.ScalerValue = 1, "REQUEST_COUNT", 2
+	spec.ExecutionTimeoutS = 300
+	ep.ExecutionTimeoutMs = 600000
+
+	got := map[string]Change{}
+	for _, c := range diffSpec(spec, accountRefs{}, tmpl, ep).Changes {
+		got[c.Field] = c
+	}
+	want := map[string][2]string{
+		"workers_min":         {"0", "1"},
+		"scaler_type":         {"QUEUE_DELAY", "REQUEST_COUNT"},
+		"scaler_value":        {"4", "2"},
+		"execution_timeout_s": {"600", "300"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("changes = %+v, want %d of them", got, len(want))
+	}
+	for k, w := range want {
+		if c := got[k]; c.Old != w[0] || c.New != w[1] {
+			t.Errorf("%s = %+v, want %q → %q", k, c, w[0], w[1])
+		}
+	}
+
+	// A dropped execution timeout is sent as 0, clearing the live one.
+	spec.ExecutionTimeoutS = 0
+	var cleared bool
+	for _, c := range diffSpec(spec, accountRefs{}, tmpl, ep).Changes {
+		cleared = cleared || c.Field == "execution_timeout_s" && c.Old == "600" && c.New == "0"
+	}
+	if !cleared {
+		t.Error("dropping the execution timeout isn't a 600 → 0 change")
+	}
+}
+
+func TestDiffSpec_AccountRefs(t *testing.T) {
+	spec := planSpec(t)
+	tmpl, ep := liveMatching(spec)
+	refs := accountRefs{registryAuthID: "auth1", networkVolumeID: "vol1", locations: "EU-RO-1"}
+	ep.NetworkVolumeID = "vol0"
+
+	got := map[string]Change{}
+	for _, c := range diffSpec(spec, refs, tmpl, ep).Changes {
+		got[c.Resource+"."+c.Field] = c
+	}
+	want := map[string][2]string{
+		"template.registry_auth_id":  {"", "auth1"},
+		"endpoint.network_volume_id": {"vol0", "vol1"},
+		"endpoint.data_centers":      {"", "EU-RO-1"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("changes = %+v, want %d of them", got, len(want))
+	}
+	for k, w := range want {
+		if c := got[k]; c.Old != w[0] || c.New != w[1] {
+			t.Errorf("%s = %+v, want %q → %q", k, c, w[0], w[1])
+		}
+	}
+}
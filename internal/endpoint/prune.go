@@ -0,0 +1,318 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"iosuite.io/internal/runpod"
+)
+
+// DefaultPruneIdleDays is the `endpoint prune --idle-days` default.
+const DefaultPruneIdleDays = 14
+
+// ActivityFileName is the prune activity log's name inside
+// config.Dir().
+const ActivityFileName = "endpoint-activity.json"
+
+// Prune candidate kinds.
+const (
+	PruneEndpoint = "endpoint"
+	PruneTemplate = "template"
+)
+
+// Activity is the last job activity prune saw on one endpoint.
+// RunPod has no "last request at" field and /health's job counts are
+// running totals over a short retention window, so idleness is
+// measured across prune runs: Since moves forward whenever the
+// completed + failed total grew or the endpoint was busy. Activity
+// that both starts and ages out of the window between two runs is
+// missed — run prune (e.g. from cron) more often than the window.
+type Activity struct {
+	Jobs  int       `json:"jobs"`
+	Since time.Time `json:"since"`
+}
+
+// ActivityLog is Activity by endpoint id, persisted as
+// ActivityFileName.
+type ActivityLog map[string]Activity
+
+// LoadActivity reads the log at path; a missing file is an empty log.
+func LoadActivity(path string) (ActivityLog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ActivityLog{}, nil
+		}
+		return nil, fmt.Errorf("read activity log: %w", err)
+	}
+	log := ActivityLog{}
+	if err := json.Unmarshal(raw, &log); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return log, nil
+}
+
+// Save writes the log to path.
+func (l ActivityLog) Save(path string) error {
+	raw, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, append(raw, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write activity log: %w", err)
+	}
+	return nil
+}
+
+// observe records one /health snapshot of endpoint id and returns how
+// long it has been idle, or false when this is the first time it's
+// been seen (no baseline yet).
+func (l ActivityLog) observe(id string, h *runpod.Health, now time.Time) (time.Duration, bool) {
+	jobs := h.Jobs.Completed + h.Jobs.Failed
+	busy := h.Jobs.InQueue+h.Jobs.InProgress+h.Workers.Running > 0
+	prev, seen := l[id]
+	switch {
+	case !seen || busy || jobs > prev.Jobs:
+		l[id] = Activity{Jobs: jobs, Since: now}
+		return 0, seen
+	case jobs < prev.Jobs:
+		// Old jobs aged out of the window; not activity.
+		l[id] = Activity{Jobs: jobs, Since: prev.Since}
+	}
+	return now.Sub(prev.Since), true
+}
+
+// forget drops endpoints that no longer exist.
+func (l ActivityLog) forget(existing []runpod.Endpoint) {
+	keep := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		keep[e.ID] = true
+	}
+	for id := range l {
+		if !keep[id] {
+			delete(l, id)
+		}
+	}
+}
+
+// PruneCandidate is one thing prune would delete. The JSON tags are
+// the `--output json` schema.
+type PruneCandidate struct {
+	Kind   string `json:"kind"` // endpoint | template
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	// Template is an idle endpoint's paired template, deleted with it.
+	Template string `json:"template,omitempty"`
+
+	// Set by Prune.
+	Action string `json:"action,omitempty"` // deleted | failed
+	Error  string `json:"error,omitempty"`
+}
+
+// PruneInput configures FindPrunable.
+type PruneInput struct {
+	APIKey    string
+	UserAgent string
+	// IdleFor is how long an endpoint must have had no jobs to be
+	// pruned. 0 = only look for orphan templates.
+	IdleFor time.Duration
+	// Activity is read and updated in place with this run's
+	// observations; the caller persists it.
+	Activity ActivityLog
+	Now      time.Time // zero = time.Now()
+}
+
+// PrunePlan is what FindPrunable found.
+type PrunePlan struct {
+	Candidates []PruneCandidate `json:"candidates"`
+	// Tracking lists endpoints seen for the first time: their
+	// idleness is measured from this run on.
+	Tracking []string `json:"tracking,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// FindPrunable looks for iosuite-created resources nobody is using:
+// serverless `*-tmpl` templates no endpoint references, and (with
+// IdleFor) endpoints running their own `<name>-tmpl` template that
+// have had no jobs for at least IdleFor. Endpoints iosuite didn't
+// create are never candidates. Nothing is deleted.
+func FindPrunable(ctx context.Context, in PruneInput) (*PrunePlan, error) {
+	if in.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	if in.Activity == nil {
+		return nil, fmt.Errorf("PruneInput.Activity is required")
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	rp := runpod.NewClient(in.APIKey, in.UserAgent)
+	endpoints, err := rp.ListEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list endpoints: %w", err)
+	}
+	templates, err := rp.ListTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	plan := &PrunePlan{}
+	idle := map[string]time.Duration{}
+	if in.IdleFor > 0 {
+		in.Activity.forget(endpoints)
+		for _, e := range endpoints {
+			if ownTemplate(e, templates) == nil {
+				continue
+			}
+			h, err := rp.Health(ctx, e.ID)
+			if err != nil {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %v (skipped)", e.Name, err))
+				continue
+			}
+			d, known := in.Activity.observe(e.ID, h, now)
+			if !known {
+				plan.Tracking = append(plan.Tracking, e.Name)
+				continue
+			}
+			idle[e.ID] = d
+		}
+	}
+	plan.Candidates = planPrune(endpoints, templates, idle, in.IdleFor)
+	return plan, nil
+}
+
+// ownTemplate returns the `<name>-tmpl` template e runs, or nil when
+// e runs something else (it wasn't created by iosuite).
+func ownTemplate(e runpod.Endpoint, templates []runpod.Template) *runpod.Template {
+	for i := range templates {
+		if templates[i].ID == e.TemplateID && templates[i].Name == e.Name+"-tmpl" {
+			return &templates[i]
+		}
+	}
+	return nil
+}
+
+// planPrune is the pure half of FindPrunable: idle endpoints first
+// (longest idle first), then orphan templates by name.
+func planPrune(endpoints []runpod.Endpoint, templates []runpod.Template, idle map[string]time.Duration, idleFor time.Duration) []PruneCandidate {
+	out := []PruneCandidate{}
+	if idleFor > 0 {
+		var eps []PruneCandidate
+		for _, e := range endpoints {
+			d, ok := idle[e.ID]
+			if !ok || d < idleFor {
+				continue
+			}
+			c := PruneCandidate{Kind: PruneEndpoint, ID: e.ID, Name: e.Name,
+				Reason: fmt.Sprintf("no jobs for %s", formatDays(d))}
+			if t := ownTemplate(e, templates); t != nil && pairedTemplateConflict(e, endpoints, t) == "" {
+				c.Template = t.Name
+			}
+			eps = append(eps, c)
+		}
+		sort.SliceStable(eps, func(i, j int) bool { return idle[eps[i].ID] > idle[eps[j].ID] })
+		out = append(out, eps...)
+	}
+
+	used := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		used[e.TemplateID] = true
+	}
+	var orphans []PruneCandidate
+	for _, t := range templates {
+		if !t.IsServerless || !strings.HasSuffix(t.Name, "-tmpl") || used[t.ID] {
+			continue
+		}
+		orphans = append(orphans, PruneCandidate{Kind: PruneTemplate, ID: t.ID, Name: t.Name,
+			Reason: "no endpoint uses it"})
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Name < orphans[j].Name })
+	return append(out, orphans...)
+}
+
+func formatDays(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// Prune deletes the candidates: each endpoint, then its paired
+// template, then the orphan templates. A failure is recorded on its
+// candidate and the rest still run.
+func Prune(ctx context.Context, apiKey, userAgent string, cands []PruneCandidate) []PruneCandidate {
+	rp := runpod.NewClient(apiKey, userAgent)
+	out := make([]PruneCandidate, len(cands))
+	for i, c := range cands {
+		var err error
+		switch c.Kind {
+		case PruneEndpoint:
+			err = rp.DeleteEndpoint(ctx, c.ID)
+			if err == nil && c.Template != "" {
+				if terr := rp.DeleteTemplate(ctx, c.Template); terr != nil {
+					err = fmt.Errorf("endpoint deleted, but template %s: %w", c.Template, terr)
+				}
+			}
+		case PruneTemplate:
+			err = rp.DeleteTemplate(ctx, c.Name)
+		default:
+			err = fmt.Errorf("unknown candidate kind %q", c.Kind)
+		}
+		c.Action = ActionDeleted
+		if err != nil {
+			c.Action, c.Error = ActionFailed, err.Error()
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// PruneFailed reports whether any deletion failed, for the exit code.
+func PruneFailed(cands []PruneCandidate) bool {
+	for _, c := range cands {
+		if c.Action == ActionFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintPrune writes one line per candidate (with its outcome once
+// Prune has run), then the plan's notes.
+func PrintPrune(w io.Writer, plan *PrunePlan) {
+	if len(plan.Candidates) == 0 {
+		fmt.Fprintln(w, "nothing to prune")
+	}
+	for _, c := range plan.Candidates {
+		label := c.Kind
+		if c.Action != "" {
+			label = c.Action + " " + c.Kind
+		}
+		fmt.Fprintf(w, "  %-17s  %-28s  %-14s  %s\n", label, c.Name, c.ID, c.Reason)
+		if c.Template != "" {
+			fmt.Fprintf(w, "  %-17s  %s\n", "", "+ template "+c.Template)
+		}
+		if c.Error != "" {
+			fmt.Fprintf(w, "  %-17s  %s\n", "", c.Error)
+		}
+	}
+	if len(plan.Tracking) > 0 {
+		fmt.Fprintf(w, "\nnow tracking activity of %s; idle endpoints are found on later runs\n", strings.Join(plan.Tracking, ", "))
+	}
+	for _, warn := range plan.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warn)
+	}
+}
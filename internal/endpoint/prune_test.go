@@ -0,0 +1,134 @@
+package endpoint
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/runpod"
+)
+
+func health(completed, inQueue int) *runpod.Health {
+	return &runpod.Health{Jobs: runpod.HealthJobs{Completed: completed, InQueue: inQueue}}
+}
+
+func TestActivityLog_Observe(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	l := ActivityLog{}
+
+	if _, known := l.observe("ep1", health(5, 0), t0); known {
+		t.Fatal("first observation should have no baseline")
+	}
+	if d, known := l.observe("ep1", health(5, 0), t0.Add(3*day)); !known || d != 3*day {
+		t.Errorf("unchanged: idle %v, %v; want 72h", d, known)
+	}
+	// Jobs aging out of RunPod's window isn't activity.
+	if d, _ := l.observe("ep1", health(2, 0), t0.Add(4*day)); d != 4*day {
+		t.Errorf("count dropped: idle %v, want 96h", d)
+	}
+	if d, _ := l.observe("ep1", health(3, 0), t0.Add(5*day)); d != 0 {
+		t.Errorf("new job: idle %v, want 0", d)
+	}
+	if d, _ := l.observe("ep1", health(3, 1), t0.Add(9*day)); d != 0 {
+		t.Errorf("queued job: idle %v, want 0", d)
+	}
+	if got := l["ep1"].Since; !got.Equal(t0.Add(9 * day)) {
+		t.Errorf("since = %v, want the last busy observation", got)
+	}
+}
+
+func TestActivityLog_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", ActivityFileName)
+	empty, err := LoadActivity(path)
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("missing file: %v, %v; want empty log", empty, err)
+	}
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := (ActivityLog{"ep1": {Jobs: 4, Since: since}}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadActivity(path)
+	if err != nil || got["ep1"].Jobs != 4 || !got["ep1"].Since.Equal(since) {
+		t.Errorf("round trip = %+v, %v", got, err)
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	endpoints := []runpod.Endpoint{
+		{ID: "ep1", Name: "esrgan-a", TemplateID: "t1"},     // idle 20 days
+		{ID: "ep2", Name: "esrgan-b", TemplateID: "t2"},     // idle 2 days
+		{ID: "ep3", Name: "hand-made", TemplateID: "t3"},    // not iosuite's
+		{ID: "ep4", Name: "esrgan-c", TemplateID: "t4"},     // idle 30 days, shares t4
+		{ID: "ep5", Name: "esrgan-c-dup", TemplateID: "t4"}, // idle, not its own template
+	}
+	templates := []runpod.Template{
+		{ID: "t1", Name: "esrgan-a-tmpl", IsServerless: true},
+		{ID: "t2", Name: "esrgan-b-tmpl", IsServerless: true},
+		{ID: "t3", Name: "mine", IsServerless: true},
+		{ID: "t4", Name: "esrgan-c-tmpl", IsServerless: true},
+		{ID: "t8", Name: "zz-old-tmpl", IsServerless: true},
+		{ID: "t9", Name: "aa-old-tmpl", IsServerless: true},
+		{ID: "t10", Name: "pod-tmpl"},                    // pod template
+		{ID: "t11", Name: "scratch", IsServerless: true}, // not iosuite's
+	}
+	day := 24 * time.Hour
+	idle := map[string]time.Duration{"ep1": 20 * day, "ep2": 2 * day, "ep4": 30 * day}
+
+	got := planPrune(endpoints, templates, idle, 14*day)
+	var names []string
+	for _, c := range got {
+		names = append(names, c.Kind+":"+c.Name)
+	}
+	want := "endpoint:esrgan-c endpoint:esrgan-a template:aa-old-tmpl template:zz-old-tmpl"
+	if strings.Join(names, " ") != want {
+		t.Fatalf("candidates = %v, want %s", names, want)
+	}
+	if got[0].Template != "" {
+		t.Errorf("shared template pruned with esrgan-c: %+v", got[0])
+	}
+	if got[1].Template != "esrgan-a-tmpl" || got[1].Reason != "no jobs for 20 days" {
+		t.Errorf("esrgan-a = %+v", got[1])
+	}
+
+	if got := planPrune(endpoints, templates, idle, 0); len(got) != 2 || got[0].Kind != PruneTemplate {
+		t.Errorf("--idle-days 0 = %+v, want only the orphan templates", got)
+	}
+}
+
+func TestPairedTemplateConflict(t *testing.T) {
+	ep := runpod.Endpoint{ID: "ep1", Name: "a", TemplateID: "t1"}
+	tmpl := &runpod.Template{ID: "t1", Name: "a-tmpl"}
+	if got := pairedTemplateConflict(ep, []runpod.Endpoint{ep}, tmpl); got != "" {
+		t.Errorf("own template: %q, want deletable", got)
+	}
+	if got := pairedTemplateConflict(ep, []runpod.Endpoint{ep}, nil); got != "" {
+		t.Errorf("no template: %q", got)
+	}
+	other := &runpod.Template{ID: "t2", Name: "a-tmpl"}
+	if got := pairedTemplateConflict(ep, []runpod.Endpoint{ep}, other); !strings.Contains(got, "not the template") {
+		t.Errorf("template a doesn't run: %q", got)
+	}
+	shared := []runpod.Endpoint{ep, {ID: "ep2", Name: "b", TemplateID: "t1"}}
+	if got := pairedTemplateConflict(ep, shared, tmpl); !strings.Contains(got, "also used by endpoint b") {
+		t.Errorf("shared template: %q", got)
+	}
+}
+
+func TestPrintPrune(t *testing.T) {
+	var buf bytes.Buffer
+	PrintPrune(&buf, &PrunePlan{
+		Candidates: []PruneCandidate{
+			{Kind: PruneEndpoint, ID: "ep1", Name: "a", Reason: "no jobs for 20 days", Template: "a-tmpl", Action: ActionDeleted},
+			{Kind: PruneTemplate, ID: "t9", Name: "b-tmpl", Reason: "no endpoint uses it", Action: ActionFailed, Error: "boom"},
+		},
+		Tracking: []string{"c"},
+	})
+	for _, want := range []string{"deleted endpoint", "+ template a-tmpl", "failed template", "boom", "now tracking activity of c"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
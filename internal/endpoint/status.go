@@ -0,0 +1,164 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"iosuite.io/internal/runpod"
+)
+
+// StatusResult is one `iosuite endpoint status` snapshot: the
+// endpoint's configuration from the GraphQL API plus live worker /
+// queue counts from its /v2/{id}/health. Like DeployResult its JSON
+// tags are iosuite's own schema, not RunPod's field names.
+type StatusResult struct {
+	EndpointID        string        `json:"endpoint_id"`
+	EndpointName      string        `json:"endpoint_name"`
+	TemplateID        string        `json:"template_id"`
+	Image             string        `json:"image"`
+	GPUPool           string        `json:"gpu_pool"`
+	WorkersMin        int           `json:"workers_min"`
+	WorkersMax        int           `json:"workers_max"`
+	IdleTimeoutS      int           `json:"idle_timeout_s"`
+	Flashboot         bool          `json:"flashboot"`
+	MinCudaVersion    string        `json:"min_cuda_version"`
+	ScalerType        string        `json:"scaler_type"`
+	ScalerValue       int           `json:"scaler_value"`
+	ExecutionTimeoutS int           `json:"execution_timeout_s"` // 0 = RunPod's default
+	Workers           StatusWorkers `json:"workers"`
+	Jobs              StatusJobs    `json:"jobs"`
+	FetchedAt         time.Time     `json:"fetched_at"`
+}
+
+// StatusWorkers counts an endpoint's workers by state.
+type StatusWorkers struct {
+	Idle         int `json:"idle"`
+	Ready        int `json:"ready"`
+	Running      int `json:"running"`
+	Initializing int `json:"initializing"`
+	Throttled    int `json:"throttled"`
+	Unhealthy    int `json:"unhealthy"`
+}
+
+// StatusJobs counts an endpoint's jobs by phase. Completed / failed
+// are RunPod's running totals, not per-refresh deltas.
+type StatusJobs struct {
+	InQueue    int `json:"in_queue"`
+	InProgress int `json:"in_progress"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Retried    int `json:"retried"`
+}
+
+// Status fetches config + health for the endpoint with the given id
+// (or, when id is empty, the endpoint matching name).
+func Status(ctx context.Context, provider, apiKey, userAgent, id, name string) (*StatusResult, error) {
+	if provider != ProviderRunPod {
+		return nil, fmt.Errorf("provider %q is not supported", provider)
+	}
+	if apiKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	rp := runpod.NewClient(apiKey, userAgent)
+
+	target, err := resolveID(ctx, rp, id, name)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := rp.GetEndpoint(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("look up endpoint %s: %w", target, err)
+	}
+	if ep == nil {
+		return nil, fmt.Errorf("no endpoint with id %q on this account", target)
+	}
+	h, err := rp.Health(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return newStatusResult(ep, h, time.Now().UTC()), nil
+}
+
+func newStatusResult(ep *runpod.EndpointDetail, h *runpod.Health, at time.Time) *StatusResult {
+	r := &StatusResult{
+		EndpointID:        ep.ID,
+		EndpointName:      ep.Name,
+		TemplateID:        ep.TemplateID,
+		GPUPool:           ep.GPUIDs,
+		WorkersMin:        ep.WorkersMin,
+		WorkersMax:        ep.WorkersMax,
+		IdleTimeoutS:      ep.IdleTimeout,
+		Flashboot:         ep.FlashBootType == "FLASHBOOT",
+		MinCudaVersion:    ep.MinCudaVersion,
+		ScalerType:        ep.ScalerType,
+		ScalerValue:       ep.ScalerValue,
+		ExecutionTimeoutS: ep.ExecutionTimeoutMs / 1000,
+		Workers:           StatusWorkers(h.Workers),
+		Jobs: StatusJobs{
+			InQueue:    h.Jobs.InQueue,
+			InProgress: h.Jobs.InProgress,
+			Completed:  h.Jobs.Completed,
+			Failed:     h.Jobs.Failed,
+			Retried:    h.Jobs.Retried,
+		},
+		FetchedAt: at,
+	}
+	if ep.Template != nil {
+		r.Image = ep.Template.ImageName
+	}
+	return r
+}
+
+// resolveID returns id when set, otherwise looks the endpoint up by
+// name. Shared by every subcommand that takes `<id> | --name`.
+func resolveID(ctx context.Context, rp *runpod.Client, id, name string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("must provide either an endpoint id or --name")
+	}
+	ep, err := rp.FindEndpoint(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("look up endpoint by name: %w", err)
+	}
+	if ep == nil {
+		return "", fmt.Errorf("no endpoint named %q on this account", name)
+	}
+	return ep.ID, nil
+}
+
+// PrintStatus writes a human-friendly status block.
+func PrintStatus(w io.Writer, r *StatusResult) {
+	fmt.Fprintf(w, "endpoint %s (%s)\n", r.EndpointName, r.EndpointID)
+	fmt.Fprintf(w, "  template:      %s\n", r.TemplateID)
+	if r.Image != "" {
+		fmt.Fprintf(w, "  image:         %s\n", r.Image)
+	}
+	fmt.Fprintf(w, "  gpu pool:      %s\n", r.GPUPool)
+	fmt.Fprintf(w, "  workers:       min %d / max %d\n", r.WorkersMin, r.WorkersMax)
+	fmt.Fprintf(w, "  idle timeout:  %ds\n", r.IdleTimeoutS)
+	if r.ScalerType != "" {
+		fmt.Fprintf(w, "  scaler:        %s %d\n", r.ScalerType, r.ScalerValue)
+	}
+	if r.ExecutionTimeoutS > 0 {
+		fmt.Fprintf(w, "  exec timeout:  %ds\n", r.ExecutionTimeoutS)
+	}
+	fmt.Fprintf(w, "  flashboot:     %t\n", r.Flashboot)
+	if r.MinCudaVersion != "" {
+		fmt.Fprintf(w, "  min cuda:      %s\n", r.MinCudaVersion)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "workers:")
+	fmt.Fprintf(w, "  idle %d  running %d  initializing %d  throttled %d",
+		r.Workers.Idle, r.Workers.Running, r.Workers.Initializing, r.Workers.Throttled)
+	if r.Workers.Unhealthy > 0 {
+		fmt.Fprintf(w, "  unhealthy %d", r.Workers.Unhealthy)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "jobs:")
+	fmt.Fprintf(w, "  in queue %d  in progress %d  completed %d  failed %d\n",
+		r.Jobs.InQueue, r.Jobs.InProgress, r.Jobs.Completed, r.Jobs.Failed)
+}
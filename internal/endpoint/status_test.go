@@ -0,0 +1,60 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/runpod"
+)
+
+func TestStatus_RequiresIDOrName(t *testing.T) {
+	_, err := Status(context.Background(), ProviderRunPod, "test", "", "", "")
+	if err == nil || !strings.Contains(err.Error(), "--name") {
+		t.Fatalf("expected id-or-name error, got %v", err)
+	}
+}
+
+func TestStatus_RejectsMissingAPIKey(t *testing.T) {
+	_, err := Status(context.Background(), ProviderRunPod, "", "", "ep1", "")
+	if err == nil || !strings.Contains(err.Error(), "RUNPOD_API_KEY") {
+		t.Fatalf("expected API key error, got %v", err)
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	var buf bytes.Buffer
+	PrintStatus(&buf, newStatusResult(
+		&runpod.EndpointDetail{
+			ID: "ep1", Name: "real-esrgan-rtx-4090", TemplateID: "tmpl1",
+			GPUIDs: "ADA_24", WorkersMin: 0, WorkersMax: 3, IdleTimeout: 30,
+			FlashBootType: "FLASHBOOT", MinCudaVersion: "12.8",
+			Template: &runpod.Template{ImageName: "ghcr.io/ls-ads/real-esrgan-serve:test"},
+		},
+		&runpod.Health{
+			Jobs:    runpod.HealthJobs{InQueue: 3, InProgress: 2, Completed: 12, Failed: 1},
+			Workers: runpod.HealthWorkers{Idle: 1, Running: 2, Initializing: 1, Throttled: 0},
+		},
+		time.Now(),
+	))
+	out := buf.String()
+	for _, want := range []string{
+		"endpoint real-esrgan-rtx-4090 (ep1)",
+		"image:         ghcr.io/ls-ads/real-esrgan-serve:test",
+		"gpu pool:      ADA_24",
+		"workers:       min 0 / max 3",
+		"flashboot:     true",
+		"min cuda:      12.8",
+		"idle 1  running 2  initializing 1  throttled 0",
+		"in queue 3  in progress 2  completed 12  failed 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "unhealthy") {
+		t.Errorf("unhealthy should only show when non-zero:\n%s", out)
+	}
+}
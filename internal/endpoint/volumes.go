@@ -0,0 +1,48 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"iosuite.io/internal/runpod"
+)
+
+// VolumeEntry is one row of `iosuite endpoint volumes`: a network
+// volume deploy can mount with --network-volume.
+type VolumeEntry struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	SizeGB     int    `json:"size_gb"`
+	DataCenter string `json:"data_center"`
+}
+
+// Volumes lists the account's network volumes.
+func Volumes(ctx context.Context, provider, apiKey, userAgent string) ([]VolumeEntry, error) {
+	if provider != ProviderRunPod {
+		return nil, fmt.Errorf("provider %q is not supported", provider)
+	}
+	if apiKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	vols, err := runpod.NewClient(apiKey, userAgent).ListNetworkVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]VolumeEntry, len(vols))
+	for i, v := range vols {
+		out[i] = VolumeEntry{ID: v.ID, Name: v.Name, SizeGB: v.Size, DataCenter: v.DataCenterID}
+	}
+	return out, nil
+}
+
+// PrintVolumes writes the human-friendly volume list.
+func PrintVolumes(w io.Writer, entries []VolumeEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "(no network volumes on this account)")
+		return
+	}
+	for _, v := range entries {
+		fmt.Fprintf(w, "  %s  %s  %dGB  %s\n", v.ID, v.Name, v.SizeGB, v.DataCenter)
+	}
+}
@@ -0,0 +1,170 @@
+// Package fleet loads the fleet file behind `iosuite endpoint apply`:
+// a declarative list of every endpoint an account should run.
+//
+//	# fleet.toml
+//	[[endpoints]]
+//	tool      = "real-esrgan"
+//	version   = "runpod-trt-0.2.2"
+//	gpu_class = "rtx-4090"
+//	workers_max = 3
+//
+//	[[endpoints]]
+//	tool      = "real-esrgan"
+//	gpu_class = "l40s"
+//	name      = "esrgan-big"
+//	flashboot = false
+//
+// fleet.json is the same shape: {"endpoints": [{...}, ...]}. Keys
+// mirror `iosuite endpoint deploy` flags, and anything left out
+// falls back to the tool's deploy manifest exactly as it does there.
+// Unknown keys are errors so a typo can't silently deploy defaults.
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"iosuite.io/internal/toml"
+)
+
+// Fleet is the parsed fleet file.
+type Fleet struct {
+	Endpoints []Endpoint `json:"endpoints"`
+
+	// Dir is the directory the file was loaded from. Relative
+	// Manifest paths resolve against it.
+	Dir string `json:"-"`
+}
+
+// Endpoint is one desired endpoint. Zero values mean "use the
+// manifest's default", matching endpoint.DeployInput.
+type Endpoint struct {
+	Tool     string `json:"tool"`
+	Version  string `json:"version,omitempty"` // *-serve git tag; empty = registry stable
+	GPUClass string `json:"gpu_class"`
+	Name     string `json:"name,omitempty"` // empty = <tool>-<gpu_class>
+
+	WorkersMin        *int   `json:"workers_min,omitempty"`
+	WorkersMax        int    `json:"workers_max,omitempty"`
+	IdleTimeoutS      int    `json:"idle_timeout_s,omitempty"`
+	Flashboot         *bool  `json:"flashboot,omitempty"`
+	MinCudaVersion    string `json:"min_cuda_version,omitempty"`
+	ScalerType        string `json:"scaler_type,omitempty"` // QUEUE_DELAY | REQUEST_COUNT
+	ScalerValue       int    `json:"scaler_value,omitempty"`
+	ExecutionTimeoutS int    `json:"execution_timeout_s,omitempty"`
+
+	RegistryAuth  string   `json:"registry_auth,omitempty"`  // credential name, for private images
+	NetworkVolume string   `json:"network_volume,omitempty"` // id or name
+	DataCenters   []string `json:"data_centers,omitempty"`
+	// NoRegistryAuth pulls without the manifest's credential, clearing
+	// one the live template has.
+	NoRegistryAuth bool `json:"no_registry_auth,omitempty"`
+
+	// Manifest reads the deploy manifest from a local file instead of
+	// fetching by tool + version (same as `deploy --manifest`).
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// ResolvedName is the endpoint name deploy will use.
+func (e Endpoint) ResolvedName() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%s-%s", e.Tool, e.GPUClass)
+}
+
+// Load reads a fleet file, picking the parser by extension (.toml
+// or .json), and validates it.
+func Load(path string) (*Fleet, error) {
+	parse := ParseTOML
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+	case ".json":
+		parse = ParseJSON
+	default:
+		return nil, fmt.Errorf("fleet file %s: unknown extension %q (expected .toml or .json)", path, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fleet file: %w", err)
+	}
+	f, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("fleet file %s: %w", path, err)
+	}
+	f.Dir = filepath.Dir(path)
+	return f, nil
+}
+
+// ParseJSON decodes and validates a fleet.json body.
+func ParseJSON(data []byte) (*Fleet, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var f Fleet
+	if err := dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ParseTOML decodes and validates a fleet.toml body with the
+// config file's TOML parser. The TOML is mapped onto the JSON shape
+// so both formats share one schema and one set of unknown-key checks.
+func ParseTOML(data []byte) (*Fleet, error) {
+	doc, err := toml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(doc.Root)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJSON(b)
+}
+
+// Validate checks required fields and that no two entries resolve
+// to the same endpoint name (they'd fight over one endpoint).
+func (f *Fleet) Validate() error {
+	if len(f.Endpoints) == 0 {
+		return fmt.Errorf("no endpoints declared")
+	}
+	seen := map[string]int{}
+	for i, e := range f.Endpoints {
+		if e.Tool == "" {
+			return fmt.Errorf("endpoints[%d]: tool is required", i)
+		}
+		if e.GPUClass == "" {
+			return fmt.Errorf("endpoints[%d]: gpu_class is required", i)
+		}
+		if e.WorkersMax < 0 || e.IdleTimeoutS < 0 || e.ScalerValue < 0 || e.ExecutionTimeoutS < 0 {
+			return fmt.Errorf("endpoints[%d]: workers_max, idle_timeout_s, scaler_value and execution_timeout_s must be >= 0", i)
+		}
+		if e.WorkersMin != nil && *e.WorkersMin < 0 {
+			return fmt.Errorf("endpoints[%d]: workers_min must be >= 0", i)
+		}
+		if e.NoRegistryAuth && e.RegistryAuth != "" {
+			return fmt.Errorf("endpoints[%d]: registry_auth and no_registry_auth are mutually exclusive", i)
+		}
+		name := e.ResolvedName()
+		if j, dup := seen[name]; dup {
+			return fmt.Errorf("endpoints[%d] and endpoints[%d] both resolve to name %q", j, i, name)
+		}
+		seen[name] = i
+	}
+	return nil
+}
+
+// ManifestPath resolves e.Manifest relative to the fleet file.
+func (f *Fleet) ManifestPath(e Endpoint) string {
+	if e.Manifest == "" || filepath.IsAbs(e.Manifest) {
+		return e.Manifest
+	}
+	return filepath.Join(f.Dir, e.Manifest)
+}
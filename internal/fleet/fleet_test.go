@@ -0,0 +1,131 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleTOML = `# two GPU classes of one tool
+[[endpoints]]
+tool      = "real-esrgan"
+version   = "runpod-trt-0.2.2"
+gpu_class = "rtx-4090"
+workers_max = 3   # burst headroom
+
+[[endpoints]]
+tool      = 'real-esrgan'
+gpu_class = "l40s"
+name      = "esrgan-big"
+flashboot = false
+manifest  = "manifests/runpod.json"
+workers_min = 0
+scaler_type = "REQUEST_COUNT"
+scaler_value = 2
+`
+
+const sampleJSON = `{"endpoints": [
+  {"tool": "real-esrgan", "version": "runpod-trt-0.2.2", "gpu_class": "rtx-4090", "workers_max": 3},
+  {"tool": "real-esrgan", "gpu_class": "l40s", "name": "esrgan-big", "flashboot": false,
+   "manifest": "manifests/runpod.json", "workers_min": 0, "scaler_type": "REQUEST_COUNT", "scaler_value": 2}
+]}`
+
+func TestParse_TOMLAndJSONAgree(t *testing.T) {
+	ft, err := ParseTOML([]byte(sampleTOML))
+	if err != nil {
+		t.Fatalf("ParseTOML: %v", err)
+	}
+	fj, err := ParseJSON([]byte(sampleJSON))
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if !reflect.DeepEqual(ft, fj) {
+		t.Errorf("TOML and JSON disagree:\n toml %+v\n json %+v", ft.Endpoints, fj.Endpoints)
+	}
+	e := ft.Endpoints[1]
+	if e.Flashboot == nil || *e.Flashboot {
+		t.Errorf("flashboot = false should be an explicit false, got %v", e.Flashboot)
+	}
+	if ft.Endpoints[0].Flashboot != nil {
+		t.Error("unset flashboot should stay nil (manifest default)")
+	}
+	if e.WorkersMin == nil || *e.WorkersMin != 0 || ft.Endpoints[0].WorkersMin != nil {
+		t.Errorf("workers_min = 0 should be an explicit 0 and unset should stay nil: %v / %v", e.WorkersMin, ft.Endpoints[0].WorkersMin)
+	}
+	if got := ft.Endpoints[0].ResolvedName(); got != "real-esrgan-rtx-4090" {
+		t.Errorf("default name = %q", got)
+	}
+}
+
+func TestParse_RejectsUnknownKey(t *testing.T) {
+	_, err := ParseTOML([]byte("[[endpoints]]\ntool = \"x\"\ngpu_class = \"y\"\nworkers_maxx = 2\n"))
+	if err == nil || !strings.Contains(err.Error(), "workers_maxx") {
+		t.Fatalf("expected unknown-field error naming the key, got %v", err)
+	}
+}
+
+func TestParse_RejectsDuplicateNames(t *testing.T) {
+	_, err := ParseJSON([]byte(`{"endpoints": [
+		{"tool": "real-esrgan", "gpu_class": "rtx-4090"},
+		{"tool": "real-esrgan", "gpu_class": "l40s", "name": "real-esrgan-rtx-4090"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "both resolve") {
+		t.Fatalf("expected duplicate-name error, got %v", err)
+	}
+}
+
+func TestParse_RejectsRegistryAuthAndNoRegistryAuth(t *testing.T) {
+	_, err := ParseJSON([]byte(`{"endpoints": [
+		{"tool": "real-esrgan", "gpu_class": "rtx-4090", "registry_auth": "ghcr-ro", "no_registry_auth": true}]}`))
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	for name, body := range map[string]string{
+		"unquoted string": "[[endpoints]]\ntool = real-esrgan\n",
+		"no equals":       "[[endpoints]]\ntool\n",
+		"duplicate key":   "[[endpoints]]\ntool = \"a\"\ntool = \"b\"\n",
+		"unterminated":    "[[endpoints]]\ntool = \"a\n",
+	} {
+		if _, err := ParseTOML([]byte(body)); err == nil || !strings.Contains(err.Error(), "line ") {
+			t.Errorf("%s: expected a line-numbered error, got %v", name, err)
+		}
+	}
+}
+
+func TestLoad_ResolvesManifestRelativeToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fleet.toml")
+	if err := os.WriteFile(path, []byte(sampleTOML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.ManifestPath(f.Endpoints[1]), filepath.Join(dir, "manifests/runpod.json"); got != want {
+		t.Errorf("ManifestPath = %q, want %q", got, want)
+	}
+	if _, err := Load(filepath.Join(dir, "fleet.yaml")); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+}
+
+func TestParseTOML_ArraysAndEscapes(t *testing.T) {
+	f, err := ParseTOML([]byte(`[[endpoints]]
+tool         = "real-esrgan"
+gpu_class    = "rtx-4090"
+name         = "esrgan-\u00e9u"
+data_centers = ["EU-RO-1", 'US-KS-2']
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := f.Endpoints[0]
+	if e.Name != "esrgan-éu" || !reflect.DeepEqual(e.DataCenters, []string{"EU-RO-1", "US-KS-2"}) {
+		t.Errorf("endpoint = %+v", e)
+	}
+}
@@ -0,0 +1,297 @@
+// Package history is the local deploy history behind `iosuite
+// endpoint history` and `endpoint rollback`. Every successful deploy
+// (and apply, and rollback) appends one Revision: the resolved
+// manifest, where it came from, and the exact Spec that was saved, so
+// any earlier state can be re-applied without re-fetching anything.
+//
+// The store is a JSON-lines file next to config.toml. Append-only
+// makes the file easy to inspect or trim by hand; a lockfile beside
+// it keeps two concurrent deploys from both claiming the same
+// revision number. Each revision
+// records the config profile (account) it was deployed with, and a
+// Store only sees its own profile's revisions, so a rollback under
+// --profile prod can never replay a staging deploy onto prod.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"iosuite.io/internal/config"
+	"iosuite.io/internal/endpoint"
+	"iosuite.io/internal/manifest"
+)
+
+// FileName is the history file's name inside config.Dir().
+const FileName = "deploy-history.jsonl"
+
+// How long Append waits for another process's lock, and the age at
+// which a lock is taken to be left behind by a crashed one. An
+// append holds it for milliseconds.
+const (
+	lockWait  = 10 * time.Second
+	lockStale = time.Minute
+)
+
+// Revision actions.
+const (
+	ActionDeploy   = "deploy"
+	ActionApply    = "apply"
+	ActionRollback = "rollback"
+)
+
+// Revision is one recorded deploy of one endpoint. The JSON tags are
+// both the on-disk format and the `--output json` schema.
+type Revision struct {
+	// Profile is the config profile the deploy ran under; "" is the
+	// top-level account.
+	Profile  string    `json:"profile,omitempty"`
+	Endpoint string    `json:"endpoint"` // endpoint name; revisions are numbered per profile and name
+	Revision int       `json:"revision"` // 1-based
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"` // deploy | apply | rollback
+	// RollbackOf is the revision a rollback re-applied.
+	RollbackOf int `json:"rollback_of,omitempty"`
+
+	Tool           string             `json:"tool,omitempty"`
+	Version        string             `json:"version,omitempty"`
+	ManifestSource string             `json:"manifest_source,omitempty"`
+	Manifest       *manifest.Manifest `json:"manifest,omitempty"`
+
+	EndpointID string        `json:"endpoint_id"`
+	TemplateID string        `json:"template_id"`
+	Spec       endpoint.Spec `json:"spec"`
+}
+
+// Store reads and appends the history file at Path on behalf of one
+// profile: List, Target and revision numbers only see revisions
+// recorded under Profile, and Append records it.
+type Store struct {
+	Path    string
+	Profile string
+}
+
+// Open returns profile's store at path. The file is created on first
+// Append; a missing file reads as empty history.
+func Open(path, profile string) *Store {
+	return &Store{Path: path, Profile: profile}
+}
+
+// Default returns profile's store under the user's config dir.
+func Default(profile string) (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return Open(filepath.Join(dir, FileName), profile), nil
+}
+
+// All returns every revision in file order, whatever its profile.
+func (s *Store) All() ([]Revision, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open deploy history: %w", err)
+	}
+	defer f.Close()
+	return decode(f, s.Path)
+}
+
+func decode(r io.Reader, path string) ([]Revision, error) {
+	var out []Revision
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rev Revision
+		if err := json.Unmarshal(sc.Bytes(), &rev); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		out = append(out, rev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read deploy history: %w", err)
+	}
+	return out, nil
+}
+
+// List returns name's revisions under the store's profile, oldest
+// first.
+func (s *Store) List(name string) ([]Revision, error) {
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	var out []Revision
+	for _, r := range all {
+		if r.Endpoint == name && r.Profile == s.Profile {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// Append numbers rev as the next revision of rev.Endpoint under the
+// store's profile, stamps the profile and (if unset) the time, writes
+// it, and returns what was written.
+func (s *Store) Append(rev Revision) (Revision, error) {
+	if rev.Endpoint == "" {
+		return rev, fmt.Errorf("history revision has no endpoint name")
+	}
+	rev.Profile = s.Profile
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return rev, fmt.Errorf("create config dir: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return rev, err
+	}
+	defer unlock()
+	prev, err := s.List(rev.Endpoint)
+	if err != nil {
+		return rev, err
+	}
+	rev.Revision = 1
+	if n := len(prev); n > 0 {
+		rev.Revision = prev[n-1].Revision + 1
+	}
+	if rev.Time.IsZero() {
+		rev.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(rev)
+	if err != nil {
+		return rev, err
+	}
+	// 0600: specs carry the template env, which may hold credentials.
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return rev, fmt.Errorf("open deploy history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return rev, fmt.Errorf("write deploy history: %w", err)
+	}
+	return rev, nil
+}
+
+// lock creates Path + ".lock" exclusively (O_EXCL, so it works the
+// same on every platform), waiting up to lockWait for another holder,
+// and returns the function that releases it.
+func (s *Store) lock() (func(), error) {
+	path := s.Path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("lock deploy history: %w", err)
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("deploy history is locked by another iosuite process (delete %s if none is running)", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Target picks the revision of name to roll back to: revision `to`
+// when non-zero, else the newest deploy older than the one live now.
+// A rollback re-applies an earlier revision, so the live one is found
+// by following RollbackOf, and rollbacks themselves are never picked:
+// rolling back twice steps back twice rather than undoing the first.
+func (s *Store) Target(name string, to int) (*Revision, error) {
+	revs, err := s.List(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		return nil, fmt.Errorf("no deploy history for endpoint %q%s (history is recorded by `iosuite endpoint deploy` / `apply` on this machine)", name, s.profileNote())
+	}
+	if to == 0 {
+		if len(revs) < 2 {
+			return nil, fmt.Errorf("endpoint %q has only one revision; nothing to roll back to", name)
+		}
+		live := applied(revs, revs[len(revs)-1])
+		for i := len(revs) - 1; i >= 0; i-- {
+			if r := revs[i]; r.Revision < live.Revision && r.RollbackOf == 0 {
+				return &r, nil
+			}
+		}
+		return nil, fmt.Errorf("endpoint %q is at revision %d, its oldest; nothing to roll back to (see `iosuite endpoint history %s`)",
+			name, live.Revision, name)
+	}
+	for _, r := range revs {
+		if r.Revision == to {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("endpoint %q has no revision %d (latest is %d; see `iosuite endpoint history %s`)",
+		name, to, revs[len(revs)-1].Revision, name)
+}
+
+// applied is the revision whose state r put live: r itself, or for a
+// rollback, the revision it re-applied (followed through rollbacks of
+// rollbacks).
+func applied(revs []Revision, r Revision) Revision {
+	for seen := 0; r.RollbackOf != 0 && seen < len(revs); seen++ {
+		next := -1
+		for i := range revs {
+			if revs[i].Revision == r.RollbackOf {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		r = revs[next]
+	}
+	return r
+}
+
+// profileNote names the store's profile for error messages.
+func (s *Store) profileNote() string {
+	if s.Profile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" under profile %q", s.Profile)
+}
+
+// Print writes one line per revision, newest last, marking the
+// current one.
+func Print(w io.Writer, name string, revs []Revision) {
+	if len(revs) == 0 {
+		fmt.Fprintf(w, "no deploy history for %s\n", name)
+		return
+	}
+	fmt.Fprintf(w, "  %-4s  %-20s  %-12s  %-9s  %s\n", "REV", "TIME", "ACTION", "WORKERS", "IMAGE")
+	for i, r := range revs {
+		mark := " "
+		if i == len(revs)-1 {
+			mark = "*"
+		}
+		action := r.Action
+		if r.RollbackOf != 0 {
+			action = fmt.Sprintf("%s→%d", action, r.RollbackOf)
+		}
+		fmt.Fprintf(w, "%s %-4d  %-20s  %-12s  %-9s  %s\n", mark, r.Revision,
+			r.Time.Local().Format("2006-01-02 15:04:05"), action,
+			fmt.Sprintf("%d-%d", r.Spec.WorkersMin, r.Spec.WorkersMax), r.Spec.Image)
+	}
+}
@@ -0,0 +1,200 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"iosuite.io/internal/endpoint"
+)
+
+func rev(name, image string) Revision {
+	return Revision{
+		Endpoint: name,
+		Action:   ActionDeploy,
+		Spec:     endpoint.Spec{Name: name, Image: image, WorkersMax: 2},
+	}
+}
+
+func TestAppend_NumbersPerEndpoint(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "sub", FileName), "")
+	for _, r := range []Revision{rev("a", "img:1"), rev("b", "img:1"), rev("a", "img:2")} {
+		if _, err := s.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.Append(rev("a", "img:3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Revision != 3 || got.Time.IsZero() {
+		t.Errorf("appended = %+v, want revision 3 with a time", got)
+	}
+
+	a, err := s.List("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 3 || a[0].Spec.Image != "img:1" || a[2].Spec.Image != "img:3" {
+		t.Errorf("List(a) = %+v", a)
+	}
+	if b, _ := s.List("b"); len(b) != 1 || b[0].Revision != 1 {
+		t.Errorf("List(b) = %+v", b)
+	}
+
+	fi, err := os.Stat(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("history file mode = %o, want 600", perm)
+	}
+}
+
+func TestList_MissingFileIsEmpty(t *testing.T) {
+	revs, err := Open(filepath.Join(t.TempDir(), FileName), "").List("a")
+	if err != nil || len(revs) != 0 {
+		t.Fatalf("got %v, %v; want empty, nil", revs, err)
+	}
+}
+
+func TestAll_CorruptLineReportsPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{\"endpoint\":\"a\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Open(path, "").All()
+	if err == nil || !strings.Contains(err.Error(), FileName+":2") {
+		t.Fatalf("expected line-numbered error, got %v", err)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), FileName), "")
+	if _, err := s.Target("a", 0); err == nil || !strings.Contains(err.Error(), "no deploy history") {
+		t.Errorf("empty history: got %v", err)
+	}
+	s.Append(rev("a", "img:1"))
+	if _, err := s.Target("a", 0); err == nil || !strings.Contains(err.Error(), "only one revision") {
+		t.Errorf("single revision: got %v", err)
+	}
+	s.Append(rev("a", "img:2"))
+	s.Append(rev("a", "img:3"))
+
+	got, err := s.Target("a", 0)
+	if err != nil || got.Revision != 2 || got.Spec.Image != "img:2" {
+		t.Errorf("default target = %+v, %v; want revision 2", got, err)
+	}
+	got, err = s.Target("a", 1)
+	if err != nil || got.Spec.Image != "img:1" {
+		t.Errorf("--to 1 = %+v, %v", got, err)
+	}
+	if _, err := s.Target("a", 9); err == nil || !strings.Contains(err.Error(), "latest is 3") {
+		t.Errorf("--to 9: got %v", err)
+	}
+}
+
+func TestTarget_RepeatedRollbackStepsBack(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), FileName), "")
+	for _, img := range []string{"img:1", "img:2", "img:3"} {
+		s.Append(rev("a", img))
+	}
+	rollback := func() *Revision {
+		t.Helper()
+		target, err := s.Target("a", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := rev("a", target.Spec.Image)
+		r.Action, r.RollbackOf = ActionRollback, target.Revision
+		s.Append(r)
+		return target
+	}
+	if got := rollback(); got.Revision != 2 {
+		t.Fatalf("first rollback target = %d, want 2", got.Revision)
+	}
+	// Revision 4 put revision 2 back; the next step back is 1, not 3.
+	if got := rollback(); got.Revision != 1 {
+		t.Errorf("second rollback target = %d, want 1", got.Revision)
+	}
+	if _, err := s.Target("a", 0); err == nil || !strings.Contains(err.Error(), "oldest") {
+		t.Errorf("rollback past the first deploy: err = %v", err)
+	}
+}
+
+func TestAppend_ConcurrentNumbersAreUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			// Separate Stores, as separate processes would have.
+			if _, err := Open(path, "").Append(rev("a", "img")); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	revs, err := Open(path, "").List("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for _, r := range revs {
+		if seen[r.Revision] {
+			t.Errorf("revision %d recorded twice", r.Revision)
+		}
+		seen[r.Revision] = true
+	}
+	if len(revs) != 8 || !seen[8] {
+		t.Errorf("got %d revisions, want 1..8", len(revs))
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lockfile left behind: %v", err)
+	}
+}
+
+func TestStore_SeparatesProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	staging, prod := Open(path, "staging"), Open(path, "prod")
+	staging.Append(rev("a", "img:staging-1"))
+	staging.Append(rev("a", "img:staging-2"))
+	got, err := prod.Append(rev("a", "img:prod-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Revision != 1 || got.Profile != "prod" {
+		t.Errorf("first prod revision = %d under %q, want 1 under prod", got.Revision, got.Profile)
+	}
+	if revs, _ := prod.List("a"); len(revs) != 1 || revs[0].Spec.Image != "img:prod-1" {
+		t.Errorf("prod List = %+v, want only the prod deploy", revs)
+	}
+	// prod has one revision, so there's nothing to roll back to, even
+	// though staging has two.
+	if _, err := prod.Target("a", 0); err == nil || !strings.Contains(err.Error(), "only one revision") {
+		t.Errorf("prod rollback target: %v", err)
+	}
+	if _, err := Open(path, "").Target("a", 0); err == nil || !strings.Contains(err.Error(), "no deploy history") {
+		t.Errorf("top-level account saw another profile's history: %v", err)
+	}
+}
+
+func TestPrint_MarksCurrentAndRollbacks(t *testing.T) {
+	first, second := rev("a", "img:1"), rev("a", "img:2")
+	first.Revision, second.Revision = 1, 2
+	second.Action, second.RollbackOf = ActionRollback, 1
+	var buf bytes.Buffer
+	Print(&buf, "a", []Revision{first, second})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[2], "* 2") || !strings.Contains(lines[2], "rollback→1") || !strings.Contains(lines[2], "0-2") {
+		t.Errorf("latest line = %q", lines[2])
+	}
+	if strings.HasPrefix(lines[1], "*") {
+		t.Errorf("older revision marked current: %q", lines[1])
+	}
+}
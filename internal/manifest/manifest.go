@@ -45,6 +45,9 @@ type Manifest struct {
 	Endpoint      EndpointDefaults   `json:"endpoint"`
 	GPUPools      map[string]string  `json:"gpu_pools"`
 	Env           []EnvVar           `json:"env"`
+	// RegistryAuth names the RunPod container registry credential to
+	// pull Image with, for private images. Empty = public image.
+	RegistryAuth string `json:"registry_auth,omitempty"`
 }
 
 // EndpointDefaults groups the per-tool defaults the deploy command
@@ -58,6 +61,19 @@ type EndpointDefaults struct {
 	// string and absent field both decode to "" — the deploy code
 	// treats both as "no pin".
 	MinCudaVersion string `json:"min_cuda_version"`
+	// Scaling. All optional; zero / "" means RunPod's client defaults
+	// (0 warm workers, QUEUE_DELAY at 4 s, no execution limit
+	// beyond RunPod's own).
+	WorkersMinDefault int    `json:"workers_min_default"`
+	ScalerType        string `json:"scaler_type"` // QUEUE_DELAY | REQUEST_COUNT; checked by the provider at deploy
+	ScalerValue       int    `json:"scaler_value"`
+	ExecutionTimeoutS int    `json:"execution_timeout_s"`
+	// NetworkVolume is the id or name of a network volume to mount
+	// at /runpod-volume (e.g. model weights). DataCenters restricts
+	// workers to these RunPod data center ids; with a volume and no
+	// data centers, workers follow the volume's data center.
+	NetworkVolume string   `json:"network_volume"`
+	DataCenters   []string `json:"data_centers"`
 }
 
 // EnvVar is one container environment variable applied to the
@@ -163,6 +179,21 @@ func (m *Manifest) validate(source string) error {
 	if m.Endpoint.IdleTimeoutSDefault <= 0 {
 		return fmt.Errorf("manifest %s: endpoint.idle_timeout_s_default must be > 0, got %d", source, m.Endpoint.IdleTimeoutSDefault)
 	}
+	if m.Endpoint.WorkersMinDefault < 0 || m.Endpoint.WorkersMinDefault > m.Endpoint.WorkersMaxDefault {
+		return fmt.Errorf("manifest %s: endpoint.workers_min_default must be between 0 and workers_max_default (%d), got %d",
+			source, m.Endpoint.WorkersMaxDefault, m.Endpoint.WorkersMinDefault)
+	}
+	if m.Endpoint.ScalerValue < 0 {
+		return fmt.Errorf("manifest %s: endpoint.scaler_value must be > 0 when set, got %d", source, m.Endpoint.ScalerValue)
+	}
+	if m.Endpoint.ExecutionTimeoutS < 0 {
+		return fmt.Errorf("manifest %s: endpoint.execution_timeout_s must be >= 0, got %d", source, m.Endpoint.ExecutionTimeoutS)
+	}
+	for _, dc := range m.Endpoint.DataCenters {
+		if strings.TrimSpace(dc) == "" {
+			return fmt.Errorf("manifest %s: endpoint.data_centers has an empty entry", source)
+		}
+	}
 	if len(m.GPUPools) == 0 {
 		return fmt.Errorf("manifest %s: gpu_pools must declare at least one entry", source)
 	}
@@ -100,6 +100,8 @@ func TestValidate_RejectsBadSchema(t *testing.T) {
 		{"image without tag", strings.Replace(validManifest, `:runpod-trt-0.2.1`, ``, 1), "no tag"},
 		{"zero disk", strings.Replace(validManifest, `"container_disk_gb": 10`, `"container_disk_gb": 0`, 1), "container_disk_gb"},
 		{"empty gpu_pools", strings.Replace(validManifest, `"gpu_pools": {"rtx-4090": "ADA_24"}`, `"gpu_pools": {}`, 1), "gpu_pools"},
+		{"workers_min above max", strings.Replace(validManifest, `"workers_max_default": 2,`, `"workers_max_default": 2, "workers_min_default": 3,`, 1), "workers_min_default"},
+		{"negative execution_timeout_s", strings.Replace(validManifest, `"workers_max_default": 2,`, `"workers_max_default": 2, "execution_timeout_s": -5,`, 1), "execution_timeout_s"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,407 @@
+// Package output renders command results for `--output
+// text|table|json|yaml`.
+//
+// Every machine-readable format is derived from the value's JSON
+// encoding, so the struct tags on a result type (DeployResult,
+// endpoint.ListEntry, benchmark.Report, ...) are the one schema:
+// YAML is the same tree in YAML syntax, and table columns are its
+// leaf keys, dotted for nested objects (`health.workers.idle`). That
+// makes the JSON tags a contract — the golden files under each
+// package's testdata pin them.
+//
+// `text` is the default and is whatever human format the command
+// printed before this package existed; callers pass it in as a
+// func so scripts that scrape it keep working.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats accepted by --output.
+const (
+	Text  = "text"
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// Options is the parsed --output / --columns / --no-headers triple.
+type Options struct {
+	Format string
+
+	// Columns selects and orders table columns by key. Empty = all,
+	// in the order the JSON encoding produces them.
+	Columns []string
+
+	// NoHeaders drops the table's header row.
+	NoHeaders bool
+
+	// Compact writes JSON on one line. Not a flag — set by streaming
+	// callers (`endpoint status --watch`) that emit one object per
+	// refresh.
+	Compact bool
+}
+
+// Register adds --output (-o), --columns and --no-headers to fs. The
+// returned Options is filled in by fs.Parse; call Validate after.
+func Register(fs *flag.FlagSet) *Options {
+	o := &Options{Format: Text}
+	usage := "Output format: text | table | json | yaml"
+	fs.StringVar(&o.Format, "output", Text, usage)
+	fs.StringVar(&o.Format, "o", Text, "Shorthand for --output")
+	fs.Func("columns", "Comma-separated table columns to show, in order (table output only)", func(v string) error {
+		o.Columns = nil
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				o.Columns = append(o.Columns, c)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&o.NoHeaders, "no-headers", false, "Omit the header row (table output only)")
+	return o
+}
+
+// Validate rejects unknown formats and table-only flags used with
+// another format, so a typo doesn't silently fall back to text.
+func (o *Options) Validate() error {
+	switch o.Format {
+	case Text, Table, JSON, YAML:
+	default:
+		return fmt.Errorf("unknown --output %q (expected text | table | json | yaml)", o.Format)
+	}
+	if o.Format != Table && (len(o.Columns) > 0 || o.NoHeaders) {
+		return fmt.Errorf("--columns / --no-headers only apply to --output table")
+	}
+	return nil
+}
+
+// Machine reports whether the format is meant for scripts. Commands
+// move progress chatter to stderr when it is, so stdout parses.
+func (o *Options) Machine() bool { return o.Format != Text }
+
+// Render writes v to w. text renders the legacy human format and is
+// only called for --output text; it may be nil when a command has no
+// separate human format, in which case text falls back to table.
+func Render(w io.Writer, o Options, v any, text func(io.Writer)) error {
+	switch o.Format {
+	case "", Text:
+		if text != nil {
+			text(w)
+			return nil
+		}
+		return renderTable(w, o, v)
+	case JSON:
+		if o.Compact {
+			return json.NewEncoder(w).Encode(v)
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case YAML:
+		n, err := toNode(v)
+		if err != nil {
+			return err
+		}
+		var b strings.Builder
+		writeYAML(&b, n, 0)
+		_, err = io.WriteString(w, b.String())
+		return err
+	case Table:
+		return renderTable(w, o, v)
+	}
+	return fmt.Errorf("unknown output format %q", o.Format)
+}
+
+// renderTable lays v out one row per element (a single object is one
+// row). Nested objects flatten to dotted keys; arrays inside a row
+// are shown as compact JSON.
+func renderTable(w io.Writer, o Options, v any) error {
+	n, err := toNode(v)
+	if err != nil {
+		return err
+	}
+	items, ok := n.([]any)
+	if !ok {
+		items = []any{n}
+	}
+
+	var all []string
+	rows := make([]map[string]string, len(items))
+	for i, it := range items {
+		rows[i] = map[string]string{}
+		flatten("", it, rows[i], &all)
+	}
+	cols := all
+	if len(o.Columns) > 0 {
+		for _, c := range o.Columns {
+			if !slices.Contains(all, c) && len(all) > 0 {
+				return fmt.Errorf("unknown column %q (available: %s)", c, strings.Join(all, ", "))
+			}
+		}
+		cols = o.Columns
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if !o.NoHeaders {
+		hdr := make([]string, len(cols))
+		for i, c := range cols {
+			hdr[i] = strings.ToUpper(c)
+		}
+		fmt.Fprintln(tw, strings.Join(hdr, "\t"))
+	}
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = r[c]
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func flatten(prefix string, n any, row map[string]string, cols *[]string) {
+	if obj, ok := n.(object); ok && (len(obj) > 0 || prefix == "") {
+		for _, f := range obj {
+			key := f.key
+			if prefix != "" {
+				key = prefix + "." + f.key
+			}
+			flatten(key, f.val, row, cols)
+		}
+		return
+	}
+	if prefix == "" {
+		prefix = "value"
+	}
+	if !slices.Contains(*cols, prefix) {
+		*cols = append(*cols, prefix)
+	}
+	row[prefix] = cell(n)
+}
+
+func cell(n any) string {
+	switch v := n.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, _ := json.Marshal(fromNode(n))
+	return string(b)
+}
+
+// object is a JSON object with its key order preserved. The tree
+// built by toNode uses object, []any, string, json.Number, bool and
+// nil.
+type object []field
+
+type field struct {
+	key string
+	val any
+}
+
+// toNode round-trips v through encoding/json so every format shares
+// the JSON schema, keeping struct field order.
+func toNode(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeNode(dec)
+}
+
+func decodeNode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := object{}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, field{key: k.(string), val: v})
+			}
+			_, err := dec.Token() // '}'
+			return obj, err
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				v, err := decodeNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+			_, err := dec.Token() // ']'
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// fromNode converts back to values encoding/json can marshal, for
+// the compact-JSON table cells.
+func fromNode(n any) any {
+	switch v := n.(type) {
+	case object:
+		m := make(map[string]any, len(v))
+		for _, f := range v {
+			m[f.key] = fromNode(f.val)
+		}
+		return m
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = fromNode(e)
+		}
+		return out
+	}
+	return n
+}
+
+// writeYAML emits n as block-style YAML at the given indent.
+func writeYAML(b *strings.Builder, n any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := n.(type) {
+	case object:
+		if len(v) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+		for _, f := range v {
+			b.WriteString(pad + yamlKey(f.key) + ":")
+			writeYAMLValue(b, f.val, indent)
+		}
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for _, e := range v {
+			b.WriteString(pad + "-")
+			writeYAMLItem(b, e, indent)
+		}
+	default:
+		b.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLValue finishes a `key:` line.
+func writeYAMLValue(b *strings.Builder, n any, indent int) {
+	switch v := n.(type) {
+	case object:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+1)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+1)
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLItem finishes a `-` line. An object's first key shares
+// the dash line, the usual compact form.
+func writeYAMLItem(b *strings.Builder, n any, indent int) {
+	switch v := n.(type) {
+	case object:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		var inner strings.Builder
+		writeYAML(&inner, v, indent+1)
+		b.WriteString(" " + strings.TrimLeft(inner.String(), " "))
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+1)
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func yamlKey(k string) string {
+	if k != "" && !needsQuote(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
+
+func yamlScalar(n any) string {
+	switch v := n.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if v == "" || needsQuote(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return fmt.Sprint(n)
+}
+
+// needsQuote is conservative: anything a YAML 1.1 or 1.2 reader
+// could take for a non-string, or that contains syntax, is quoted.
+func needsQuote(s string) bool {
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.TrimSpace(s) != s {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`0123456789.") {
+		return true
+	}
+	return strings.ContainsAny(s, ":#\n\t\\") || strings.Contains(s, ": ")
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+type inner struct {
+	Idle    int `json:"idle"`
+	Running int `json:"running"`
+}
+
+type row struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Ready   bool     `json:"ready"`
+	Workers inner    `json:"workers"`
+	Tags    []string `json:"tags"`
+}
+
+func rows() []row {
+	return []row{
+		{ID: "ep1", Name: "real-esrgan-rtx-4090", Ready: true, Workers: inner{1, 2}, Tags: []string{"a"}},
+		{ID: "ep2", Name: "ffmpeg-cpu", Workers: inner{0, 0}},
+	}
+}
+
+func render(t *testing.T, o Options, v any) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Render(&buf, o, v, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRender_Table(t *testing.T) {
+	got := render(t, Options{Format: Table}, rows())
+	want := "" +
+		"ID   NAME                  READY  WORKERS.IDLE  WORKERS.RUNNING  TAGS\n" +
+		"ep1  real-esrgan-rtx-4090  true   1             2                [\"a\"]\n" +
+		"ep2  ffmpeg-cpu            false  0             0                \n"
+	if got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRender_TableColumnsAndNoHeaders(t *testing.T) {
+	got := render(t, Options{Format: Table, Columns: []string{"name", "workers.idle"}, NoHeaders: true}, rows())
+	want := "real-esrgan-rtx-4090  1\nffmpeg-cpu            0\n"
+	if got != want {
+		t.Errorf("table =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRender_TableUnknownColumn(t *testing.T) {
+	err := Render(io.Discard, Options{Format: Table, Columns: []string{"nope"}}, rows(), nil)
+	if err == nil || !strings.Contains(err.Error(), "available: id, name") {
+		t.Fatalf("expected unknown-column error listing available columns, got %v", err)
+	}
+}
+
+func TestRender_YAML(t *testing.T) {
+	got := render(t, Options{Format: YAML}, rows())
+	want := `- id: ep1
+  name: real-esrgan-rtx-4090
+  ready: true
+  workers:
+    idle: 1
+    running: 2
+  tags:
+    - a
+- id: ep2
+  name: ffmpeg-cpu
+  ready: false
+  workers:
+    idle: 0
+    running: 0
+  tags: null
+`
+	if got != want {
+		t.Errorf("yaml =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestYAMLScalar_QuotesAmbiguousStrings(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":            "plain",
+		"12.8":             `"12.8"`,
+		"true":             `"true"`,
+		"":                 `""`,
+		"a: b":             `"a: b"`,
+		"https://x.io/a":   `"https://x.io/a"`,
+		"ghcr.io/ls-ads/x": "ghcr.io/ls-ads/x",
+	} {
+		if got := yamlScalar(in); got != want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRender_TextUsesLegacyPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, Options{Format: Text}, rows(), func(w io.Writer) { io.WriteString(w, "legacy\n") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "legacy\n" {
+		t.Errorf("text output = %q, want the legacy printer's", buf.String())
+	}
+}
+
+func TestRegister_ParsesAndValidates(t *testing.T) {
+	fs := flag.NewFlagSet("x", flag.ContinueOnError)
+	o := Register(fs)
+	if err := fs.Parse([]string{"-o", "table", "--columns", "id, name", "--no-headers"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if o.Format != Table || strings.Join(o.Columns, ",") != "id,name" || !o.NoHeaders {
+		t.Errorf("parsed = %+v", o)
+	}
+
+	for _, args := range [][]string{
+		{"--output", "xml"},
+		{"--output", "json", "--columns", "id"},
+	} {
+		fs := flag.NewFlagSet("x", flag.ContinueOnError)
+		o := Register(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%v) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package outputtest holds the golden-file check the packages with
+// `--output json` results share. Run `go test -update` in a package
+// to rewrite its testdata/*.golden.json files.
+package outputtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iosuite.io/internal/output"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden files")
+
+// Golden compares the --output json rendering of v with
+// testdata/<name>. These files are the schema contract for scripts:
+// a diff here is a breaking change, not a fixture refresh.
+func Golden(t testing.TB, name string, v any) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.Options{Format: output.JSON}, v, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run `go test -update` to create)", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("%s changed:\ngot\n%s\nwant\n%s", name, buf.Bytes(), want)
+	}
+}
@@ -0,0 +1,397 @@
+// Package retry is the shared retry / backoff / circuit-breaker
+// component for iosuite's calls to RunPod.
+//
+// Both the `iosuite serve --provider runpod` daemon and `iosuite
+// endpoint benchmark` POST to api.runpod.ai/v2/<id>/runsync, and both
+// used to give up on the first bad response. One flaky gateway hop
+// then threw away a whole benchmark run or 502'd a user's upload.
+//
+// Rules are idempotency-aware. A /runsync POST creates a job, so it is
+// only retried when the failure says the job was never accepted: a
+// refused connection, a reset before the request was fully written,
+// 502 / 503 / 504 from the gateway, and 429. A reset after the write
+// proves nothing (the upstream may have created the job and then
+// dropped the connection), so callers send non-idempotent requests
+// through Track, which marks those errors, and they are not retried.
+// Other 4xx mean the request itself is wrong and are never retried.
+// Idempotent calls (GET /status, GraphQL reads) additionally retry
+// 500s, timeouts and truncated bodies.
+//
+// A Breaker shared across calls fails fast once the upstream has
+// failed Threshold times in a row, so a dead endpoint costs one error
+// per request rather than MaxAttempts × backoff per request.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Policy is how hard a caller tries before surfacing an error. The
+// zero value is usable: every field falls back to a default.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	// Default 4; 1 disables retries.
+	MaxAttempts int
+
+	// Budget caps the wall-clock time spent across all attempts and
+	// the waits between them: each attempt's context expires when the
+	// budget does, so a stalled attempt can't overrun it. Default 0 =
+	// no cap beyond MaxAttempts and the caller's context.
+	Budget time.Duration
+
+	// Initial is the wait before the first retry. Default 500 ms.
+	Initial time.Duration
+
+	// Max caps the exponential backoff. Default 10 s.
+	Max time.Duration
+
+	// Multiplier grows the wait after every retry. Default 2.
+	Multiplier float64
+
+	// Jitter randomises each wait by ±Jitter (fraction 0..1).
+	// Default 0.2; negative disables.
+	Jitter float64
+
+	// Breaker, when set, is consulted before every attempt and told
+	// about every outcome. Share one Breaker per upstream.
+	Breaker *Breaker
+
+	// OnRetry, when set, is called before each wait so callers can
+	// log the retry in their own format.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 4
+	}
+	if p.Initial <= 0 {
+		p.Initial = 500 * time.Millisecond
+	}
+	if p.Max <= 0 {
+		p.Max = 10 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter == 0 {
+		p.Jitter = 0.2
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	return p
+}
+
+// Do runs fn until it succeeds, returns an error that isn't worth
+// retrying, or the policy is exhausted. idempotent selects the rule
+// set (see package doc). The returned error is fn's last error,
+// wrapped with the attempt count when more than one try was made.
+func (p Policy) Do(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	p = p.withDefaults()
+	var deadline time.Time
+	if p.Budget > 0 {
+		deadline = time.Now().Add(p.Budget)
+	}
+	delay := p.Initial
+	for attempt := 1; ; attempt++ {
+		if p.Breaker != nil {
+			if err := p.Breaker.Allow(); err != nil {
+				return err
+			}
+		}
+		overBudget, err := p.attempt(ctx, deadline, fn)
+		if p.Breaker != nil {
+			p.Breaker.Record(err)
+		}
+		if err == nil {
+			return nil
+		}
+		if overBudget {
+			return fmt.Errorf("retry budget %s exhausted during attempt %d: %w", p.Budget, attempt, err)
+		}
+		if ctx.Err() != nil || !Retryable(err, idempotent) {
+			return wrapAttempts(err, attempt)
+		}
+		if attempt >= p.MaxAttempts {
+			return wrapAttempts(err, attempt)
+		}
+		wait := Jitter(delay, p.Jitter)
+		if ra := RetryAfter(err); ra > wait {
+			wait = ra
+		}
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("retry budget %s exhausted after %d attempts: %w", p.Budget, attempt, err)
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return wrapAttempts(err, attempt)
+		case <-time.After(wait):
+		}
+		delay = Next(delay, p.Multiplier, p.Max)
+	}
+}
+
+// attempt runs fn once, under a context that ends at deadline when
+// there is one, and reports whether it failed because the budget ran
+// out rather than because of the caller's context.
+func (p Policy) attempt(ctx context.Context, deadline time.Time, fn func(ctx context.Context) error) (bool, error) {
+	if deadline.IsZero() {
+		return false, fn(ctx)
+	}
+	actx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	err := fn(actx)
+	return err != nil && actx.Err() != nil && ctx.Err() == nil, err
+}
+
+func wrapAttempts(err error, attempts int) error {
+	if attempts <= 1 {
+		return err
+	}
+	return fmt.Errorf("after %d attempts: %w", attempts, err)
+}
+
+// HTTPError is a non-2xx upstream response. Callers return it from
+// the fn passed to Do so the status code drives the retry decision.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       string // already truncated for display
+	// RetryAfter is the parsed Retry-After header, zero when absent.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("runpod %s: HTTP %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// NewHTTPError builds an HTTPError from a response whose body the
+// caller has already read.
+func NewHTTPError(url string, resp *http.Response, body string) *HTTPError {
+	return &HTTPError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// Retryable reports whether err is worth another attempt under the
+// idempotent / non-idempotent rule set described in the package doc.
+func Retryable(err error, idempotent bool) bool {
+	if err == nil || errors.Is(err, ErrOpen) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		switch herr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		case http.StatusInternalServerError, http.StatusRequestTimeout:
+			return idempotent
+		}
+		return false
+	}
+	// Past this point there is no response. Once the request was
+	// written, the upstream may have acted on it.
+	if !idempotent && errors.As(err, new(*writtenError)) {
+		return false
+	}
+	// The request never reached a server that could have acted on it.
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+	// Safe to repeat: anything that looks like a dropped or stalled
+	// connection.
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Track returns req with a trace that notes when the request has been
+// fully written, and a function to pass any error from sending it or
+// reading the response through. An error after the write is marked so
+// Retryable won't retry it under the non-idempotent rules, whatever
+// it is: a connection reset then may follow an accepted job.
+func Track(req *http.Request) (*http.Request, func(error) error) {
+	var written atomic.Bool
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return req, func(err error) error {
+		if err == nil || !written.Load() {
+			return err
+		}
+		return &writtenError{err}
+	}
+}
+
+// writtenError is a failure after the request was fully written.
+type writtenError struct{ err error }
+
+func (e *writtenError) Error() string { return e.err.Error() }
+func (e *writtenError) Unwrap() error { return e.err }
+
+// RetryAfter returns the upstream's Retry-After hint carried by err,
+// or zero.
+func RetryAfter(err error) time.Duration {
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		return herr.RetryAfter
+	}
+	return 0
+}
+
+// ParseRetryAfter reads a Retry-After header in either of its RFC
+// 9110 forms (delta-seconds or HTTP-date). Unparseable or past
+// values return 0 so the caller falls back to its own backoff.
+func ParseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Next grows d by mult, capped at max.
+func Next(d time.Duration, mult float64, max time.Duration) time.Duration {
+	next := time.Duration(float64(d) * mult)
+	if next > max || next <= 0 {
+		return max
+	}
+	return next
+}
+
+// Jitter spreads d uniformly over [d*(1-frac), d*(1+frac)].
+func Jitter(d time.Duration, frac float64) time.Duration {
+	if frac <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + frac*(2*rand.Float64()-1)))
+}
+
+// ErrOpen is returned (wrapped) by Breaker.Allow while the circuit is
+// open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// Breaker is a consecutive-failure circuit breaker. After Threshold
+// upstream failures in a row it opens and rejects calls for Cooldown;
+// the first call after that is let through as a probe, and its
+// outcome closes or re-opens the circuit. Safe for concurrent use.
+//
+// Only failures that say something about upstream health count
+// (anything Retryable under the idempotent rules). A 400 means the
+// upstream answered, so it resets the count like a success does.
+type Breaker struct {
+	Threshold int           // default 5
+	Cooldown  time.Duration // default 30 s
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	lastErr  error
+	probing  bool
+}
+
+// NewBreaker returns a Breaker; zero arguments take the defaults.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+func (b *Breaker) limits() (int, time.Duration) {
+	threshold, cooldown := b.Threshold, b.Cooldown
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return threshold, cooldown
+}
+
+// Allow returns nil when a call may proceed, or an error wrapping
+// ErrOpen (and the failure that tripped the breaker) when it may not.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	threshold, cooldown := b.limits()
+	if b.failures < threshold {
+		return nil
+	}
+	if time.Since(b.openedAt) < cooldown || b.probing {
+		return fmt.Errorf("%w after %d consecutive failures (last: %v)", ErrOpen, b.failures, b.lastErr)
+	}
+	b.probing = true
+	return nil
+}
+
+// Record feeds one call's outcome into the breaker.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// Our caller gave up; says nothing about the upstream.
+		return
+	}
+	if err == nil || !Retryable(err, true) {
+		b.failures = 0
+		b.lastErr = nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if threshold, _ := b.limits(); b.failures >= threshold {
+		b.openedAt = time.Now()
+	}
+}
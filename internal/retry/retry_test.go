@@ -0,0 +1,297 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fast keeps test waits in the millisecond range.
+var fast = Policy{Initial: time.Millisecond, Max: time.Millisecond}
+
+func httpErr(code int) error {
+	return &HTTPError{URL: "/runsync", StatusCode: code, Body: "x"}
+}
+
+func TestRetryable_NonIdempotent(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{httpErr(429), true},
+		{httpErr(502), true},
+		{httpErr(503), true},
+		{httpErr(504), true},
+		{httpErr(500), false}, // the job may have been created
+		{httpErr(400), false},
+		{httpErr(401), false},
+		{httpErr(404), false},
+		{fmt.Errorf("post: %w", syscall.ECONNRESET), true},
+		{fmt.Errorf("post: %w", syscall.ECONNREFUSED), true},
+		{io.ErrUnexpectedEOF, false},
+		{context.Canceled, false},
+	}
+	for _, tc := range cases {
+		if got := Retryable(tc.err, false); got != tc.want {
+			t.Errorf("Retryable(%v, false) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryable_IdempotentAddsServerErrors(t *testing.T) {
+	for _, err := range []error{httpErr(500), httpErr(408), io.ErrUnexpectedEOF} {
+		if !Retryable(err, true) {
+			t.Errorf("Retryable(%v, true) = false, want true", err)
+		}
+	}
+	if Retryable(httpErr(403), true) {
+		t.Error("4xx must not be retried even when idempotent")
+	}
+}
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := fast.Do(context.Background(), false, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return httpErr(503)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDo_StopsAtMaxAttempts(t *testing.T) {
+	p := fast
+	p.MaxAttempts = 2
+	calls := 0
+	err := p.Do(context.Background(), false, func(context.Context) error {
+		calls++
+		return httpErr(502)
+	})
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Fatalf("expected attempts-exhausted error, got %v", err)
+	}
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != 502 {
+		t.Errorf("last upstream error should stay unwrappable: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestDo_NoRetryOnClientError(t *testing.T) {
+	calls := 0
+	_ = fast.Do(context.Background(), false, func(context.Context) error {
+		calls++
+		return httpErr(422)
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDo_BudgetExhausted(t *testing.T) {
+	p := Policy{Initial: time.Hour, Max: time.Hour, Budget: 10 * time.Millisecond, Jitter: -1}
+	err := p.Do(context.Background(), false, func(context.Context) error {
+		return httpErr(503)
+	})
+	if err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+}
+
+func TestDo_BudgetBoundsAStalledAttempt(t *testing.T) {
+	p := Policy{Budget: 20 * time.Millisecond}
+	start := time.Now()
+	err := p.Do(context.Background(), true, func(ctx context.Context) error {
+		<-ctx.Done() // an upstream that never answers
+		return ctx.Err()
+	})
+	if err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Do returned after %s, well past the 20ms budget", d)
+	}
+}
+
+func TestTrack_ResetAfterWriteIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.ReadAll(r.Body)
+		// The job is accepted, then the connection drops.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if tc, ok := conn.(*net.TCPConn); ok {
+			_ = tc.SetLinger(0)
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	err := fast.Do(context.Background(), false, func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(`{"input":{}}`))
+		req, sent := Track(req)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			return sent(err)
+		}
+		resp.Body.Close()
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected the dropped connection to surface")
+	}
+	if !errors.As(err, new(*writtenError)) {
+		t.Errorf("err = %v, want it marked as after the write", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("POST sent %d times after the upstream had it; want 1", n)
+	}
+	if !Retryable(err, true) {
+		t.Errorf("an idempotent call should still retry %v", err)
+	}
+}
+
+func TestTrack_UnsentRequestIsRetried(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // nothing listening: connection refused
+
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{}`))
+	req, sent := Track(req)
+	_, err := http.DefaultClient.Do(req)
+	if err = sent(err); !Retryable(err, false) {
+		t.Errorf("refused connection should be retryable: %v", err)
+	}
+}
+
+func TestDo_HonoursRetryAfter(t *testing.T) {
+	var waits []time.Duration
+	p := fast
+	p.MaxAttempts = 2
+	p.OnRetry = func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) }
+	_ = p.Do(context.Background(), false, func(context.Context) error {
+		return &HTTPError{StatusCode: 429, RetryAfter: 20 * time.Millisecond}
+	})
+	if len(waits) != 1 || waits[0] < 20*time.Millisecond {
+		t.Errorf("waits = %v, want one wait >= Retry-After (20ms)", waits)
+	}
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	b := NewBreaker(2, 20*time.Millisecond)
+	b.Record(httpErr(503))
+	if err := b.Allow(); err != nil {
+		t.Fatalf("breaker opened after 1 failure: %v", err)
+	}
+	b.Record(httpErr(503))
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("breaker should be open after 2 failures, got %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("breaker should let a probe through after cooldown: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("only one probe at a time while half-open, got %v", err)
+	}
+	b.Record(nil)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("successful probe should close the breaker: %v", err)
+	}
+}
+
+func TestBreaker_ClientErrorsDoNotTrip(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	b.Record(httpErr(400))
+	if err := b.Allow(); err != nil {
+		t.Errorf("a 4xx means the upstream is up; breaker should stay closed: %v", err)
+	}
+}
+
+func TestDo_FailsFastWhenBreakerOpen(t *testing.T) {
+	p := fast
+	p.Breaker = NewBreaker(1, time.Minute)
+	p.MaxAttempts = 1
+	_ = p.Do(context.Background(), false, func(context.Context) error { return httpErr(503) })
+
+	calls := 0
+	err := p.Do(context.Background(), false, func(context.Context) error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("fn ran %d times with the breaker open", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-1":                            0,
+		"garbage":                       0,
+		"Fri, 01 May 2026 12:00:30 GMT": 30 * time.Second,
+		"Fri, 01 May 2026 11:59:00 GMT": 0, // already past
+	}
+	for in, want := range cases {
+		if got := ParseRetryAfter(in, now); got != want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestNewHTTPError_ReadsRetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"3"}}}
+	if got := NewHTTPError("/x", resp, "").RetryAfter; got != 3*time.Second {
+		t.Errorf("RetryAfter = %v, want 3s", got)
+	}
+}
+
+func TestNext_CapsAtMax(t *testing.T) {
+	d := 250 * time.Millisecond
+	for i := 0; i < 20; i++ {
+		d = Next(d, 1.5, 15*time.Second)
+	}
+	if d != 15*time.Second {
+		t.Errorf("delay after 20 steps = %v, want cap 15s", d)
+	}
+}
+
+func TestJitter_StaysInBand(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		got := Jitter(time.Second, 0.2)
+		if got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("Jitter(1s, 0.2) = %v, outside ±20%%", got)
+		}
+	}
+	if got := Jitter(time.Second, 0); got != time.Second {
+		t.Errorf("Jitter with frac 0 = %v, want unchanged", got)
+	}
+}
@@ -0,0 +1,67 @@
+package runpod
+
+import "context"
+
+// Account is the billing half of the GraphQL `myself` object.
+// Amounts are USD.
+type Account struct {
+	Balance    float64 `json:"clientBalance"`
+	SpendPerHr float64 `json:"currentSpendPerHr"` // everything running right now
+	// SpendLimit is the account's hourly spend cap; 0 when unset.
+	SpendLimit float64         `json:"spendLimit"`
+	Endpoints  []EndpointSpend `json:"endpoints"`
+}
+
+// EndpointSpend is a serverless endpoint and the workers it is paying
+// for right now. RunPod bills a serverless worker while it runs, so
+// the endpoint's spend rate is the sum of its workers' CostPerHr.
+type EndpointSpend struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	GPUIDs  string        `json:"gpuIds"`
+	Workers []WorkerSpend `json:"pods"`
+}
+
+// WorkerSpend is one serverless worker (a pod in RunPod's schema).
+type WorkerSpend struct {
+	ID            string  `json:"id"`
+	DesiredStatus string  `json:"desiredStatus"` // RUNNING, EXITED, ...
+	CostPerHr     float64 `json:"costPerHr"`
+}
+
+// SpendPerHr sums the hourly cost of the endpoint's running workers.
+func (e EndpointSpend) SpendPerHr() float64 {
+	var sum float64
+	for _, w := range e.Workers {
+		if w.DesiredStatus == "RUNNING" {
+			sum += w.CostPerHr
+		}
+	}
+	return sum
+}
+
+// RunningWorkers counts the endpoint's running workers.
+func (e EndpointSpend) RunningWorkers() int {
+	n := 0
+	for _, w := range e.Workers {
+		if w.DesiredStatus == "RUNNING" {
+			n++
+		}
+	}
+	return n
+}
+
+// GetAccount returns the account's balance, spend rate and
+// per-endpoint worker costs.
+func (c *Client) GetAccount(ctx context.Context) (*Account, error) {
+	var out struct {
+		Myself Account `json:"myself"`
+	}
+	if err := c.query(ctx, `query account { myself {
+		clientBalance currentSpendPerHr spendLimit
+		endpoints { id name gpuIds pods { id desiredStatus costPerHr } }
+	} }`, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.Myself, nil
+}
@@ -0,0 +1,67 @@
+package runpod
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestGetAccount(t *testing.T) {
+	withGraphQL(t, "account")
+	acct, err := testClient().GetAccount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.Balance != 41.27 || acct.SpendPerHr != 1.38 || acct.SpendLimit != 80 {
+		t.Errorf("account = %+v", acct)
+	}
+	if len(acct.Endpoints) != 2 {
+		t.Fatalf("endpoints = %+v", acct.Endpoints)
+	}
+	ep := acct.Endpoints[0]
+	if ep.RunningWorkers() != 2 || math.Abs(ep.SpendPerHr()-1.38) > 1e-9 {
+		t.Errorf("ep1: %d running, $%v/hr; want 2, $1.38 (exited workers don't bill)", ep.RunningWorkers(), ep.SpendPerHr())
+	}
+	if acct.Endpoints[1].SpendPerHr() != 0 {
+		t.Errorf("ep2 spend = %v, want 0", acct.Endpoints[1].SpendPerHr())
+	}
+}
+
+func TestListGPUTypes_PoolStock(t *testing.T) {
+	withGraphQL(t, "gpuTypes")
+	all, err := testClient().ListGPUTypes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pool, want := range map[string]string{
+		"ADA_24":            StockLow,
+		"AMPERE_24":         StockHigh, // best of A5000 (High), L4 (Medium), 3090 (absent)
+		"HOPPER_141":        StockNone, // null stockStatus
+		"AMPERE_80":         StockNone, // no member in the catalogue
+		"MYSTERY_99":        StockUnknown,
+		"ADA_24,HOPPER_141": StockLow,
+	} {
+		if got := PoolStock(all, pool); got != want {
+			t.Errorf("PoolStock(%s) = %q, want %q", pool, got, want)
+		}
+	}
+}
+
+func TestPoolPricePerSecond(t *testing.T) {
+	withGraphQL(t, "gpuTypes")
+	all, err := testClient().ListGPUTypes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pool, perHr := range map[string]float64{
+		"ADA_24":     0.69,
+		"AMPERE_24":  0.29, // cheapest in-stock member
+		"HOPPER_141": 3.99, // none in stock: cheapest overall
+		"AMPERE_80":  0,    // nothing priced
+		"MYSTERY_99": 0,
+	} {
+		if got := PoolPricePerSecond(all, pool); math.Abs(got-perHr/3600) > 1e-12 {
+			t.Errorf("PoolPricePerSecond(%s) = %v, want %v", pool, got, perHr/3600)
+		}
+	}
+}
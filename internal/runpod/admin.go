@@ -0,0 +1,77 @@
+package runpod
+
+import (
+	"context"
+	"fmt"
+)
+
+// Admin APIs, for `endpoint deploy --api`.
+const (
+	APIGraphQL = "graphql" // api.runpod.io/graphql — Client
+	APIREST    = "rest"    // rest.runpod.io/v1 — RESTClient
+)
+
+// DefaultAPI is the backend used when none is chosen.
+const DefaultAPI = APIGraphQL
+
+// Admin is what a deploy needs from RunPod's admin API: list, find,
+// save and delete templates and endpoints, plus the account
+// resources a spec refers to by name. Client (GraphQL) and
+// RESTClient implement it with the same semantics, which the
+// contract tests pin.
+type Admin interface {
+	ListEndpoints(ctx context.Context) ([]Endpoint, error)
+	FindEndpoint(ctx context.Context, name string) (*Endpoint, error)
+	ListTemplates(ctx context.Context) ([]Template, error)
+	FindTemplate(ctx context.Context, name string) (*Template, error)
+	SaveTemplate(ctx context.Context, in SaveTemplateInput) (string, error)
+	SaveEndpoint(ctx context.Context, in SaveEndpointInput) (string, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+	DeleteTemplate(ctx context.Context, name string) error
+	FindRegistryAuth(ctx context.Context, name string) (*RegistryAuth, error)
+	FindNetworkVolume(ctx context.Context, ref string) (*NetworkVolume, error)
+}
+
+var (
+	_ Admin = (*Client)(nil)
+	_ Admin = (*RESTClient)(nil)
+)
+
+// ValidAPI reports whether api names an admin backend.
+func ValidAPI(api string) bool {
+	return api == APIGraphQL || api == APIREST
+}
+
+// NewAdmin returns the admin backend named api ("" = DefaultAPI).
+func NewAdmin(api, apiKey, userAgent string) (Admin, error) {
+	switch api {
+	case "", APIGraphQL:
+		return NewClient(apiKey, userAgent), nil
+	case APIREST:
+		return NewRESTClient(apiKey, userAgent), nil
+	default:
+		return nil, fmt.Errorf("unknown RunPod API %q (want %s or %s)", api, APIGraphQL, APIREST)
+	}
+}
+
+// endpointNamed returns the first endpoint called name, or nil.
+// RunPod allows duplicate names but we treat the first match as
+// authoritative — names are user-chosen and intended to be unique.
+func endpointNamed(all []Endpoint, name string) *Endpoint {
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i]
+		}
+	}
+	return nil
+}
+
+// templateNamed returns the template called name, or nil.
+func templateNamed(all []Template, name string) *Template {
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i]
+		}
+	}
+	return nil
+}
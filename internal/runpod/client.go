@@ -6,17 +6,24 @@
 // real-esrgan-serve's `build/runpod_deploy.py:RunPodClient`; lift
 // changes from there if RunPod's schema shifts.
 //
-// Two RunPod hosts are in play:
+// Three RunPod hosts are in play:
 //
 //   - https://api.runpod.io/graphql — administrative ops (templates,
-//     endpoints, account state). What this client talks to.
+//     endpoints, account state). What Client talks to.
+//   - https://rest.runpod.io/v1 — the newer REST admin API. RESTClient
+//     implements the deploy subset (Admin) against it.
 //   - https://api.runpod.ai/v2/{endpoint_id}/{run|status} — per-endpoint
-//     job submission. Not used by this client; handled in the
-//     `iosuite serve --provider runpod` path (round 3).
+//     job submission. Only /health is used here; jobs are handled in
+//     the `iosuite serve --provider runpod` path (round 3).
 //
 // Cloudflare in front of api.runpod.io blocks the default Go
 // `User-Agent: Go-http-client/1.1` with a 1020 challenge. Setting a
-// browser-shaped UA like `iosuite/<version>` gets through.
+// browser-shaped UA like `iosuite/<version>` gets through. A 1020 that
+// still happens (a proxy rewriting the UA, a blocked egress IP) is
+// reported as ErrCloudflareBlocked rather than a raw HTML page.
+//
+// Every call retries rate limits and transient gateway failures with
+// exponential backoff, honouring Retry-After; see Options.
 package runpod
 
 import (
@@ -26,7 +33,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
+
+	"iosuite.io/internal/retry"
 )
 
 const (
@@ -36,6 +45,33 @@ const (
 	GraphQLEndpoint = "https://api.runpod.io/graphql"
 )
 
+// graphQLURL is where query posts. Package-level var so tests can
+// point the client at an httptest server.
+var graphQLURL = GraphQLEndpoint
+
+// Defaults SaveTemplate / SaveEndpoint apply to zero-valued inputs.
+// Exported so deploy plans can predict what a save will write.
+const (
+	DefaultContainerDiskGB = 10 // sane default; the runpod-trt image is ~3 GB
+	DefaultIdleTimeoutS    = 30 // RunPod's recommended default
+	DefaultWorkersMax      = 1  // safe default; ops can scale up via console or update
+	DefaultScalerType      = ScalerQueueDelay
+	DefaultScalerValue     = 4 // seconds of queue delay before adding a worker
+)
+
+// Scaler types RunPod accepts for SaveEndpointInput.ScalerType.
+// QUEUE_DELAY adds workers once a job has waited ScalerValue seconds;
+// REQUEST_COUNT targets ScalerValue queued + running jobs per worker.
+const (
+	ScalerQueueDelay   = "QUEUE_DELAY"
+	ScalerRequestCount = "REQUEST_COUNT"
+)
+
+// ValidScalerType reports whether t is a scaler type RunPod accepts.
+func ValidScalerType(t string) bool {
+	return t == ScalerQueueDelay || t == ScalerRequestCount
+}
+
 // Client is a thin HTTP wrapper around the RunPod GraphQL endpoint.
 // Construct with NewClient; methods are safe for concurrent use
 // (http.Client is concurrent-safe and we don't share mutable state).
@@ -43,35 +79,90 @@ type Client struct {
 	apiKey    string
 	userAgent string
 	http      *http.Client
+	retry     retry.Policy
 }
 
 // NewClient returns a Client. apiKey must be non-empty; callers
 // resolve it from --runpod-api-key flag, RUNPOD_API_KEY env, or
-// config file in that order before calling here.
+// config file in that order before calling here. It uses
+// DefaultOptions.
 func NewClient(apiKey, userAgent string) *Client {
+	return NewClientWithOptions(apiKey, userAgent, DefaultOptions)
+}
+
+// NewClientWithOptions is NewClient with an explicit timeout and
+// retry policy.
+func NewClientWithOptions(apiKey, userAgent string, opts Options) *Client {
 	if userAgent == "" {
 		userAgent = "iosuite/dev"
 	}
 	return &Client{
 		apiKey:    apiKey,
 		userAgent: userAgent,
-		http:      &http.Client{Timeout: 60 * time.Second},
+		http:      opts.httpClient(),
+		retry:     opts.Retry,
 	}
 }
 
-// query runs a GraphQL operation. Returns the `data` payload on
-// success or an error describing the upstream failure (HTTP status,
-// network blip, GraphQL `errors` array). Callers cast the returned
-// any to whatever shape they expect from the operation.
-func (c *Client) query(ctx context.Context, query string, variables map[string]any) (any, error) {
-	body, err := json.Marshal(map[string]any{
-		"query":     query,
-		"variables": variables,
+// query runs a GraphQL operation with the given variables (any
+// JSON-marshalable value; nil for none) and decodes the `data`
+// payload into out. Returns an error describing the upstream failure
+// (HTTP status, network blip, GraphQL `errors` array) otherwise.
+//
+// Every value goes in variables, never into the operation text, so
+// names and env values need no escaping whatever they contain.
+func (c *Client) query(ctx context.Context, query string, variables, out any) error {
+	body, err := json.Marshal(struct {
+		Query     string `json:"query"`
+		Variables any    `json:"variables,omitempty"`
+	}{query, variables})
+	if err != nil {
+		return err
+	}
+	// Reads are safe to repeat; a mutation is only retried when the
+	// failure shows RunPod never acted on it (429, gateway errors,
+	// refused connections).
+	idempotent := strings.HasPrefix(strings.TrimSpace(query), "query")
+	var respBody []byte
+	err = c.retry.Do(ctx, idempotent, func(ctx context.Context) error {
+		respBody, err = c.post(ctx, body)
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return err
+	}
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, GraphQLEndpoint, bytes.NewReader(body))
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("runpod graphql: parse: %w (body: %s)", err, truncate(string(respBody), 200))
+	}
+	if len(envelope.Errors) > 0 {
+		msgs := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("runpod graphql: %v", msgs)
+	}
+	if out == nil {
+		return nil
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return fmt.Errorf("runpod graphql: response has no data")
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("runpod graphql: decode data: %w (body: %s)", err, truncate(string(envelope.Data), 200))
+	}
+	return nil
+}
+
+// post makes one attempt at the GraphQL endpoint and returns the
+// body of a 200 response.
+func (c *Client) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphQLURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -93,26 +184,9 @@ func (c *Client) query(ctx context.Context, query string, variables map[string]a
 		return nil, fmt.Errorf("runpod graphql: read body: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("runpod graphql: HTTP %d: %s",
-			resp.StatusCode, truncate(string(respBody), 400))
-	}
-	var envelope struct {
-		Data   any `json:"data"`
-		Errors []struct {
-			Message string `json:"message"`
-		} `json:"errors"`
-	}
-	if err := json.Unmarshal(respBody, &envelope); err != nil {
-		return nil, fmt.Errorf("runpod graphql: parse: %w (body: %s)", err, truncate(string(respBody), 200))
-	}
-	if len(envelope.Errors) > 0 {
-		msgs := make([]string, len(envelope.Errors))
-		for i, e := range envelope.Errors {
-			msgs[i] = e.Message
-		}
-		return nil, fmt.Errorf("runpod graphql: %v", msgs)
+		return nil, responseError("graphql", c.userAgent, resp, respBody)
 	}
-	return envelope.Data, nil
+	return respBody, nil
 }
 
 // Endpoint is the subset of an endpoint object we use for
@@ -128,83 +202,144 @@ type Endpoint struct {
 // the same template type drives both pod and serverless endpoints
 // when `isServerless: true` is set.
 type Template struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	ImageName string `json:"imageName"`
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	ImageName       string   `json:"imageName"`
+	ContainerDiskGB int      `json:"containerDiskInGb"`
+	Env             []EnvVar `json:"env"`
+	RegistryAuthID  string   `json:"containerRegistryAuthId"`
+	IsServerless    bool     `json:"isServerless"`
 }
 
 // ListEndpoints returns all serverless endpoints on the account.
 // Used by `iosuite endpoint list` and by FindEndpoint for name-based
 // lookup (RunPod doesn't expose a query-by-name).
 func (c *Client) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
-	data, err := c.query(ctx, `query { myself { endpoints { id name templateId } } }`, nil)
-	if err != nil {
-		return nil, err
-	}
-	// Re-marshal then re-unmarshal to type-cast through the
-	// any → struct shape. Avoids hand-walking the map[string]any.
-	raw, _ := json.Marshal(data)
 	var out struct {
 		Myself struct {
 			Endpoints []Endpoint `json:"endpoints"`
 		} `json:"myself"`
 	}
-	if err := json.Unmarshal(raw, &out); err != nil {
+	if err := c.query(ctx, `query listEndpoints { myself { endpoints { id name templateId } } }`, nil, &out); err != nil {
 		return nil, err
 	}
 	return out.Myself.Endpoints, nil
 }
 
 // FindEndpoint returns the endpoint matching the given name, or nil
-// if none exists. RunPod allows duplicate names but we treat the
-// first match as authoritative — names are user-chosen and intended
-// to be unique.
+// if none exists (first match wins; see endpointNamed).
 func (c *Client) FindEndpoint(ctx context.Context, name string) (*Endpoint, error) {
 	all, err := c.ListEndpoints(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return endpointNamed(all, name), nil
+}
+
+// EndpointDetail is an endpoint's full scaling / placement config,
+// as `iosuite endpoint status` shows it. Kept separate from Endpoint
+// so the list query stays cheap.
+type EndpointDetail struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	TemplateID         string    `json:"templateId"`
+	GPUIDs             string    `json:"gpuIds"`
+	WorkersMin         int       `json:"workersMin"`
+	WorkersMax         int       `json:"workersMax"`
+	IdleTimeout        int       `json:"idleTimeout"`
+	FlashBootType      string    `json:"flashBootType"`
+	MinCudaVersion     string    `json:"minCudaVersion"`
+	ScalerType         string    `json:"scalerType"`
+	ScalerValue        int       `json:"scalerValue"`
+	ExecutionTimeoutMs int       `json:"executionTimeoutMs"` // 0 = RunPod's default
+	NetworkVolumeID    string    `json:"networkVolumeId"`
+	Locations          string    `json:"locations"` // comma-separated data center ids; "" = any
+	Template           *Template `json:"template"`
+}
+
+// GetEndpoint returns the endpoint with the given id (including its
+// template's image), or nil if none exists. Like ListEndpoints this
+// filters `myself.endpoints` client-side — there's no by-id query.
+func (c *Client) GetEndpoint(ctx context.Context, id string) (*EndpointDetail, error) {
+	all, err := c.endpointDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for i := range all {
-		if all[i].Name == name {
+		if all[i].ID == id {
 			return &all[i], nil
 		}
 	}
 	return nil, nil
 }
 
-// FindTemplate returns the (serverless) template with the given
-// name, or nil if none exists.
-func (c *Client) FindTemplate(ctx context.Context, name string) (*Template, error) {
-	data, err := c.query(ctx, `query { myself { podTemplates { id name imageName } } }`, nil)
+// FindEndpointDetail is FindEndpoint returning the full detail, for
+// callers (deploy plans) that need the current settings by name.
+func (c *Client) FindEndpointDetail(ctx context.Context, name string) (*EndpointDetail, error) {
+	all, err := c.endpointDetails(ctx)
 	if err != nil {
 		return nil, err
 	}
-	raw, _ := json.Marshal(data)
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Client) endpointDetails(ctx context.Context) ([]EndpointDetail, error) {
+	var out struct {
+		Myself struct {
+			Endpoints []EndpointDetail `json:"endpoints"`
+		} `json:"myself"`
+	}
+	if err := c.query(ctx, `query endpointDetails { myself { endpoints {
+		id name templateId gpuIds workersMin workersMax idleTimeout
+		flashBootType minCudaVersion scalerType scalerValue executionTimeoutMs
+		networkVolumeId locations
+		template { id name imageName }
+	} } }`, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Myself.Endpoints, nil
+}
+
+// ListTemplates returns every template on the account, pod and
+// serverless alike.
+func (c *Client) ListTemplates(ctx context.Context) ([]Template, error) {
 	var out struct {
 		Myself struct {
 			PodTemplates []Template `json:"podTemplates"`
 		} `json:"myself"`
 	}
-	if err := json.Unmarshal(raw, &out); err != nil {
+	if err := c.query(ctx, `query listTemplates { myself { podTemplates {
+		id name imageName containerDiskInGb env { key value } containerRegistryAuthId isServerless
+	} } }`, nil, &out); err != nil {
 		return nil, err
 	}
-	for i := range out.Myself.PodTemplates {
-		if out.Myself.PodTemplates[i].Name == name {
-			return &out.Myself.PodTemplates[i], nil
-		}
+	return out.Myself.PodTemplates, nil
+}
+
+// FindTemplate returns the (serverless) template with the given
+// name, or nil if none exists.
+func (c *Client) FindTemplate(ctx context.Context, name string) (*Template, error) {
+	all, err := c.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return templateNamed(all, name), nil
 }
 
 // SaveTemplate creates a new template or updates an existing one
 // (when ExistingID is non-empty). Returns the template id.
 type SaveTemplateInput struct {
-	Name             string
-	Image            string
-	ContainerDiskGB  int
-	ExistingID       string
-	RegistryAuthID   string // empty = no registry auth (public images)
-	Env              []EnvVar
+	Name            string
+	Image           string
+	ContainerDiskGB int
+	ExistingID      string
+	RegistryAuthID  string // empty = no registry auth (public images; clears one on update)
+	Env             []EnvVar
 }
 
 // EnvVar is one container env entry.
@@ -213,9 +348,27 @@ type EnvVar struct {
 	Value string `json:"value"`
 }
 
+// templateInput is saveTemplate's SaveTemplateInput.
+type templateInput struct {
+	ID                string `json:"id,omitempty"`
+	Name              string `json:"name"`
+	ImageName         string `json:"imageName"`
+	ContainerDiskInGb int    `json:"containerDiskInGb"`
+	VolumeInGb        int    `json:"volumeInGb"`
+	DockerArgs        string `json:"dockerArgs"`
+	IsServerless      bool   `json:"isServerless"`
+	// Always sent: empty drops a credential on update.
+	ContainerRegistryAuthID string   `json:"containerRegistryAuthId"`
+	Env                     []EnvVar `json:"env"`
+}
+
+const saveTemplateMutation = `mutation saveTemplate($input: SaveTemplateInput!) {
+	saveTemplate(input: $input) { id name }
+}`
+
 func (c *Client) SaveTemplate(ctx context.Context, in SaveTemplateInput) (string, error) {
 	if in.ContainerDiskGB == 0 {
-		in.ContainerDiskGB = 10 // sane default; the runpod-trt image is ~3 GB
+		in.ContainerDiskGB = DefaultContainerDiskGB
 	}
 	// RunPod's saveTemplate input requires `env` to be a non-null
 	// list ([EnvironmentVariableInput]!). A nil Go slice marshals to
@@ -226,43 +379,23 @@ func (c *Client) SaveTemplate(ctx context.Context, in SaveTemplateInput) (string
 	if env == nil {
 		env = []EnvVar{}
 	}
-	envBytes, _ := json.Marshal(env)
-
-	idField := ""
-	if in.ExistingID != "" {
-		idField = fmt.Sprintf(`id: "%s",`, escapeGQL(in.ExistingID))
-	}
-	authField := ""
-	if in.RegistryAuthID != "" {
-		authField = fmt.Sprintf(`containerRegistryAuthId: "%s",`, escapeGQL(in.RegistryAuthID))
-	}
-	mutation := fmt.Sprintf(`
-		mutation saveTemplate {
-			saveTemplate(input: {
-				%s
-				%s
-				name: "%s",
-				imageName: "%s",
-				containerDiskInGb: %d,
-				volumeInGb: 0,
-				dockerArgs: "",
-				isServerless: true,
-				env: %s
-			}) { id name }
-		}
-	`, idField, authField, escapeGQL(in.Name), escapeGQL(in.Image), in.ContainerDiskGB, string(envBytes))
-
-	data, err := c.query(ctx, mutation, nil)
-	if err != nil {
-		return "", err
-	}
-	raw, _ := json.Marshal(data)
+	vars := struct {
+		Input templateInput `json:"input"`
+	}{templateInput{
+		ID:                      in.ExistingID,
+		Name:                    in.Name,
+		ImageName:               in.Image,
+		ContainerDiskInGb:       in.ContainerDiskGB,
+		IsServerless:            true,
+		ContainerRegistryAuthID: in.RegistryAuthID,
+		Env:                     env,
+	}}
 	var out struct {
 		SaveTemplate struct {
 			ID string `json:"id"`
 		} `json:"saveTemplate"`
 	}
-	if err := json.Unmarshal(raw, &out); err != nil {
+	if err := c.query(ctx, saveTemplateMutation, vars, &out); err != nil {
 		return "", err
 	}
 	return out.SaveTemplate.ID, nil
@@ -294,66 +427,95 @@ type SaveEndpointInput struct {
 	// 11.8, 12.0, 12.1, 12.2, 12.3, 12.4, 12.5, 12.6, 12.7, 12.8,
 	// 12.9, 13.0.
 	MinCudaVersion string
-	ExistingID     string
+	// ScalerType is ScalerQueueDelay or ScalerRequestCount; empty =
+	// DefaultScalerType. ScalerValue 0 = DefaultScalerValue.
+	ScalerType  string
+	ScalerValue int
+	// ExecutionTimeoutS fails a job that runs longer than this.
+	// 0 = leave RunPod's default.
+	ExecutionTimeoutS int
+	// NetworkVolumeID mounts a network volume at /runpod-volume.
+	// Empty = none (and clears one on update).
+	NetworkVolumeID string
+	// Locations restricts workers to these data centers, comma-
+	// separated (e.g. "EU-RO-1,US-KS-2"). Empty = anywhere in the pool
+	// (and lifts a restriction on update).
+	Locations  string
+	ExistingID string
+}
+
+// endpointInput is saveEndpoint's EndpointInput.
+type endpointInput struct {
+	ID            string `json:"id,omitempty"`
+	Name          string `json:"name"`
+	TemplateID    string `json:"templateId"`
+	GPUIDs        string `json:"gpuIds"`
+	WorkersMin    int    `json:"workersMin"`
+	WorkersMax    int    `json:"workersMax"`
+	IdleTimeout   int    `json:"idleTimeout"`
+	FlashBootType string `json:"flashBootType"`
+	ScalerType    string `json:"scalerType"`
+	ScalerValue   int    `json:"scalerValue"`
+	// Always sent, even empty: RunPod keeps the stored value for an
+	// omitted field, so "" / 0 is how an update removes a CUDA pin,
+	// timeout, data center restriction or volume.
+	MinCudaVersion     string `json:"minCudaVersion"`
+	ExecutionTimeoutMs int    `json:"executionTimeoutMs"`
+	Locations          string `json:"locations"`
+	NetworkVolumeID    string `json:"networkVolumeId"`
 }
 
+const saveEndpointMutation = `mutation saveEndpoint($input: EndpointInput!) {
+	saveEndpoint(input: $input) { id }
+}`
+
 func (c *Client) SaveEndpoint(ctx context.Context, in SaveEndpointInput) (string, error) {
 	if in.IdleTimeoutS == 0 {
-		in.IdleTimeoutS = 30 // RunPod's recommended default
+		in.IdleTimeoutS = DefaultIdleTimeoutS
 	}
 	if in.WorkersMax == 0 {
-		in.WorkersMax = 1 // safe default; ops can scale up via console or update
+		in.WorkersMax = DefaultWorkersMax
+	}
+	if in.ScalerType == "" {
+		in.ScalerType = DefaultScalerType
 	}
-	idField := ""
-	if in.ExistingID != "" {
-		idField = fmt.Sprintf(`id: "%s",`, escapeGQL(in.ExistingID))
+	if in.ScalerValue == 0 {
+		in.ScalerValue = DefaultScalerValue
 	}
 	// FlashBoot is exposed on RunPod's GraphQL API as an enum, not a
 	// boolean — `flashBootType: FLASHBOOT` (on) vs `flashBootType: OFF`.
 	// Both values discovered empirically: introspection is disabled
 	// on RunPod's Apollo server, and only those two pass validation.
-	// (RunPod's newer REST API at rest.runpod.io takes a plain
-	// `flashboot: bool`; we stay on GraphQL here for parity with the
-	// rest of this client until a broader REST migration.)
+	// (RunPod's newer REST API takes a plain `flashboot: bool`; see
+	// RESTClient.)
 	flashBootType := "OFF"
 	if in.Flashboot {
 		flashBootType = "FLASHBOOT"
 	}
-	cudaField := ""
-	if in.MinCudaVersion != "" {
-		cudaField = fmt.Sprintf(`minCudaVersion: "%s",`, escapeGQL(in.MinCudaVersion))
-	}
-	mutation := fmt.Sprintf(`
-		mutation saveEndpoint {
-			saveEndpoint(input: {
-				%s
-				name: "%s",
-				templateId: "%s",
-				gpuIds: "%s",
-				workersMin: %d,
-				workersMax: %d,
-				idleTimeout: %d,
-				flashBootType: %s,
-				%s
-				scalerType: "QUEUE_DELAY",
-				scalerValue: 4,
-				networkVolumeId: ""
-			}) { id }
-		}
-	`, idField, escapeGQL(in.Name), escapeGQL(in.TemplateID), escapeGQL(in.GPUPool),
-		in.WorkersMin, in.WorkersMax, in.IdleTimeoutS, flashBootType, cudaField)
-
-	data, err := c.query(ctx, mutation, nil)
-	if err != nil {
-		return "", err
-	}
-	raw, _ := json.Marshal(data)
+	vars := struct {
+		Input endpointInput `json:"input"`
+	}{endpointInput{
+		ID:                 in.ExistingID,
+		Name:               in.Name,
+		TemplateID:         in.TemplateID,
+		GPUIDs:             in.GPUPool,
+		WorkersMin:         in.WorkersMin,
+		WorkersMax:         in.WorkersMax,
+		IdleTimeout:        in.IdleTimeoutS,
+		FlashBootType:      flashBootType,
+		MinCudaVersion:     in.MinCudaVersion,
+		ExecutionTimeoutMs: in.ExecutionTimeoutS * 1000,
+		Locations:          in.Locations,
+		ScalerType:         in.ScalerType,
+		ScalerValue:        in.ScalerValue,
+		NetworkVolumeID:    in.NetworkVolumeID,
+	}}
 	var out struct {
 		SaveEndpoint struct {
 			ID string `json:"id"`
 		} `json:"saveEndpoint"`
 	}
-	if err := json.Unmarshal(raw, &out); err != nil {
+	if err := c.query(ctx, saveEndpointMutation, vars, &out); err != nil {
 		return "", err
 	}
 	return out.SaveEndpoint.ID, nil
@@ -363,27 +525,16 @@ func (c *Client) SaveEndpoint(ctx context.Context, in SaveEndpointInput) (string
 // drains workers internally; the call returns immediately on
 // success (a few hundred ms typically).
 func (c *Client) DeleteEndpoint(ctx context.Context, id string) error {
-	mutation := fmt.Sprintf(`mutation { deleteEndpoint(id: "%s") }`, escapeGQL(id))
-	_, err := c.query(ctx, mutation, nil)
-	return err
+	return c.query(ctx, `mutation deleteEndpoint($id: String!) { deleteEndpoint(id: $id) }`,
+		map[string]string{"id": id}, nil)
 }
 
-// escapeGQL is the minimal escape we need for the inline values we
-// inject into mutation strings. RunPod's API doesn't require fully
-// general JSON escaping of these fields (names are user-chosen but
-// constrained to a friendly subset), so this is a surface-area bug
-// guard, not a security boundary — operators with valid API keys
-// can already do anything to their account.
-func escapeGQL(s string) string {
-	out := make([]byte, 0, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c == '"' || c == '\\' {
-			out = append(out, '\\')
-		}
-		out = append(out, c)
-	}
-	return string(out)
+// DeleteTemplate removes the template with the given name. RunPod
+// keys template deletion by name, not id, and refuses while an
+// endpoint still references the template — delete the endpoint first.
+func (c *Client) DeleteTemplate(ctx context.Context, name string) error {
+	return c.query(ctx, `mutation deleteTemplate($templateName: String!) { deleteTemplate(templateName: $templateName) }`,
+		map[string]string{"templateName": name}, nil)
 }
 
 func truncate(s string, n int) string {
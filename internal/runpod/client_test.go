@@ -1,19 +1,315 @@
 package runpod
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
 
-func TestEscapeGQL(t *testing.T) {
-	cases := map[string]string{
-		"plain":          "plain",
-		`with "quotes"`:  `with \"quotes\"`,
-		`back\slash`:     `back\\slash`,
-		"":               "",
-		`mixed\"both`:    `mixed\\\"both`,
+// fixture is one recorded GraphQL exchange in testdata/graphql: the
+// operation and variables the client must send, and the response
+// RunPod gave (IDs redacted).
+type fixture struct {
+	Operation string          `json:"operation"`
+	Variables json.RawMessage `json:"variables"`
+	Status    int             `json:"status"` // 0 = 200
+	Response  json.RawMessage `json:"response"`
+}
+
+var operationRe = regexp.MustCompile(`^\s*(?:query|mutation)\s+(\w+)`)
+
+// gqlRequest is what the stand-in saw, for assertions beyond the
+// fixture's.
+type gqlRequest struct {
+	Query     string
+	Variables json.RawMessage
+}
+
+// withGraphQL points the client at an httptest stand-in that replays
+// the named fixtures in order, failing the test when a request
+// doesn't match the recording.
+func withGraphQL(t *testing.T, names ...string) *[]gqlRequest {
+	t.Helper()
+	fixtures := make([]fixture, len(names))
+	for i, name := range names {
+		raw, err := os.ReadFile(filepath.Join("testdata", "graphql", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(raw, &fixtures[i]); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
 	}
-	for in, want := range cases {
-		if got := escapeGQL(in); got != want {
-			t.Errorf("escapeGQL(%q) = %q, want %q", in, got, want)
+	var seen []gqlRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := len(seen)
+		if n >= len(fixtures) {
+			t.Errorf("unexpected request #%d", n+1)
+			http.Error(w, "no fixture", http.StatusInternalServerError)
+			return
+		}
+		fx := fixtures[n]
+		if r.Method != http.MethodPost {
+			t.Errorf("%s: method = %s, want POST", names[n], r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer k" {
+			t.Errorf("%s: Authorization = %q", names[n], got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: Content-Type = %q", names[n], got)
+		}
+		if got := r.Header.Get("User-Agent"); got != "iosuite/test" {
+			t.Errorf("%s: User-Agent = %q", names[n], got)
+		}
+		var req gqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("%s: decode request: %v", names[n], err)
+		}
+		seen = append(seen, req)
+		op := ""
+		if m := operationRe.FindStringSubmatch(req.Query); m != nil {
+			op = m[1]
+		}
+		if op != fx.Operation {
+			t.Errorf("%s: operation = %q, want %q", names[n], op, fx.Operation)
+		}
+		if !jsonEqual(t, req.Variables, fx.Variables) {
+			t.Errorf("%s: variables =\n%s\nwant\n%s", names[n], req.Variables, fx.Variables)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if fx.Status != 0 {
+			w.WriteHeader(fx.Status)
+		}
+		_, _ = w.Write(fx.Response)
+	}))
+	prev := graphQLURL
+	graphQLURL = srv.URL
+	t.Cleanup(func() {
+		graphQLURL = prev
+		srv.Close()
+		if len(seen) != len(fixtures) {
+			t.Errorf("%d of %d recorded exchanges were replayed", len(seen), len(fixtures))
+		}
+	})
+	return &seen
+}
+
+// jsonEqual compares two JSON documents by value; absent and null
+// are equal.
+func jsonEqual(t *testing.T, a, b json.RawMessage) bool {
+	t.Helper()
+	decode := func(raw json.RawMessage) any {
+		if len(raw) == 0 {
+			return nil
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatalf("bad JSON %s: %v", raw, err)
 		}
+		return v
+	}
+	return reflect.DeepEqual(decode(a), decode(b))
+}
+
+func testClient() *Client { return NewClient("k", "iosuite/test") }
+
+func TestListEndpoints(t *testing.T) {
+	withGraphQL(t, "listEndpoints")
+	got, err := testClient().ListEndpoints(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Endpoint{
+		{ID: "abc123xyz", Name: "real-esrgan-rtx-4090", TemplateID: "tpl111"},
+		{ID: "def456uvw", Name: "real-esrgan-l40s", TemplateID: "tpl222"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFindEndpoint(t *testing.T) {
+	withGraphQL(t, "listEndpoints", "listEndpoints")
+	c := testClient()
+	ep, err := c.FindEndpoint(context.Background(), "real-esrgan-l40s")
+	if err != nil || ep == nil || ep.ID != "def456uvw" {
+		t.Errorf("FindEndpoint = %+v, %v", ep, err)
+	}
+	if ep, err := c.FindEndpoint(context.Background(), "nope"); ep != nil || err != nil {
+		t.Errorf("missing name = %+v, %v; want nil, nil", ep, err)
+	}
+}
+
+func TestGetEndpoint_DecodesDetailAndNulls(t *testing.T) {
+	withGraphQL(t, "endpointDetails", "endpointDetails")
+	c := testClient()
+	got, err := c.GetEndpoint(context.Background(), "abc123xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &EndpointDetail{
+		ID: "abc123xyz", Name: "real-esrgan-rtx-4090", TemplateID: "tpl111",
+		GPUIDs: "ADA_24", WorkersMax: 3, IdleTimeout: 30, FlashBootType: "FLASHBOOT",
+		MinCudaVersion: "12.8", ScalerType: ScalerQueueDelay, ScalerValue: 4,
+		ExecutionTimeoutMs: 600000, NetworkVolumeID: "vol9", Locations: "EU-RO-1",
+		Template: &Template{ID: "tpl111", Name: "real-esrgan-rtx-4090-tmpl",
+			ImageName: "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	other, err := c.FindEndpointDetail(context.Background(), "real-esrgan-l40s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Template != nil || other.MinCudaVersion != "" || other.ExecutionTimeoutMs != 0 || other.WorkersMin != 1 {
+		t.Errorf("null fields should decode to zero values: %+v", other)
+	}
+}
+
+func TestListTemplates_AndFind(t *testing.T) {
+	withGraphQL(t, "listTemplates", "listTemplates")
+	c := testClient()
+	all, err := c.ListTemplates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || !all[0].IsServerless || all[1].IsServerless {
+		t.Fatalf("templates = %+v", all)
+	}
+	want := Template{
+		ID: "tpl111", Name: "real-esrgan-rtx-4090-tmpl",
+		ImageName:       "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.2",
+		ContainerDiskGB: 15, Env: []EnvVar{{Key: "LOG_LEVEL", Value: "info"}},
+		RegistryAuthID: "cred1", IsServerless: true,
+	}
+	if !reflect.DeepEqual(all[0], want) {
+		t.Errorf("got  %+v\nwant %+v", all[0], want)
+	}
+	if tmpl, err := c.FindTemplate(context.Background(), "jupyter"); err != nil || tmpl == nil || tmpl.ID != "tpl900" {
+		t.Errorf("FindTemplate = %+v, %v", tmpl, err)
+	}
+}
+
+func TestListRegistryAuthsAndVolumes(t *testing.T) {
+	withGraphQL(t, "listRegistryAuths", "listNetworkVolumes")
+	c := testClient()
+	auth, err := c.FindRegistryAuth(context.Background(), "dockerhub")
+	if err != nil || auth.ID != "cred2" {
+		t.Errorf("FindRegistryAuth = %+v, %v", auth, err)
+	}
+	vol, err := c.FindNetworkVolume(context.Background(), "weights")
+	if err != nil || *vol != (NetworkVolume{ID: "vol9", Name: "weights", Size: 50, DataCenterID: "EU-RO-1"}) {
+		t.Errorf("FindNetworkVolume = %+v, %v", vol, err)
+	}
+}
+
+func TestSaveTemplate_ValuesTravelAsVariables(t *testing.T) {
+	seen := withGraphQL(t, "saveTemplate_create")
+	name := "esrgan \"quoted\"\nnext-line ünï-tmpl"
+	id, err := testClient().SaveTemplate(context.Background(), SaveTemplateInput{
+		Name:  name,
+		Image: "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.2",
+		Env: []EnvVar{
+			{Key: "BANNER", Value: "tab\there\x01ctl \\ back"},
+			{Key: "EMOJI", Value: "🚀"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "tpl333" {
+		t.Errorf("id = %q, want tpl333", id)
+	}
+	q := (*seen)[0].Query
+	for _, v := range []string{"quoted", "ghcr.io", "BANNER"} {
+		if strings.Contains(q, v) {
+			t.Errorf("value %q interpolated into the operation:\n%s", v, q)
+		}
+	}
+}
+
+func TestSaveTemplate_Update(t *testing.T) {
+	withGraphQL(t, "saveTemplate_update")
+	id, err := testClient().SaveTemplate(context.Background(), SaveTemplateInput{
+		ExistingID:      "tpl111",
+		Name:            "real-esrgan-rtx-4090-tmpl",
+		Image:           "ghcr.io/ls-ads/real-esrgan-serve:runpod-trt-0.2.3",
+		ContainerDiskGB: 15,
+		RegistryAuthID:  "cred1",
+	})
+	if err != nil || id != "tpl111" {
+		t.Errorf("SaveTemplate = %q, %v", id, err)
+	}
+}
+
+func TestSaveEndpoint_CreateAppliesDefaults(t *testing.T) {
+	withGraphQL(t, "saveEndpoint_create")
+	id, err := testClient().SaveEndpoint(context.Background(), SaveEndpointInput{
+		Name:           "real-esrgan-rtx-4090",
+		TemplateID:     "tpl111",
+		GPUPool:        "ADA_24",
+		Flashboot:      true,
+		MinCudaVersion: "12.8",
+	})
+	if err != nil || id != "abc123xyz" {
+		t.Errorf("SaveEndpoint = %q, %v", id, err)
+	}
+}
+
+func TestSaveEndpoint_Update(t *testing.T) {
+	withGraphQL(t, "saveEndpoint_update")
+	id, err := testClient().SaveEndpoint(context.Background(), SaveEndpointInput{
+		ExistingID:        "abc123xyz",
+		Name:              "real-esrgan-rtx-4090",
+		TemplateID:        "tpl111",
+		GPUPool:           "ADA_24,ADA_48_PRO",
+		WorkersMin:        1,
+		WorkersMax:        3,
+		IdleTimeoutS:      5,
+		ScalerType:        ScalerRequestCount,
+		ScalerValue:       2,
+		ExecutionTimeoutS: 600,
+		NetworkVolumeID:   "vol9",
+		Locations:         "EU-RO-1",
+	})
+	if err != nil || id != "abc123xyz" {
+		t.Errorf("SaveEndpoint = %q, %v", id, err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	withGraphQL(t, "deleteEndpoint", "deleteTemplate")
+	c := testClient()
+	if err := c.DeleteEndpoint(context.Background(), "abc123xyz"); err != nil {
+		t.Errorf("DeleteEndpoint: %v", err)
+	}
+	if err := c.DeleteTemplate(context.Background(), "real-esrgan-rtx-4090-tmpl"); err != nil {
+		t.Errorf("DeleteTemplate: %v", err)
+	}
+}
+
+func TestQuery_GraphQLErrors(t *testing.T) {
+	withGraphQL(t, "deleteTemplate_inUse")
+	err := testClient().DeleteTemplate(context.Background(), "real-esrgan-rtx-4090-tmpl")
+	if err == nil || !strings.Contains(err.Error(), "Template is in use") {
+		t.Errorf("err = %v, want the GraphQL error message", err)
+	}
+}
+
+func TestQuery_HTTPStatus(t *testing.T) {
+	withGraphQL(t, "listEndpoints_unauthorized")
+	_, err := testClient().ListEndpoints(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("err = %v, want HTTP 401", err)
 	}
 }
 
@@ -0,0 +1,600 @@
+package runpod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The contract tests drive both Admin backends through the same
+// deploy / update / teardown story against in-memory fakes of the
+// two RunPod APIs that share one account model, and require the same
+// observable results and the same saved state from each.
+
+// storedEndpoint is an endpoint as the fake account keeps it,
+// normalized across the two wire formats.
+type storedEndpoint struct {
+	ID, Name, TemplateID   string
+	GPUs                   []string // GPU type ids
+	WorkersMin, WorkersMax int
+	IdleTimeout            int
+	Flashboot              bool
+	MinCuda                string
+	ExecutionTimeoutMs     int
+	ScalerType             string
+	ScalerValue            int
+	NetworkVolumeID        string
+	DataCenters            []string
+}
+
+type fakeAccount struct {
+	mu        sync.Mutex
+	next      int
+	templates []Template
+	endpoints []storedEndpoint
+	auths     []RegistryAuth
+	volumes   []NetworkVolume
+}
+
+func newFakeAccount() *fakeAccount {
+	return &fakeAccount{
+		auths:   []RegistryAuth{{ID: "cred1", Name: "ghcr"}},
+		volumes: []NetworkVolume{{ID: "vol1", Name: "weights", Size: 50, DataCenterID: "EU-RO-1"}},
+	}
+}
+
+func (a *fakeAccount) newID(prefix string) string {
+	a.next++
+	return fmt.Sprintf("%s%d", prefix, a.next)
+}
+
+func (a *fakeAccount) saveTemplate(t Template) (string, error) {
+	if t.ID == "" {
+		if templateNamed(a.templates, t.Name) != nil {
+			return "", fmt.Errorf("template name %q already in use", t.Name)
+		}
+		t.ID = a.newID("tpl")
+		a.templates = append(a.templates, t)
+		return t.ID, nil
+	}
+	for i := range a.templates {
+		if a.templates[i].ID == t.ID {
+			t.IsServerless = a.templates[i].IsServerless
+			a.templates[i] = t
+			return t.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no template %s", t.ID)
+}
+
+func (a *fakeAccount) saveEndpoint(e storedEndpoint) (string, error) {
+	if e.ID == "" {
+		e.ID = a.newID("ep")
+		a.endpoints = append(a.endpoints, e)
+		return e.ID, nil
+	}
+	for i := range a.endpoints {
+		if a.endpoints[i].ID == e.ID {
+			a.endpoints[i] = e
+			return e.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no endpoint %s", e.ID)
+}
+
+func (a *fakeAccount) deleteEndpoint(id string) error {
+	for i := range a.endpoints {
+		if a.endpoints[i].ID == id {
+			a.endpoints = append(a.endpoints[:i], a.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no endpoint %s", id)
+}
+
+func (a *fakeAccount) deleteTemplate(match func(Template) bool) error {
+	for i, t := range a.templates {
+		if !match(t) {
+			continue
+		}
+		for _, e := range a.endpoints {
+			if e.TemplateID == t.ID {
+				return fmt.Errorf("template %s is in use by endpoint %s", t.Name, e.Name)
+			}
+		}
+		a.templates = append(a.templates[:i], a.templates[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("no such template")
+}
+
+func (a *fakeAccount) listEndpoints() []Endpoint {
+	out := []Endpoint{}
+	for _, e := range a.endpoints {
+		out = append(out, Endpoint{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID})
+	}
+	return out
+}
+
+func checkAuth(t *testing.T, r *http.Request) {
+	t.Helper()
+	if got := r.Header.Get("Authorization"); got != "Bearer k" {
+		t.Errorf("%s %s: Authorization = %q", r.Method, r.URL.Path, got)
+	}
+	if got := r.Header.Get("User-Agent"); got != "iosuite/test" {
+		t.Errorf("%s %s: User-Agent = %q", r.Method, r.URL.Path, got)
+	}
+}
+
+// graphQLHandler fakes api.runpod.io/graphql over the account.
+func (a *fakeAccount) graphQLHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkAuth(t, r)
+		var req struct {
+			Query     string          `json:"query"`
+			Variables json.RawMessage `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode graphql request: %v", err)
+		}
+		op := ""
+		if m := operationRe.FindStringSubmatch(req.Query); m != nil {
+			op = m[1]
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		data, err := a.graphQL(op, req.Variables)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": err.Error()}}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	})
+}
+
+func (a *fakeAccount) graphQL(op string, vars json.RawMessage) (any, error) {
+	myself := func(field string, v any) any { return map[string]any{"myself": map[string]any{field: v}} }
+	switch op {
+	case "listEndpoints":
+		return myself("endpoints", a.listEndpoints()), nil
+	case "listTemplates":
+		return myself("podTemplates", a.templates), nil
+	case "listRegistryAuths":
+		return myself("containerRegistryCreds", a.auths), nil
+	case "listNetworkVolumes":
+		return myself("networkVolumes", a.volumes), nil
+	case "saveTemplate":
+		var v struct{ Input json.RawMessage }
+		if err := json.Unmarshal(vars, &v); err != nil {
+			return nil, err
+		}
+		var cur templateInput
+		if err := json.Unmarshal(v.Input, &cur); err != nil {
+			return nil, err
+		}
+		if cur.ID != "" {
+			t := templateWithID(a.templates, cur.ID)
+			if t == nil {
+				return nil, fmt.Errorf("no template %s", cur.ID)
+			}
+			cur = templateInputOf(*t)
+		}
+		in, err := patched(bytes.NewReader(v.Input), cur)
+		if err != nil {
+			return nil, err
+		}
+		id, err := a.saveTemplate(Template{ID: in.ID, Name: in.Name, ImageName: in.ImageName,
+			ContainerDiskGB: in.ContainerDiskInGb, Env: in.Env, RegistryAuthID: in.ContainerRegistryAuthID,
+			IsServerless: in.IsServerless})
+		return map[string]any{"saveTemplate": map[string]string{"id": id, "name": in.Name}}, err
+	case "saveEndpoint":
+		var v struct{ Input json.RawMessage }
+		if err := json.Unmarshal(vars, &v); err != nil {
+			return nil, err
+		}
+		var cur endpointInput
+		if err := json.Unmarshal(v.Input, &cur); err != nil {
+			return nil, err
+		}
+		if cur.ID != "" {
+			e := a.endpointWithID(cur.ID)
+			if e == nil {
+				return nil, fmt.Errorf("no endpoint %s", cur.ID)
+			}
+			cur = endpointInputOf(*e)
+		}
+		in, err := patched(bytes.NewReader(v.Input), cur)
+		if err != nil {
+			return nil, err
+		}
+		var gpus []string
+		for _, p := range splitComma(in.GPUIDs) {
+			gpus = append(gpus, gpuPools[p]...)
+		}
+		id, err := a.saveEndpoint(storedEndpoint{
+			ID: in.ID, Name: in.Name, TemplateID: in.TemplateID, GPUs: gpus,
+			WorkersMin: in.WorkersMin, WorkersMax: in.WorkersMax, IdleTimeout: in.IdleTimeout,
+			Flashboot: in.FlashBootType == "FLASHBOOT", MinCuda: in.MinCudaVersion,
+			ExecutionTimeoutMs: in.ExecutionTimeoutMs, ScalerType: in.ScalerType, ScalerValue: in.ScalerValue,
+			NetworkVolumeID: in.NetworkVolumeID, DataCenters: splitComma(in.Locations),
+		})
+		return map[string]any{"saveEndpoint": map[string]string{"id": id}}, err
+	case "deleteEndpoint":
+		var v struct{ ID string }
+		if err := json.Unmarshal(vars, &v); err != nil {
+			return nil, err
+		}
+		return map[string]any{"deleteEndpoint": nil}, a.deleteEndpoint(v.ID)
+	case "deleteTemplate":
+		var v struct{ TemplateName string }
+		if err := json.Unmarshal(vars, &v); err != nil {
+			return nil, err
+		}
+		return map[string]any{"deleteTemplate": nil}, a.deleteTemplate(func(t Template) bool { return t.Name == v.TemplateName })
+	}
+	return nil, fmt.Errorf("fake graphql: unknown operation %q", op)
+}
+
+// restHandler fakes rest.runpod.io/v1 over the account.
+func (a *fakeAccount) restHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkAuth(t, r)
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		status, body := a.rest(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if body != nil {
+			_ = json.NewEncoder(w).Encode(body)
+		}
+	})
+}
+
+func (a *fakeAccount) rest(r *http.Request) (int, any) {
+	collection, id, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	fail := func(status int, err error) (int, any) { return status, map[string]string{"error": err.Error()} }
+	switch r.Method + " " + collection {
+	case "GET endpoints":
+		var out []restEndpoint
+		for _, e := range a.listEndpoints() {
+			out = append(out, restEndpoint{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID})
+		}
+		return http.StatusOK, out
+	case "GET templates":
+		out := []restTemplate{}
+		for _, t := range a.templates {
+			out = append(out, restTemplateOf(t))
+		}
+		return http.StatusOK, out
+	case "GET containerregistryauth":
+		return http.StatusOK, a.auths
+	case "GET networkvolumes":
+		return http.StatusOK, a.volumes
+	case "POST templates", "PATCH templates":
+		var cur restTemplate
+		if r.Method == http.MethodPatch {
+			t := templateWithID(a.templates, id)
+			if t == nil {
+				return fail(http.StatusNotFound, fmt.Errorf("no template %s", id))
+			}
+			cur = restTemplateOf(*t)
+		}
+		in, err := patched(r.Body, cur)
+		if err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		tmpl := in.template()
+		tmpl.ID = id
+		saved, err := a.saveTemplate(tmpl)
+		if err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		return http.StatusOK, restTemplate{ID: saved, Name: in.Name}
+	case "POST endpoints", "PATCH endpoints":
+		var cur restEndpoint
+		if r.Method == http.MethodPatch {
+			e := a.endpointWithID(id)
+			if e == nil {
+				return fail(http.StatusNotFound, fmt.Errorf("no endpoint %s", id))
+			}
+			cur = restEndpointOf(*e)
+		}
+		in, err := patched(r.Body, cur)
+		if err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		minCuda := ""
+		if cuda := deref(in.AllowedCudaVersions); len(cuda) > 0 {
+			minCuda = cuda[0]
+		}
+		saved, err := a.saveEndpoint(storedEndpoint{
+			ID: id, Name: in.Name, TemplateID: in.TemplateID, GPUs: in.GPUTypeIDs,
+			WorkersMin: in.WorkersMin, WorkersMax: in.WorkersMax, IdleTimeout: in.IdleTimeout,
+			Flashboot: in.Flashboot, MinCuda: minCuda, ExecutionTimeoutMs: deref(in.ExecutionTimeoutMs),
+			ScalerType: in.ScalerType, ScalerValue: in.ScalerValue,
+			NetworkVolumeID: in.NetworkVolumeID, DataCenters: nonEmpty(deref(in.DataCenterIDs)),
+		})
+		if err != nil {
+			return fail(http.StatusNotFound, err)
+		}
+		return http.StatusOK, restEndpoint{ID: saved, Name: in.Name}
+	case "DELETE endpoints":
+		if err := a.deleteEndpoint(id); err != nil {
+			return fail(http.StatusNotFound, err)
+		}
+		return http.StatusNoContent, nil
+	case "DELETE templates":
+		if err := a.deleteTemplate(func(t Template) bool { return t.ID == id }); err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		return http.StatusNoContent, nil
+	}
+	return fail(http.StatusNotFound, fmt.Errorf("fake rest: no route %s %s", r.Method, r.URL.Path))
+}
+
+// patched is cur with body's top-level fields laid over it, as RunPod
+// applies a REST PATCH or a GraphQL save with an id: a field the body
+// leaves out keeps its stored value. A create passes the zero cur.
+func patched[T any](body io.Reader, cur T) (T, error) {
+	var out T
+	merged := map[string]json.RawMessage{}
+	b, err := json.Marshal(cur)
+	if err == nil {
+		err = json.Unmarshal(b, &merged)
+	}
+	if err != nil {
+		return out, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&fields); err != nil {
+		return out, err
+	}
+	maps.Copy(merged, fields)
+	if b, err = json.Marshal(merged); err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func restTemplateOf(t Template) restTemplate {
+	env := map[string]string{}
+	for _, e := range t.Env {
+		env[e.Key] = e.Value
+	}
+	serverless := t.IsServerless
+	return restTemplate{ID: t.ID, Name: t.Name, ImageName: t.ImageName,
+		ContainerDiskInGb: t.ContainerDiskGB, Env: env, IsServerless: &serverless,
+		ContainerRegistryAuthID: clearable(t.RegistryAuthID, t.RegistryAuthID == "", false)}
+}
+
+func restEndpointOf(e storedEndpoint) restEndpoint {
+	cuda, _ := cudaVersionsFrom(e.MinCuda)
+	return restEndpoint{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID, ComputeType: "GPU",
+		GPUTypeIDs: e.GPUs, WorkersMin: e.WorkersMin, WorkersMax: e.WorkersMax,
+		IdleTimeout: e.IdleTimeout, Flashboot: e.Flashboot,
+		AllowedCudaVersions: clearable(cuda, len(cuda) == 0, false),
+		ExecutionTimeoutMs:  clearable(e.ExecutionTimeoutMs, e.ExecutionTimeoutMs == 0, false),
+		ScalerType:          e.ScalerType, ScalerValue: e.ScalerValue, NetworkVolumeID: e.NetworkVolumeID,
+		DataCenterIDs: clearable(e.DataCenters, len(e.DataCenters) == 0, false)}
+}
+
+// templateInputOf and endpointInputOf are a stored resource as the
+// GraphQL inputs spell it, for patched. gpuIds is left out: the
+// schema requires it, so every save sends it.
+func templateInputOf(t Template) templateInput {
+	return templateInput{ID: t.ID, Name: t.Name, ImageName: t.ImageName,
+		ContainerDiskInGb: t.ContainerDiskGB, IsServerless: t.IsServerless,
+		ContainerRegistryAuthID: t.RegistryAuthID, Env: t.Env}
+}
+
+func endpointInputOf(e storedEndpoint) endpointInput {
+	flashBoot := "OFF"
+	if e.Flashboot {
+		flashBoot = "FLASHBOOT"
+	}
+	return endpointInput{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID,
+		WorkersMin: e.WorkersMin, WorkersMax: e.WorkersMax, IdleTimeout: e.IdleTimeout,
+		FlashBootType: flashBoot, ScalerType: e.ScalerType, ScalerValue: e.ScalerValue,
+		MinCudaVersion: e.MinCuda, ExecutionTimeoutMs: e.ExecutionTimeoutMs,
+		Locations: strings.Join(e.DataCenters, ","), NetworkVolumeID: e.NetworkVolumeID}
+}
+
+func templateWithID(all []Template, id string) *Template {
+	for i := range all {
+		if all[i].ID == id {
+			return &all[i]
+		}
+	}
+	return nil
+}
+
+func (a *fakeAccount) endpointWithID(id string) *storedEndpoint {
+	for i := range a.endpoints {
+		if a.endpoints[i].ID == id {
+			return &a.endpoints[i]
+		}
+	}
+	return nil
+}
+
+// nonEmpty normalizes an empty list to nil, as the account stores it.
+func nonEmpty(l []string) []string {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// withBackend serves acct through api's fake and returns that
+// backend's Admin.
+func withBackend(t *testing.T, api string, acct *fakeAccount) Admin {
+	t.Helper()
+	var target *string
+	var h http.Handler
+	switch api {
+	case APIGraphQL:
+		target, h = &graphQLURL, acct.graphQLHandler(t)
+	case APIREST:
+		target, h = &restBase, acct.restHandler(t)
+	}
+	srv := httptest.NewServer(h)
+	prev := *target
+	*target = srv.URL
+	t.Cleanup(func() {
+		*target = prev
+		srv.Close()
+	})
+	admin, err := NewAdmin(api, "k", "iosuite/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admin
+}
+
+func TestAdminContract(t *testing.T) {
+	for _, api := range []string{APIGraphQL, APIREST} {
+		t.Run(api, func(t *testing.T) {
+			acct := newFakeAccount()
+			testAdminContract(t, withBackend(t, api, acct), acct)
+		})
+	}
+}
+
+func testAdminContract(t *testing.T, rp Admin, acct *fakeAccount) {
+	ctx := context.Background()
+
+	if tmpl, err := rp.FindTemplate(ctx, "esrgan-tmpl"); tmpl != nil || err != nil {
+		t.Fatalf("FindTemplate on an empty account = %+v, %v", tmpl, err)
+	}
+	auth, err := rp.FindRegistryAuth(ctx, "ghcr")
+	if err != nil || auth.ID != "cred1" {
+		t.Fatalf("FindRegistryAuth = %+v, %v", auth, err)
+	}
+	if _, err := rp.FindRegistryAuth(ctx, "nope"); err == nil {
+		t.Error("FindRegistryAuth(nope): expected an error")
+	}
+	vol, err := rp.FindNetworkVolume(ctx, "weights")
+	if err != nil || vol.ID != "vol1" || vol.DataCenterID != "EU-RO-1" {
+		t.Fatalf("FindNetworkVolume = %+v, %v", vol, err)
+	}
+
+	// Create.
+	env := []EnvVar{{Key: "A_LOG", Value: "info"}, {Key: "B_NOTE", Value: "line\n\"quoted\" ✓"}}
+	tmplID, err := rp.SaveTemplate(ctx, SaveTemplateInput{Name: "esrgan-tmpl", Image: "img:1", Env: env, RegistryAuthID: auth.ID})
+	if err != nil {
+		t.Fatalf("SaveTemplate create: %v", err)
+	}
+	tmpl, err := rp.FindTemplate(ctx, "esrgan-tmpl")
+	if err != nil || tmpl == nil {
+		t.Fatalf("FindTemplate after create = %+v, %v", tmpl, err)
+	}
+	want := Template{ID: tmplID, Name: "esrgan-tmpl", ImageName: "img:1", ContainerDiskGB: DefaultContainerDiskGB,
+		Env: env, RegistryAuthID: "cred1", IsServerless: true}
+	if !reflect.DeepEqual(*tmpl, want) {
+		t.Errorf("template =\n  %+v\nwant\n  %+v", *tmpl, want)
+	}
+	epID, err := rp.SaveEndpoint(ctx, SaveEndpointInput{Name: "esrgan", TemplateID: tmplID, GPUPool: "ADA_24", Flashboot: true})
+	if err != nil {
+		t.Fatalf("SaveEndpoint create: %v", err)
+	}
+	if ep, err := rp.FindEndpoint(ctx, "esrgan"); err != nil || ep == nil || *ep != (Endpoint{ID: epID, Name: "esrgan", TemplateID: tmplID}) {
+		t.Errorf("FindEndpoint after create = %+v, %v", ep, err)
+	}
+	wantEP := storedEndpoint{ID: epID, Name: "esrgan", TemplateID: tmplID, GPUs: []string{"NVIDIA GeForce RTX 4090"},
+		WorkersMax: DefaultWorkersMax, IdleTimeout: DefaultIdleTimeoutS, Flashboot: true,
+		ScalerType: DefaultScalerType, ScalerValue: DefaultScalerValue}
+	if !reflect.DeepEqual(acct.endpoints, []storedEndpoint{wantEP}) {
+		t.Errorf("saved endpoint =\n  %+v\nwant\n  %+v", acct.endpoints, wantEP)
+	}
+
+	// Update in place.
+	id, err := rp.SaveTemplate(ctx, SaveTemplateInput{ExistingID: tmplID, Name: "esrgan-tmpl", Image: "img:2", ContainerDiskGB: 20})
+	if err != nil || id != tmplID {
+		t.Fatalf("SaveTemplate update = %q, %v", id, err)
+	}
+	if all, _ := rp.ListTemplates(ctx); len(all) != 1 || all[0].ImageName != "img:2" || len(all[0].Env) != 0 || all[0].RegistryAuthID != "" {
+		t.Errorf("templates after update = %+v", all)
+	}
+	id, err = rp.SaveEndpoint(ctx, SaveEndpointInput{
+		ExistingID: epID, Name: "esrgan", TemplateID: tmplID, GPUPool: "ADA_24,ADA_48_PRO",
+		WorkersMin: 1, WorkersMax: 3, IdleTimeoutS: 5, MinCudaVersion: "12.8",
+		ScalerType: ScalerRequestCount, ScalerValue: 2, ExecutionTimeoutS: 600,
+		NetworkVolumeID: vol.ID, Locations: "EU-RO-1",
+	})
+	if err != nil || id != epID {
+		t.Fatalf("SaveEndpoint update = %q, %v", id, err)
+	}
+	wantEP = storedEndpoint{ID: epID, Name: "esrgan", TemplateID: tmplID,
+		GPUs:       []string{"NVIDIA GeForce RTX 4090", "NVIDIA L40", "NVIDIA L40S", "NVIDIA RTX 6000 Ada Generation"},
+		WorkersMin: 1, WorkersMax: 3, IdleTimeout: 5, MinCuda: "12.8", ExecutionTimeoutMs: 600000,
+		ScalerType: ScalerRequestCount, ScalerValue: 2, NetworkVolumeID: "vol1", DataCenters: []string{"EU-RO-1"}}
+	if !reflect.DeepEqual(acct.endpoints, []storedEndpoint{wantEP}) {
+		t.Errorf("updated endpoint =\n  %+v\nwant\n  %+v", acct.endpoints, wantEP)
+	}
+
+	// An update that drops optional settings clears them, rather than
+	// leaving the old values behind.
+	if _, err := rp.SaveEndpoint(ctx, SaveEndpointInput{
+		ExistingID: epID, Name: "esrgan", TemplateID: tmplID, GPUPool: "ADA_24", WorkersMax: 3, Flashboot: true,
+	}); err != nil {
+		t.Fatalf("SaveEndpoint clearing update: %v", err)
+	}
+	wantEP = storedEndpoint{ID: epID, Name: "esrgan", TemplateID: tmplID, GPUs: []string{"NVIDIA GeForce RTX 4090"},
+		WorkersMax: 3, IdleTimeout: DefaultIdleTimeoutS, Flashboot: true,
+		ScalerType: DefaultScalerType, ScalerValue: DefaultScalerValue}
+	if !reflect.DeepEqual(acct.endpoints, []storedEndpoint{wantEP}) {
+		t.Errorf("cleared endpoint =\n  %+v\nwant\n  %+v", acct.endpoints, wantEP)
+	}
+
+	// Teardown: the template can't go while the endpoint uses it.
+	if err := rp.DeleteTemplate(ctx, "esrgan-tmpl"); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("DeleteTemplate while in use: err = %v", err)
+	}
+	if err := rp.DeleteEndpoint(ctx, epID); err != nil {
+		t.Fatalf("DeleteEndpoint: %v", err)
+	}
+	if err := rp.DeleteTemplate(ctx, "esrgan-tmpl"); err != nil {
+		t.Fatalf("DeleteTemplate: %v", err)
+	}
+	eps, err := rp.ListEndpoints(ctx)
+	if err != nil || len(eps) != 0 || len(acct.templates) != 0 {
+		t.Errorf("after teardown: endpoints %+v (%v), templates %+v", eps, err, acct.templates)
+	}
+	if err := rp.DeleteEndpoint(ctx, epID); err == nil {
+		t.Error("deleting a missing endpoint should fail")
+	}
+}
+
+func TestRESTClient_RejectsUnknownPoolAndCuda(t *testing.T) {
+	rp := withBackend(t, APIREST, newFakeAccount())
+	ctx := context.Background()
+	if _, err := rp.SaveEndpoint(ctx, SaveEndpointInput{Name: "x", GPUPool: "MYSTERY_99"}); err == nil || !strings.Contains(err.Error(), "--api graphql") {
+		t.Errorf("unknown pool: err = %v", err)
+	}
+	if _, err := rp.SaveEndpoint(ctx, SaveEndpointInput{Name: "x", GPUPool: "ADA_24", MinCudaVersion: "12.85"}); err == nil {
+		t.Error("unknown CUDA version: expected an error")
+	}
+}
+
+func TestNewAdmin(t *testing.T) {
+	for api, want := range map[string]string{"": "*runpod.Client", APIGraphQL: "*runpod.Client", APIREST: "*runpod.RESTClient"} {
+		rp, err := NewAdmin(api, "k", "")
+		if err != nil || fmt.Sprintf("%T", rp) != want {
+			t.Errorf("NewAdmin(%q) = %T, %v; want %s", api, rp, err, want)
+		}
+	}
+	if _, err := NewAdmin("soap", "k", ""); err == nil {
+		t.Error("NewAdmin(soap): expected an error")
+	}
+}
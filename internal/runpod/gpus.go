@@ -0,0 +1,135 @@
+package runpod
+
+import (
+	"context"
+	"sort"
+)
+
+// Stock levels RunPod reports for a GPU type, best first. A type
+// with none available reports no level at all (StockNone).
+const (
+	StockHigh   = "High"
+	StockMedium = "Medium"
+	StockLow    = "Low"
+	StockNone   = "None"
+	// StockUnknown is a pool iosuite has no GPU type list for.
+	StockUnknown = "Unknown"
+)
+
+var stockRank = map[string]int{StockHigh: 3, StockMedium: 2, StockLow: 1}
+
+// GPUType is one row of RunPod's `gpuTypes` catalogue.
+type GPUType struct {
+	ID          string `json:"id"` // e.g. "NVIDIA GeForce RTX 4090"
+	DisplayName string `json:"displayName"`
+	MemoryInGB  int    `json:"memoryInGb"`
+	// SecurePrice is the on-demand secure-cloud rate, USD per GPU
+	// hour. Serverless flex workers bill per second at RunPod's
+	// serverless rate, which tracks it but can be higher.
+	SecurePrice float64 `json:"securePrice"`
+	LowestPrice struct {
+		StockStatus *string `json:"stockStatus"`
+	} `json:"lowestPrice"`
+}
+
+// Stock is the type's stock level, StockNone when RunPod has none.
+func (g GPUType) Stock() string {
+	if s := g.LowestPrice.StockStatus; s != nil && stockRank[*s] > 0 {
+		return *s
+	}
+	return StockNone
+}
+
+// ListGPUTypes returns RunPod's GPU catalogue with current stock for
+// a one-GPU worker.
+func (c *Client) ListGPUTypes(ctx context.Context) ([]GPUType, error) {
+	var out struct {
+		GPUTypes []GPUType `json:"gpuTypes"`
+	}
+	if err := c.query(ctx, `query gpuTypes { gpuTypes {
+		id displayName memoryInGb securePrice
+		lowestPrice(input: { gpuCount: 1 }) { stockStatus }
+	} }`, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.GPUTypes, nil
+}
+
+// PoolGPUTypes returns the GPU type ids in a serverless pool like
+// "ADA_24" (or a comma-separated list of pools), sorted; nil for
+// pools iosuite doesn't know.
+func PoolGPUTypes(pool string) []string {
+	var types []string
+	for _, p := range splitComma(pool) {
+		types = append(types, gpuPools[p]...)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// PoolStock is a pool's stock level: the best of its GPU types',
+// since RunPod schedules a worker on whichever type has capacity.
+// pool may be a comma-separated list, as in SaveEndpointInput.GPUPool.
+// StockUnknown when no pool in it is in iosuite's table.
+func PoolStock(catalogue []GPUType, pool string) string {
+	members := poolMembers(catalogue, pool)
+	if members == nil {
+		return StockUnknown
+	}
+	best := StockNone
+	for _, g := range members {
+		if stockRank[g.Stock()] > stockRank[best] {
+			best = g.Stock()
+		}
+	}
+	return best
+}
+
+// PoolPricePerSecond is the cheapest per-second SecurePrice among the
+// pool's GPU types that are in stock, else among all of them. 0 when
+// the catalogue prices none of them.
+func PoolPricePerSecond(catalogue []GPUType, pool string) float64 {
+	members := poolMembers(catalogue, pool)
+	cheapest := func(inStockOnly bool) float64 {
+		var best float64
+		for _, g := range members {
+			if g.SecurePrice <= 0 || (inStockOnly && g.Stock() == StockNone) {
+				continue
+			}
+			if best == 0 || g.SecurePrice < best {
+				best = g.SecurePrice
+			}
+		}
+		return best
+	}
+	price := cheapest(true)
+	if price == 0 {
+		price = cheapest(false)
+	}
+	return price / 3600
+}
+
+// poolMembers returns the catalogue entries for the GPU types in
+// pool (comma-separated ids allowed); nil when no id is known.
+func poolMembers(catalogue []GPUType, pool string) []GPUType {
+	known := false
+	members := []GPUType{}
+	for _, p := range splitComma(pool) {
+		ids, ok := gpuPools[p]
+		if !ok {
+			continue
+		}
+		known = true
+		for _, g := range catalogue {
+			for _, id := range ids {
+				if g.ID == id {
+					members = append(members, g)
+				}
+			}
+		}
+	}
+	if !known {
+		return nil
+	}
+	return members
+}
@@ -0,0 +1,93 @@
+package runpod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// jobAPIBase is the per-endpoint job API (the second host in the
+// package doc). Package-level var so tests can point Health at an
+// httptest server.
+var jobAPIBase = "https://api.runpod.ai/v2"
+
+// Health is RunPod's per-endpoint /health snapshot: worker counts by
+// state and job counts by phase. Completed / failed are running
+// totals over RunPod's retention window, not since-last-call deltas.
+type Health struct {
+	Jobs    HealthJobs    `json:"jobs"`
+	Workers HealthWorkers `json:"workers"`
+}
+
+// HealthJobs is the `jobs` half of /health.
+type HealthJobs struct {
+	InQueue    int `json:"inQueue"`
+	InProgress int `json:"inProgress"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Retried    int `json:"retried"`
+}
+
+// HealthWorkers is the `workers` half of /health. Ready workers are
+// warm and able to take a job; Idle is the subset with nothing to do.
+type HealthWorkers struct {
+	Idle         int `json:"idle"`
+	Ready        int `json:"ready"`
+	Running      int `json:"running"`
+	Initializing int `json:"initializing"`
+	Throttled    int `json:"throttled"`
+	Unhealthy    int `json:"unhealthy"`
+}
+
+// Health fetches GET /v2/{endpointID}/health. Unlike the GraphQL
+// calls this hits api.runpod.ai, which authenticates with the same
+// API key. A read, so it retries like one.
+func (c *Client) Health(ctx context.Context, endpointID string) (*Health, error) {
+	url := fmt.Sprintf("%s/%s/health", jobAPIBase, endpointID)
+	var body []byte
+	err := c.retry.Do(ctx, true, func(ctx context.Context) error {
+		var err error
+		body, err = c.getHealth(ctx, url, endpointID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var h Health
+	if err := json.Unmarshal(body, &h); err != nil {
+		return nil, fmt.Errorf("runpod /health: parse: %w (body: %s)", err, truncate(string(body), 200))
+	}
+	return &h, nil
+}
+
+func (c *Client) getHealth(ctx context.Context, url, endpointID string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("runpod /health: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("runpod /health: read body: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return body, nil
+	case isCloudflareBlock(resp, body):
+		return nil, responseError("/health", c.userAgent, resp, body)
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("runpod /health: HTTP %d (check RUNPOD_API_KEY)", resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("runpod /health: endpoint %q not found", endpointID)
+	default:
+		return nil, responseError("/health", c.userAgent, resp, body)
+	}
+}
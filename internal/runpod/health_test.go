@@ -0,0 +1,55 @@
+package runpod
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func withJobAPI(t *testing.T, h http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	prev := jobAPIBase
+	jobAPIBase = srv.URL
+	t.Cleanup(func() {
+		jobAPIBase = prev
+		srv.Close()
+	})
+}
+
+func TestHealth_DecodesWorkersAndJobs(t *testing.T) {
+	withJobAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ep1/health" {
+			t.Errorf("path = %s, want /ep1/health", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer k" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"jobs":{"completed":12,"failed":1,"inProgress":2,"inQueue":3,"retried":0},
+			"workers":{"idle":1,"initializing":2,"ready":1,"running":2,"throttled":4,"unhealthy":0}}`))
+	})
+
+	h, err := NewClient("k", "").Health(context.Background(), "ep1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Health{
+		Jobs:    HealthJobs{InQueue: 3, InProgress: 2, Completed: 12, Failed: 1},
+		Workers: HealthWorkers{Idle: 1, Ready: 1, Running: 2, Initializing: 2, Throttled: 4},
+	}
+	if *h != want {
+		t.Errorf("Health = %+v, want %+v", *h, want)
+	}
+}
+
+func TestHealth_NotFound(t *testing.T) {
+	withJobAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	_, err := NewClient("k", "").Health(context.Background(), "nope")
+	if err == nil || !strings.Contains(err.Error(), `"nope" not found`) {
+		t.Fatalf("expected not-found error naming the endpoint, got %v", err)
+	}
+}
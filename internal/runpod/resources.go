@@ -0,0 +1,103 @@
+package runpod
+
+import (
+	"context"
+	"fmt"
+)
+
+// RegistryAuth is a saved container registry credential. Templates
+// reference it by id to pull private images; only the name is
+// readable back, never the secret.
+type RegistryAuth struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ListRegistryAuths returns the account's registry credentials.
+func (c *Client) ListRegistryAuths(ctx context.Context) ([]RegistryAuth, error) {
+	var out struct {
+		Myself struct {
+			ContainerRegistryCreds []RegistryAuth `json:"containerRegistryCreds"`
+		} `json:"myself"`
+	}
+	if err := c.query(ctx, `query listRegistryAuths { myself { containerRegistryCreds { id name } } }`, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Myself.ContainerRegistryCreds, nil
+}
+
+// FindRegistryAuth returns the credential with the given name, or an
+// error listing the ones that exist.
+func (c *Client) FindRegistryAuth(ctx context.Context, name string) (*RegistryAuth, error) {
+	all, err := c.ListRegistryAuths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchRegistryAuth(all, name)
+}
+
+func matchRegistryAuth(all []RegistryAuth, name string) (*RegistryAuth, error) {
+	names := make([]string, 0, len(all))
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i], nil
+		}
+		names = append(names, all[i].Name)
+	}
+	return nil, fmt.Errorf("no registry auth named %q on this account (have: %v) — add it under Settings → Container Registry Auth in the RunPod console", name, names)
+}
+
+// NetworkVolume is a persistent volume serverless workers can mount
+// (at /runpod-volume). A volume lives in one data center, and
+// workers that mount it are scheduled there.
+type NetworkVolume struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Size         int    `json:"size"` // GB
+	DataCenterID string `json:"dataCenterId"`
+}
+
+// ListNetworkVolumes returns the account's network volumes.
+func (c *Client) ListNetworkVolumes(ctx context.Context) ([]NetworkVolume, error) {
+	var out struct {
+		Myself struct {
+			NetworkVolumes []NetworkVolume `json:"networkVolumes"`
+		} `json:"myself"`
+	}
+	if err := c.query(ctx, `query listNetworkVolumes { myself { networkVolumes { id name size dataCenterId } } }`, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Myself.NetworkVolumes, nil
+}
+
+// FindNetworkVolume returns the volume whose id or name is ref. Ids
+// win, so a volume can't be shadowed by another named like its id.
+func (c *Client) FindNetworkVolume(ctx context.Context, ref string) (*NetworkVolume, error) {
+	all, err := c.ListNetworkVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchVolume(all, ref)
+}
+
+func matchVolume(all []NetworkVolume, ref string) (*NetworkVolume, error) {
+	for i := range all {
+		if all[i].ID == ref {
+			return &all[i], nil
+		}
+	}
+	var found *NetworkVolume
+	for i := range all {
+		if all[i].Name != ref {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("more than one network volume is named %q — pass its id instead (see `iosuite endpoint volumes`)", ref)
+		}
+		found = &all[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no network volume with id or name %q (see `iosuite endpoint volumes`)", ref)
+	}
+	return found, nil
+}
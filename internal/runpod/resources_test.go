@@ -0,0 +1,37 @@
+package runpod
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchVolume(t *testing.T) {
+	vols := []NetworkVolume{
+		{ID: "vol1", Name: "weights", DataCenterID: "EU-RO-1"},
+		{ID: "vol2", Name: "scratch", DataCenterID: "US-KS-2"},
+		{ID: "vol3", Name: "scratch", DataCenterID: "US-KS-2"},
+		{ID: "vol4", Name: "vol1"},
+	}
+	cases := []struct {
+		ref, wantID, wantErr string
+	}{
+		{ref: "vol2", wantID: "vol2"},
+		{ref: "weights", wantID: "vol1"},
+		{ref: "vol1", wantID: "vol1"}, // id beats a volume named like it
+		{ref: "scratch", wantErr: "more than one"},
+		{ref: "nope", wantErr: "no network volume"},
+	}
+	for _, tc := range cases {
+		got, err := matchVolume(vols, tc.ref)
+		switch {
+		case tc.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tc.ref, err, tc.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tc.ref, err)
+		case got.ID != tc.wantID:
+			t.Errorf("%s: got %s, want %s", tc.ref, got.ID, tc.wantID)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package runpod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"iosuite.io/internal/retry"
+)
+
+// restBase is RunPod's REST admin API. Package-level var so tests
+// can point RESTClient at an httptest server.
+var restBase = "https://rest.runpod.io/v1"
+
+// RESTClient implements Admin against rest.runpod.io, RunPod's newer
+// admin API (plain booleans and lists where GraphQL has enums and
+// comma-joined strings; new features land here first). Construct
+// with NewRESTClient; safe for concurrent use like Client.
+type RESTClient struct {
+	apiKey    string
+	userAgent string
+	http      *http.Client
+	retry     retry.Policy
+}
+
+// NewRESTClient returns a RESTClient using DefaultOptions. See
+// NewClient for apiKey and userAgent.
+func NewRESTClient(apiKey, userAgent string) *RESTClient {
+	return NewRESTClientWithOptions(apiKey, userAgent, DefaultOptions)
+}
+
+// NewRESTClientWithOptions is NewRESTClient with an explicit timeout
+// and retry policy.
+func NewRESTClientWithOptions(apiKey, userAgent string, opts Options) *RESTClient {
+	if userAgent == "" {
+		userAgent = "iosuite/dev"
+	}
+	return &RESTClient{
+		apiKey:    apiKey,
+		userAgent: userAgent,
+		http:      opts.httpClient(),
+		retry:     opts.Retry,
+	}
+}
+
+// do sends body (nil = none) as JSON and decodes a 2xx response into
+// out (nil = discard). GETs retry like GraphQL reads; writes only
+// retry failures that show RunPod never acted on them.
+func (c *RESTClient) do(ctx context.Context, method, path string, body, out any) error {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	var respBody []byte
+	err := c.retry.Do(ctx, method == http.MethodGet, func(ctx context.Context) error {
+		var err error
+		respBody, err = c.send(ctx, method, path, raw)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("runpod rest: %s %s: parse: %w (body: %s)", method, path, err, truncate(string(respBody), 200))
+	}
+	return nil
+}
+
+// send makes one attempt and returns the body of a 2xx response.
+func (c *RESTClient) send(ctx context.Context, method, path string, raw []byte) ([]byte, error) {
+	var rd io.Reader
+	if raw != nil {
+		rd = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, restBase+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if raw != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.userAgent) // see the package doc on Cloudflare
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("runpod rest: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("runpod rest: %s %s: read body: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError("rest: "+method+" "+path, c.userAgent, resp, respBody)
+	}
+	return respBody, nil
+}
+
+// restTemplate is a template on the REST wire. Env is an object
+// there, not a key/value list.
+//
+// Updates are PATCHes, which leave any field the body omits as it
+// was, so every field an update may need to clear is sent even when
+// empty (see clearable).
+type restTemplate struct {
+	ID                      string            `json:"id,omitempty"`
+	Name                    string            `json:"name"`
+	ImageName               string            `json:"imageName"`
+	ContainerDiskInGb       int               `json:"containerDiskInGb"`
+	VolumeInGb              int               `json:"volumeInGb"`
+	Env                     map[string]string `json:"env"`
+	IsServerless            *bool             `json:"isServerless,omitempty"` // create only
+	ContainerRegistryAuthID *string           `json:"containerRegistryAuthId,omitempty"`
+}
+
+func (t restTemplate) template() Template {
+	keys := make([]string, 0, len(t.Env))
+	for k := range t.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]EnvVar, len(keys))
+	for i, k := range keys {
+		env[i] = EnvVar{Key: k, Value: t.Env[k]}
+	}
+	return Template{
+		ID:              t.ID,
+		Name:            t.Name,
+		ImageName:       t.ImageName,
+		ContainerDiskGB: t.ContainerDiskInGb,
+		Env:             env,
+		RegistryAuthID:  deref(t.ContainerRegistryAuthID),
+		IsServerless:    t.IsServerless != nil && *t.IsServerless,
+	}
+}
+
+// restEndpoint is an endpoint on the REST wire. Pointer fields are
+// sent empty on update to clear them, as for restTemplate.
+type restEndpoint struct {
+	ID                  string    `json:"id,omitempty"`
+	Name                string    `json:"name"`
+	TemplateID          string    `json:"templateId"`
+	ComputeType         string    `json:"computeType,omitempty"`
+	GPUTypeIDs          []string  `json:"gpuTypeIds,omitempty"`
+	WorkersMin          int       `json:"workersMin"`
+	WorkersMax          int       `json:"workersMax"`
+	IdleTimeout         int       `json:"idleTimeout"`
+	Flashboot           bool      `json:"flashboot"`
+	AllowedCudaVersions *[]string `json:"allowedCudaVersions,omitempty"`
+	ExecutionTimeoutMs  *int      `json:"executionTimeoutMs,omitempty"`
+	ScalerType          string    `json:"scalerType,omitempty"`
+	ScalerValue         int       `json:"scalerValue,omitempty"`
+	// Always sent: empty detaches a volume on update.
+	NetworkVolumeID string    `json:"networkVolumeId"`
+	DataCenterIDs   *[]string `json:"dataCenterIds,omitempty"`
+}
+
+// clearable is v as an optional REST field: left out of a create when
+// it's empty (RunPod applies its default), but always sent on update,
+// where leaving it out would keep the old value.
+func clearable[T any](v T, empty, update bool) *T {
+	if empty && !update {
+		return nil
+	}
+	return &v
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func (c *RESTClient) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	var eps []restEndpoint
+	if err := c.do(ctx, http.MethodGet, "/endpoints", nil, &eps); err != nil {
+		return nil, err
+	}
+	out := make([]Endpoint, len(eps))
+	for i, e := range eps {
+		out[i] = Endpoint{ID: e.ID, Name: e.Name, TemplateID: e.TemplateID}
+	}
+	return out, nil
+}
+
+func (c *RESTClient) FindEndpoint(ctx context.Context, name string) (*Endpoint, error) {
+	all, err := c.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return endpointNamed(all, name), nil
+}
+
+func (c *RESTClient) ListTemplates(ctx context.Context) ([]Template, error) {
+	var tmpls []restTemplate
+	if err := c.do(ctx, http.MethodGet, "/templates", nil, &tmpls); err != nil {
+		return nil, err
+	}
+	out := make([]Template, len(tmpls))
+	for i, t := range tmpls {
+		out[i] = t.template()
+	}
+	return out, nil
+}
+
+func (c *RESTClient) FindTemplate(ctx context.Context, name string) (*Template, error) {
+	all, err := c.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return templateNamed(all, name), nil
+}
+
+// SaveTemplate creates (POST) or updates (PATCH, when ExistingID is
+// set) a serverless template, with the same defaults as
+// Client.SaveTemplate.
+func (c *RESTClient) SaveTemplate(ctx context.Context, in SaveTemplateInput) (string, error) {
+	if in.ContainerDiskGB == 0 {
+		in.ContainerDiskGB = DefaultContainerDiskGB
+	}
+	update := in.ExistingID != ""
+	body := restTemplate{
+		Name:                    in.Name,
+		ImageName:               in.Image,
+		ContainerDiskInGb:       in.ContainerDiskGB,
+		Env:                     make(map[string]string, len(in.Env)),
+		ContainerRegistryAuthID: clearable(in.RegistryAuthID, in.RegistryAuthID == "", update),
+	}
+	for _, e := range in.Env {
+		body.Env[e.Key] = e.Value
+	}
+	method, path := http.MethodPost, "/templates"
+	if update {
+		method, path = http.MethodPatch, "/templates/"+in.ExistingID
+	} else {
+		serverless := true
+		body.IsServerless = &serverless
+	}
+	var out restTemplate
+	if err := c.do(ctx, method, path, body, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+// SaveEndpoint creates (POST) or updates (PATCH) an endpoint, with
+// the same defaults as Client.SaveEndpoint. The GraphQL inputs are
+// translated: GPU pools to the GPU types they contain, the minimum
+// CUDA version to the list of versions at or above it, and the
+// comma-separated locations to a list.
+func (c *RESTClient) SaveEndpoint(ctx context.Context, in SaveEndpointInput) (string, error) {
+	if in.IdleTimeoutS == 0 {
+		in.IdleTimeoutS = DefaultIdleTimeoutS
+	}
+	if in.WorkersMax == 0 {
+		in.WorkersMax = DefaultWorkersMax
+	}
+	if in.ScalerType == "" {
+		in.ScalerType = DefaultScalerType
+	}
+	if in.ScalerValue == 0 {
+		in.ScalerValue = DefaultScalerValue
+	}
+	gpus, err := poolGPUTypes(in.GPUPool)
+	if err != nil {
+		return "", err
+	}
+	cuda, err := cudaVersionsFrom(in.MinCudaVersion)
+	if err != nil {
+		return "", err
+	}
+	update := in.ExistingID != ""
+	if cuda == nil {
+		cuda = []string{}
+	}
+	dcs := splitComma(in.Locations)
+	if dcs == nil {
+		dcs = []string{}
+	}
+	body := restEndpoint{
+		Name:                in.Name,
+		TemplateID:          in.TemplateID,
+		ComputeType:         "GPU",
+		GPUTypeIDs:          gpus,
+		WorkersMin:          in.WorkersMin,
+		WorkersMax:          in.WorkersMax,
+		IdleTimeout:         in.IdleTimeoutS,
+		Flashboot:           in.Flashboot,
+		AllowedCudaVersions: clearable(cuda, len(cuda) == 0, update),
+		ExecutionTimeoutMs:  clearable(in.ExecutionTimeoutS*1000, in.ExecutionTimeoutS == 0, update),
+		ScalerType:          in.ScalerType,
+		ScalerValue:         in.ScalerValue,
+		NetworkVolumeID:     in.NetworkVolumeID,
+		DataCenterIDs:       clearable(dcs, len(dcs) == 0, update),
+	}
+	method, path := http.MethodPost, "/endpoints"
+	if update {
+		method, path = http.MethodPatch, "/endpoints/"+in.ExistingID
+	}
+	var out restEndpoint
+	if err := c.do(ctx, method, path, body, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+func (c *RESTClient) DeleteEndpoint(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/endpoints/"+id, nil, nil)
+}
+
+// DeleteTemplate removes the template with the given name. REST
+// deletes by id, so this looks the name up first.
+func (c *RESTClient) DeleteTemplate(ctx context.Context, name string) error {
+	t, err := c.FindTemplate(ctx, name)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("runpod rest: no template named %q", name)
+	}
+	return c.do(ctx, http.MethodDelete, "/templates/"+t.ID, nil, nil)
+}
+
+func (c *RESTClient) FindRegistryAuth(ctx context.Context, name string) (*RegistryAuth, error) {
+	var all []RegistryAuth
+	if err := c.do(ctx, http.MethodGet, "/containerregistryauth", nil, &all); err != nil {
+		return nil, err
+	}
+	return matchRegistryAuth(all, name)
+}
+
+func (c *RESTClient) FindNetworkVolume(ctx context.Context, ref string) (*NetworkVolume, error) {
+	var all []NetworkVolume
+	if err := c.do(ctx, http.MethodGet, "/networkvolumes", nil, &all); err != nil {
+		return nil, err
+	}
+	return matchVolume(all, ref)
+}
+
+// gpuPools is the GPU types in each RunPod serverless pool, as the
+// console's GPU configuration lists them. GraphQL takes pool ids;
+// REST only takes GPU type ids, and PoolStock reads stock per type.
+// Extend when a manifest names a new pool.
+var gpuPools = map[string][]string{
+	"AMPERE_16":  {"NVIDIA RTX A4000", "NVIDIA RTX A4500", "NVIDIA RTX 4000 Ada Generation", "NVIDIA RTX 2000 Ada Generation"},
+	"AMPERE_24":  {"NVIDIA RTX A5000", "NVIDIA L4", "NVIDIA GeForce RTX 3090"},
+	"ADA_24":     {"NVIDIA GeForce RTX 4090"},
+	"ADA_32_PRO": {"NVIDIA GeForce RTX 5090"},
+	"AMPERE_48":  {"NVIDIA A40", "NVIDIA RTX A6000"},
+	"ADA_48_PRO": {"NVIDIA L40", "NVIDIA L40S", "NVIDIA RTX 6000 Ada Generation"},
+	"AMPERE_80":  {"NVIDIA A100 80GB PCIe", "NVIDIA A100-SXM4-80GB"},
+	"ADA_80_PRO": {"NVIDIA H100 PCIe", "NVIDIA H100 80GB HBM3", "NVIDIA H100 NVL"},
+	"HOPPER_141": {"NVIDIA H200"},
+}
+
+// poolGPUTypes expands comma-separated pool ids to GPU type ids.
+func poolGPUTypes(pools string) ([]string, error) {
+	var out []string
+	for _, p := range splitComma(pools) {
+		types, ok := gpuPools[p]
+		if !ok {
+			return nil, fmt.Errorf("GPU pool %q has no known GPU types for the REST API — use --api graphql", p)
+		}
+		out = append(out, types...)
+	}
+	return out, nil
+}
+
+// cudaVersions are the values RunPod accepts for allowedCudaVersions,
+// oldest first.
+var cudaVersions = []string{"11.8", "12.0", "12.1", "12.2", "12.3", "12.4", "12.5", "12.6", "12.7", "12.8", "12.9", "13.0"}
+
+// cudaVersionsFrom returns the versions at or above min; nil (no
+// filter) for "".
+func cudaVersionsFrom(min string) ([]string, error) {
+	if min == "" {
+		return nil, nil
+	}
+	for i, v := range cudaVersions {
+		if v == min {
+			return append([]string(nil), cudaVersions[i:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("min CUDA version %q is not one RunPod accepts (%s)", min, strings.Join(cudaVersions, ", "))
+}
+
+func splitComma(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package runpod
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"iosuite.io/internal/retry"
+)
+
+// DefaultTimeout bounds one HTTP request to RunPod (connect, send,
+// and read the whole response) when Options.Timeout is zero.
+const DefaultTimeout = 60 * time.Second
+
+// Options tune the HTTP behaviour Client and RESTClient share.
+type Options struct {
+	// Timeout bounds each attempt. 0 = DefaultTimeout.
+	Timeout time.Duration
+	// Retry governs retries of rate limits (429, honouring
+	// Retry-After), gateway errors and dropped connections. Reads
+	// also retry 500s and timeouts; writes only retry failures that
+	// prove the request never landed (see package retry). The zero
+	// value is retry's defaults.
+	Retry retry.Policy
+}
+
+// DefaultOptions is what NewClient and NewRESTClient use. The CLI
+// sets it once at startup from config and env.
+var DefaultOptions Options
+
+func (o Options) httpClient() *http.Client {
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// ErrCloudflareBlocked is wrapped by the error for a request
+// Cloudflare's edge rejected before it reached RunPod.
+var ErrCloudflareBlocked = errors.New("blocked by Cloudflare")
+
+// responseError turns a non-2xx response whose body has been read
+// into an error. A Cloudflare block gets an actionable message;
+// anything else is a *retry.HTTPError, so the status code and
+// Retry-After drive the retry decision. api names the API in the
+// message ("graphql", "rest", "/health").
+func responseError(api, userAgent string, resp *http.Response, body []byte) error {
+	if isCloudflareBlock(resp, body) {
+		ray := resp.Header.Get("Cf-Ray")
+		if ray == "" {
+			ray = "unknown"
+		}
+		return fmt.Errorf("runpod %s: %w (error 1020, HTTP %d; Ray ID %s). "+
+			"Cloudflare in front of RunPod refused this client before RunPod saw the request. "+
+			"It blocks generic HTTP client User-Agents; this request sent %q. "+
+			"If a proxy rewrites User-Agent, bypass it. Otherwise the network you're on may be blocked "+
+			"(VPN or cloud egress IP): retry from another network, or give RunPod support the Ray ID",
+			api, ErrCloudflareBlocked, resp.StatusCode, ray, userAgent)
+	}
+	return retry.NewHTTPError(api, resp, truncate(string(body), 400))
+}
+
+// isCloudflareBlock recognises Cloudflare's "Access denied / error
+// code: 1020" page: a 403 from Cloudflare's edge (HTML, not the
+// JSON RunPod's servers return) that mentions 1020.
+func isCloudflareBlock(resp *http.Response, body []byte) bool {
+	if resp.StatusCode != http.StatusForbidden || !bytes.Contains(body, []byte("1020")) {
+		return false
+	}
+	return strings.EqualFold(resp.Header.Get("Server"), "cloudflare") ||
+		resp.Header.Get("Cf-Ray") != "" ||
+		bytes.Contains(bytes.ToLower(body), []byte("cloudflare"))
+}
@@ -0,0 +1,185 @@
+package runpod
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/retry"
+)
+
+// fastRetry retries immediately so tests don't sleep through backoff.
+var fastRetry = Options{Retry: retry.Policy{Initial: time.Millisecond, Jitter: -1}}
+
+// withStatusSequence points the GraphQL and REST clients at a server
+// that answers with statuses in turn (the last one repeats) and
+// returns the request count.
+func withStatusSequence(t *testing.T, h http.Header, statuses ...int) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(statuses) {
+			n = len(statuses) - 1
+		}
+		for k, v := range h {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[n])
+		if statuses[n] == http.StatusOK {
+			_, _ = w.Write([]byte(`{"data":{"myself":{"endpoints":[]}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"error":"try later"}`))
+	}))
+	prevGQL, prevREST := graphQLURL, restBase
+	graphQLURL, restBase = srv.URL, srv.URL
+	t.Cleanup(func() {
+		graphQLURL, restBase = prevGQL, prevREST
+		srv.Close()
+	})
+	return &calls
+}
+
+func TestQuery_RetriesTransientFailures(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusInternalServerError} {
+		calls := withStatusSequence(t, nil, status, status, http.StatusOK)
+		c := NewClientWithOptions("k", "iosuite/test", fastRetry)
+		if _, err := c.ListEndpoints(context.Background()); err != nil {
+			t.Fatalf("HTTP %d: %v", status, err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("HTTP %d: %d requests, want 3", status, got)
+		}
+	}
+}
+
+func TestQuery_HonoursRetryAfter(t *testing.T) {
+	calls := withStatusSequence(t, http.Header{"Retry-After": {"120"}}, http.StatusTooManyRequests, http.StatusOK)
+	opts := fastRetry
+	opts.Retry.Budget = 5 * time.Second
+	// Waiting 120 s would blow the budget, so a client that honours
+	// Retry-After gives up instead of retrying after 1 ms.
+	_, err := NewClientWithOptions("k", "iosuite/test", opts).ListEndpoints(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Fatalf("err = %v, want retry budget exhausted", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestQuery_MutationNotRetriedOn500(t *testing.T) {
+	calls := withStatusSequence(t, nil, http.StatusInternalServerError, http.StatusOK)
+	err := NewClientWithOptions("k", "iosuite/test", fastRetry).DeleteEndpoint(context.Background(), "ep1")
+	if err == nil || !strings.Contains(err.Error(), "runpod graphql: HTTP 500") {
+		t.Fatalf("err = %v, want HTTP 500", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1 (a 500 may have deleted it)", got)
+	}
+}
+
+func TestQuery_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := withStatusSequence(t, nil, http.StatusServiceUnavailable)
+	opts := fastRetry
+	opts.Retry.MaxAttempts = 3
+	_, err := NewClientWithOptions("k", "iosuite/test", opts).ListEndpoints(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("%d requests, want 3", got)
+	}
+}
+
+func TestQuery_CloudflareBlock(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Server", "cloudflare")
+		w.Header().Set("Cf-Ray", "8a1b2c3d4e5f-AMS")
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<!DOCTYPE html><title>Access denied | api.runpod.io used Cloudflare to restrict access</title>
+<h1>Error 1020</h1>`))
+	}))
+	prev := graphQLURL
+	graphQLURL = srv.URL
+	t.Cleanup(func() {
+		graphQLURL = prev
+		srv.Close()
+	})
+
+	_, err := NewClientWithOptions("k", "iosuite/test", fastRetry).ListEndpoints(context.Background())
+	if !errors.Is(err, ErrCloudflareBlocked) {
+		t.Fatalf("err = %v, want ErrCloudflareBlocked", err)
+	}
+	for _, want := range []string{"8a1b2c3d4e5f-AMS", `"iosuite/test"`, "proxy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "<h1>") {
+		t.Errorf("error dumps the HTML page: %q", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1 (a block isn't transient)", got)
+	}
+}
+
+func TestIsCloudflareBlock_IgnoresRunPod403(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	if isCloudflareBlock(resp, []byte(`{"errors":[{"message":"forbidden"}]}`)) {
+		t.Error("RunPod's own 403 reported as a Cloudflare block")
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	prev := graphQLURL
+	graphQLURL = srv.URL
+	t.Cleanup(func() {
+		graphQLURL = prev
+		close(release)
+		srv.Close()
+	})
+
+	opts := Options{Timeout: 50 * time.Millisecond, Retry: retry.Policy{MaxAttempts: 1}}
+	start := time.Now()
+	_, err := NewClientWithOptions("k", "iosuite/test", opts).ListEndpoints(context.Background())
+	if err == nil {
+		t.Fatal("want timeout error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %s; Timeout not applied", d)
+	}
+}
+
+func TestREST_RetriesReadsNotWrites(t *testing.T) {
+	calls := withStatusSequence(t, nil, http.StatusInternalServerError, http.StatusOK)
+	c := NewRESTClientWithOptions("k", "iosuite/test", fastRetry)
+	if err := c.do(context.Background(), http.MethodGet, "/endpoints", nil, nil); err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("GET: %d requests, want 2", got)
+	}
+
+	calls = withStatusSequence(t, nil, http.StatusInternalServerError, http.StatusOK)
+	err := c.do(context.Background(), http.MethodPost, "/endpoints", map[string]string{"name": "x"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "runpod rest: POST /endpoints: HTTP 500") {
+		t.Fatalf("POST err = %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("POST: %d requests, want 1", got)
+	}
+}
@@ -21,19 +21,44 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"iosuite.io/internal/retry"
 )
 
 // RunPodProviderOptions configures the upstream connection.
 type RunPodProviderOptions struct {
 	// EndpointID is the RunPod serverless endpoint id (e.g.
-	// `l03sfgxn8crha1`). Required.
+	// `l03sfgxn8crha1`). Required unless Routes is set; with Routes
+	// it's the fallback for requests no route matches.
 	EndpointID string
 
+	// Routes maps a model or tool name to the endpoint that serves
+	// it, for daemons fronting several endpoints (one per tool or GPU
+	// class). Each request is routed on its envelope's `input.model`,
+	// then `input.tool`. Values are endpoint ids, or `name:<endpoint
+	// name>` to look the id up via ResolveName at Start.
+	Routes map[string]string
+
+	// ResolveName turns an endpoint name into its id for `name:`
+	// routes. Wired by the caller (normally runpod.Client.FindEndpoint)
+	// so this package stays free of the GraphQL client.
+	ResolveName func(ctx context.Context, name string) (string, error)
+
 	// APIKey authenticates with RunPod. Required.
 	APIKey string
 
+	// Mode picks the upstream submission path: ModeSync (default)
+	// posts /runsync and polls /status on a cold queue; ModeStream
+	// posts /run and drains /stream/{job}, forwarding each output to
+	// the client as the worker yields it. Stream mode is for outputs
+	// too large to hold whole — 4× PNG batches — and needs a worker
+	// whose handler yields one output per image.
+	Mode string
+
 	// SyncTimeout — how long /runsync waits before falling back to
 	// /run + /status polling. RunPod's server-side cap is 90 s; we
 	// give our own client 30 s of headroom.
@@ -42,19 +67,72 @@ type RunPodProviderOptions struct {
 	// PollMax — longest we poll /status before giving up. Default
 	// 10 m mirrors the iosuite.io client cap.
 	PollMax time.Duration
+
+	// PollInitial — first wait between /status calls. Short by
+	// default (250 ms) so a job that just missed the /runsync window
+	// comes back in well under a second instead of a full tick.
+	PollInitial time.Duration
+
+	// PollMaxInterval caps the exponential backoff between /status
+	// calls. Default 15 s — an 8-minute cold start then costs ~40
+	// polls instead of ~240 at the old fixed 2 s tick.
+	PollMaxInterval time.Duration
+
+	// PollMultiplier is the growth factor applied to the wait after
+	// every poll. Default 1.5.
+	PollMultiplier float64
+
+	// PollJitter randomises each wait by ±PollJitter (a fraction,
+	// clamped to 0..1) so a burst of jobs submitted together doesn't
+	// poll RunPod in lockstep. Default 0.2; negative disables jitter.
+	PollJitter float64
+
+	// PollMaxErrors — consecutive transient /status failures
+	// (network errors, 429, 5xx) tolerated before the job is failed.
+	// Default 5. A single blip no longer throws away a job that's
+	// still running upstream.
+	PollMaxErrors int
+
+	// Retry governs the /runsync (or /run) POST. Only failures that
+	// prove the job was never accepted are retried (see
+	// internal/retry). Each endpoint gets its own breaker, so a dead
+	// endpoint fails its requests fast instead of backing off on
+	// every one, without tripping the endpoints routed beside it.
+	// Retry.Breaker, when set, only supplies their Threshold and
+	// Cooldown.
+	Retry retry.Policy
 }
 
+// Upstream submission modes for RunPodProviderOptions.Mode.
+const (
+	ModeSync   = "sync"
+	ModeStream = "stream"
+)
+
 // RunPodProvider implements Provider against a RunPod endpoint.
 type RunPodProvider struct {
 	opts RunPodProviderOptions
 	http *http.Client
+
+	// routes is opts.Routes with every `name:` value resolved to an
+	// endpoint id. Filled by Start.
+	routes map[string]string
+
+	breakerLimits *retry.Breaker // Threshold and Cooldown for each breaker
+	mu            sync.Mutex
+	breakers      map[string]*retry.Breaker // by endpoint id
 }
 
-const runpodBase = "https://api.runpod.ai/v2"
+// runpodBase is the per-endpoint job API. Package-level var (not a
+// const) so tests can point the provider at an httptest server.
+var runpodBase = "https://api.runpod.ai/v2"
 
 // NewRunPod returns a configured RunPodProvider. Doesn't make any
 // network calls — Start does the auth probe.
 func NewRunPod(opts RunPodProviderOptions) *RunPodProvider {
+	if opts.Mode == "" {
+		opts.Mode = ModeSync
+	}
 	if opts.SyncTimeout == 0 {
 		opts.SyncTimeout = 120 * time.Second
 	}
@@ -66,9 +144,37 @@ func NewRunPod(opts RunPodProviderOptions) *RunPodProvider {
 		// comfortably above it so the bottleneck is RunPod, not us.
 		opts.PollMax = 10 * time.Minute
 	}
+	if opts.PollInitial == 0 {
+		opts.PollInitial = 250 * time.Millisecond
+	}
+	if opts.PollMaxInterval == 0 {
+		opts.PollMaxInterval = 15 * time.Second
+	}
+	if opts.PollMultiplier < 1 {
+		opts.PollMultiplier = 1.5
+	}
+	if opts.PollJitter == 0 {
+		opts.PollJitter = 0.2
+	}
+	opts.PollJitter = min(max(opts.PollJitter, 0), 1)
+	if opts.PollMaxErrors == 0 {
+		opts.PollMaxErrors = 5
+	}
+	if opts.Retry.Breaker == nil {
+		opts.Retry.Breaker = retry.NewBreaker(0, 0)
+	}
+	breakerLimits := opts.Retry.Breaker
+	opts.Retry.Breaker = nil
+	if opts.Retry.OnRetry == nil {
+		opts.Retry.OnRetry = func(attempt int, err error, wait time.Duration) {
+			logf("runpod.post.retry attempt=%d wait=%s err=%q", attempt, wait, err.Error())
+		}
+	}
 	return &RunPodProvider{
-		opts: opts,
-		http: &http.Client{Timeout: opts.SyncTimeout + 30*time.Second},
+		opts:          opts,
+		http:          &http.Client{Timeout: opts.SyncTimeout + 30*time.Second},
+		breakerLimits: breakerLimits,
+		breakers:      map[string]*retry.Breaker{},
 	}
 }
 
@@ -77,14 +183,79 @@ func NewRunPod(opts RunPodProviderOptions) *RunPodProvider {
 // endpoint IDs before we accept user traffic — otherwise the first
 // inbound request would 502 with a confusing runpod-side message.
 func (r *RunPodProvider) Start(ctx context.Context) error {
-	if r.opts.EndpointID == "" {
-		return errors.New("RunPodProvider: EndpointID is required")
+	if r.opts.EndpointID == "" && len(r.opts.Routes) == 0 {
+		return errors.New("RunPodProvider: EndpointID or Routes is required")
 	}
 	if r.opts.APIKey == "" {
 		return errors.New("RunPodProvider: APIKey is required")
 	}
+	if r.opts.Mode != ModeSync && r.opts.Mode != ModeStream {
+		return fmt.Errorf("RunPodProvider: unknown mode %q (expected %s | %s)", r.opts.Mode, ModeSync, ModeStream)
+	}
 
-	url := fmt.Sprintf("%s/%s/health", runpodBase, r.opts.EndpointID)
+	if err := r.resolveRoutes(ctx); err != nil {
+		return err
+	}
+	for _, id := range r.endpoints() {
+		if err := r.probe(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRoutes copies opts.Routes into r.routes, looking up each
+// `name:` value by endpoint name.
+func (r *RunPodProvider) resolveRoutes(ctx context.Context) error {
+	r.routes = make(map[string]string, len(r.opts.Routes))
+	for key, target := range r.opts.Routes {
+		name, byName := strings.CutPrefix(target, "name:")
+		if !byName {
+			if target == "" {
+				return fmt.Errorf("RunPodProvider: route %q has no endpoint", key)
+			}
+			r.routes[key] = target
+			continue
+		}
+		if r.opts.ResolveName == nil {
+			return fmt.Errorf("RunPodProvider: route %q uses name:%s but no ResolveName is configured", key, name)
+		}
+		id, err := r.opts.ResolveName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("RunPodProvider: resolve route %s=name:%s: %w", key, name, err)
+		}
+		logf("runpod.route key=%s endpoint=%s name=%s", key, id, name)
+		r.routes[key] = id
+	}
+	return nil
+}
+
+// endpoints lists every distinct endpoint id the provider may send
+// to, default first, in a stable order.
+func (r *RunPodProvider) endpoints() []string {
+	var ids []string
+	seen := map[string]bool{"": true}
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	add(r.opts.EndpointID)
+	keys := make([]string, 0, len(r.routes))
+	for k := range r.routes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(r.routes[k])
+	}
+	return ids
+}
+
+// probe hits one endpoint's /health.
+func (r *RunPodProvider) probe(ctx context.Context, endpointID string) error {
+	url := fmt.Sprintf("%s/%s/health", runpodBase, endpointID)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	req.Header.Set("Authorization", "Bearer "+r.opts.APIKey)
 	resp, err := r.http.Do(req)
@@ -100,21 +271,80 @@ func (r *RunPodProvider) Start(ctx context.Context) error {
 		return fmt.Errorf("runpod /health: HTTP %d (check RUNPOD_API_KEY)", resp.StatusCode)
 	}
 	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("runpod /health: endpoint %q not found (check --endpoint-id)", r.opts.EndpointID)
+		return fmt.Errorf("runpod /health: endpoint %q not found (check --endpoint-id / --route)", endpointID)
 	}
 	return fmt.Errorf("runpod /health: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 }
 
+// route picks the endpoint for one request body. Without Routes
+// that's always EndpointID. With Routes, `input.model` is looked up
+// first, then `input.tool`. Only a request naming neither goes to
+// EndpointID: one asking for a model or tool nobody serves fails
+// rather than landing on a worker that can't run it. Routing
+// failures are RequestErrors (400), since the request, not the
+// upstream, is at fault.
+func (r *RunPodProvider) route(requestBody []byte) (string, error) {
+	if len(r.routes) == 0 {
+		return r.opts.EndpointID, nil
+	}
+	var env struct {
+		Input struct {
+			Model string `json:"model"`
+			Tool  string `json:"tool"`
+		} `json:"input"`
+	}
+	// The HTTP layer already validated the envelope; a non-object
+	// input just routes like one with no model/tool.
+	_ = json.Unmarshal(requestBody, &env)
+	for _, key := range []string{env.Input.Model, env.Input.Tool} {
+		if id, ok := r.routes[key]; ok && key != "" {
+			return id, nil
+		}
+	}
+	if r.opts.EndpointID != "" && env.Input.Model == "" && env.Input.Tool == "" {
+		return r.opts.EndpointID, nil
+	}
+	known := make([]string, 0, len(r.routes))
+	for k := range r.routes {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+	switch {
+	case env.Input.Model != "":
+		return "", &RequestError{fmt.Errorf("no RunPod endpoint routed for model %q (routes: %s)", env.Input.Model, strings.Join(known, ", "))}
+	case env.Input.Tool != "":
+		return "", &RequestError{fmt.Errorf("no RunPod endpoint routed for tool %q (routes: %s)", env.Input.Tool, strings.Join(known, ", "))}
+	}
+	return "", &RequestError{fmt.Errorf(`request names no model or tool and there is no default endpoint; set "input.model" or "input.tool" (routes: %s)`, strings.Join(known, ", "))}
+}
+
+// breaker returns endpointID's circuit breaker, creating it on first
+// use.
+func (r *RunPodProvider) breaker(endpointID string) *retry.Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[endpointID]
+	if !ok {
+		b = retry.NewBreaker(r.breakerLimits.Threshold, r.breakerLimits.Cooldown)
+		r.breakers[endpointID] = b
+	}
+	return b
+}
+
 // Run forwards the raw request body to RunPod /runsync and returns
 // the raw response body. Pass-through; iosuite doesn't interpret
 // the inner contents. Status polling for queued/in-progress jobs
 // happens internally so the caller sees a single round-trip.
 func (r *RunPodProvider) Run(ctx context.Context, requestBody []byte) ([]byte, error) {
 	start := time.Now()
-	syncURL := fmt.Sprintf("%s/%s/runsync", runpodBase, r.opts.EndpointID)
-	logf("runpod.runsync.post bytes=%d", len(requestBody))
+	endpointID, err := r.route(requestBody)
+	if err != nil {
+		return nil, err
+	}
+	syncURL := fmt.Sprintf("%s/%s/runsync", runpodBase, endpointID)
+	logf("runpod.runsync.post endpoint=%s bytes=%d", endpointID, len(requestBody))
 
-	respBody, err := r.post(ctx, syncURL, requestBody)
+	respBody, err := r.post(ctx, endpointID, syncURL, requestBody)
 	if err != nil {
 		logf("runpod.runsync.err err=%q dur=%s", err.Error(), time.Since(start))
 		return nil, AsProviderError(err)
@@ -129,7 +359,7 @@ func (r *RunPodProvider) Run(ctx context.Context, requestBody []byte) ([]byte, e
 		if jobID == "" {
 			return nil, AsProviderError(fmt.Errorf("runpod %s but no job id in response", status))
 		}
-		respBody, err = r.pollUntilDone(ctx, jobID)
+		respBody, err = r.pollUntilDone(ctx, endpointID, jobID)
 		if err != nil {
 			logf("runpod.poll.err job=%s err=%q dur=%s", jobID, err.Error(), time.Since(start))
 			return nil, AsProviderError(err)
@@ -161,61 +391,116 @@ func peekStatusAndID(body []byte) (status, id string) {
 	return env.Status, env.ID
 }
 
-func (r *RunPodProvider) post(ctx context.Context, url string, body []byte) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+r.opts.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := r.http.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("runpod %s: HTTP %d: %s", url, resp.StatusCode, truncate(string(respBody), 300))
-	}
-	return respBody, nil
-}
-
-func (r *RunPodProvider) pollUntilDone(ctx context.Context, jobID string) ([]byte, error) {
-	statusURL := fmt.Sprintf("%s/%s/status/%s", runpodBase, r.opts.EndpointID, jobID)
-	deadline := time.Now().Add(r.opts.PollMax)
-	tick := time.NewTicker(2 * time.Second)
-	defer tick.Stop()
-	for {
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+// post submits one job (/runsync, or /run in stream mode) to
+// endpointID. A POST creates a job upstream, so the retry policy runs
+// with the non-idempotent rule set.
+func (r *RunPodProvider) post(ctx context.Context, endpointID, url string, body []byte) ([]byte, error) {
+	var respBody []byte
+	policy := r.opts.Retry
+	policy.Breaker = r.breaker(endpointID)
+	err := policy.Do(ctx, false, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
 		req.Header.Set("Authorization", "Bearer "+r.opts.APIKey)
+		req.Header.Set("Content-Type", "application/json")
+		req, sent := retry.Track(req)
 		resp, err := r.http.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("runpod /status: %w", err)
+			return sent(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return sent(err)
 		}
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("runpod /status: HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+			return retry.NewHTTPError(url, resp, truncate(string(b), 300))
 		}
-		status, _ := peekStatusAndID(respBody)
-		switch status {
-		case "COMPLETED":
-			return respBody, nil
-		case "FAILED", "CANCELLED", "TIMED_OUT":
-			return nil, fmt.Errorf("runpod job %s: %s", jobID, status)
+		respBody = b
+		return nil
+	})
+	return respBody, err
+}
+
+// pollUntilDone polls /status until the job reaches a terminal state.
+// The wait between polls starts at PollInitial and grows by
+// PollMultiplier up to PollMaxInterval, with ±PollJitter applied to
+// each wait. Transient failures (network errors, 429, 5xx) are
+// retried up to PollMaxErrors in a row; a Retry-After header on
+// those responses stretches the next wait to at least that long,
+// jitter included.
+func (r *RunPodProvider) pollUntilDone(ctx context.Context, endpointID, jobID string) ([]byte, error) {
+	statusURL := fmt.Sprintf("%s/%s/status/%s", runpodBase, endpointID, jobID)
+	deadline := time.Now().Add(r.opts.PollMax)
+	delay := r.opts.PollInitial
+	failures := 0
+	for {
+		// floor is the upstream's Retry-After, which jitter must not
+		// shorten.
+		wait, floor := delay, time.Duration(0)
+		respBody, err := r.getStatus(ctx, statusURL)
+		if err != nil {
+			if !retry.Retryable(err, true) {
+				return nil, err
+			}
+			failures++
+			if failures >= r.opts.PollMaxErrors {
+				return nil, fmt.Errorf("runpod job %s: giving up after %d consecutive /status failures: %w", jobID, failures, err)
+			}
+			logf("runpod.poll.retry job=%s attempt=%d err=%q", jobID, failures, err.Error())
+			floor = retry.RetryAfter(err)
+		} else {
+			failures = 0
+			status, _ := peekStatusAndID(respBody)
+			switch status {
+			case "COMPLETED":
+				return respBody, nil
+			case "FAILED", "CANCELLED", "TIMED_OUT":
+				return nil, fmt.Errorf("runpod job %s: %s", jobID, status)
+			}
 		}
 		if time.Now().After(deadline) {
 			return nil, fmt.Errorf("runpod job %s still running after %s", jobID, r.opts.PollMax)
 		}
+		wait = max(retry.Jitter(wait, r.opts.PollJitter), floor)
+		if remaining := time.Until(deadline); wait > remaining {
+			// One last poll right at the deadline rather than
+			// sleeping past it and failing without looking.
+			wait = remaining
+		}
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-tick.C:
+		case <-time.After(wait):
 		}
+		delay = retry.Next(delay, r.opts.PollMultiplier, r.opts.PollMaxInterval)
+	}
+}
+
+// getStatus does one GET /status. Failures come back classified for
+// retry.Retryable: upstream non-200s as *retry.HTTPError, transport
+// errors as-is.
+func (r *RunPodProvider) getStatus(ctx context.Context, statusURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Authorization", "Bearer "+r.opts.APIKey)
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("runpod /status: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("runpod /status: read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, retry.NewHTTPError("/status", resp, truncate(string(respBody), 200))
+	}
+	return respBody, nil
 }
 
 // truncate caps a string for inclusion in error messages.
@@ -0,0 +1,294 @@
+// Stream mode — RunPodProvider with Mode: ModeStream.
+//
+// /runsync hands back the whole job output in one body, and /status
+// re-sends all of it on every poll once the job completes. A batch of
+// 4×-upscaled PNGs can run to hundreds of MB, so the daemon briefly
+// held it all twice. Stream mode instead submits via /run and drains
+// /stream/{job}: each call returns the outputs the worker has yielded
+// since the previous call, and we copy each one to the client as soon
+// as it's decoded. Memory use is bounded by the largest single output
+// (one image), not the batch.
+//
+// The client still receives a RunPod-shaped envelope — just written
+// incrementally, with `status` last so it can reflect how the job
+// actually ended:
+//
+//	{"id":"<job>","output":{"outputs":[<item>,<item>,...]},"status":"COMPLETED"}
+//
+// If the job fails after some outputs were forwarded, the HTTP status
+// is already 200; the envelope then closes with "status":"FAILED" and
+// an "error" string, which RunPod-shaped clients already check for.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"iosuite.io/internal/retry"
+)
+
+// maxStreamItem caps one decoded /stream output. A 4× PNG of a
+// 2048² input is ~40 MB base64; 64 MB leaves headroom without
+// letting a misbehaving worker exhaust the daemon.
+const maxStreamItem = 64 * 1024 * 1024
+
+// Streams reports whether Run should be bypassed in favour of
+// RunStream. Implements StreamingProvider.
+func (r *RunPodProvider) Streams() bool { return r.opts.Mode == ModeStream }
+
+// RunStream submits the request body to /run and forwards each
+// /stream output to w as it arrives. Nothing is written to w until
+// the job is accepted upstream, so submission errors still surface
+// as a clean 502.
+func (r *RunPodProvider) RunStream(ctx context.Context, requestBody []byte, w io.Writer) error {
+	start := time.Now()
+	endpointID, err := r.route(requestBody)
+	if err != nil {
+		return err
+	}
+	runURL := fmt.Sprintf("%s/%s/run", runpodBase, endpointID)
+	logf("runpod.run.post endpoint=%s bytes=%d", endpointID, len(requestBody))
+
+	respBody, err := r.post(ctx, endpointID, runURL, requestBody)
+	if err != nil {
+		logf("runpod.run.err err=%q dur=%s", err.Error(), time.Since(start))
+		return AsProviderError(err)
+	}
+	status, jobID := peekStatusAndID(respBody)
+	logf("runpod.run.resp status=%s id=%s dur=%s", status, jobID, time.Since(start))
+	if jobID == "" {
+		return AsProviderError(fmt.Errorf("runpod /run returned no job id (status %q)", status))
+	}
+
+	env := &streamEnvelope{w: w, jobID: jobID}
+	items, err := r.drainStream(ctx, endpointID, jobID, env.item)
+	if err != nil {
+		logf("runpod.stream.err job=%s items=%d err=%q dur=%s", jobID, items, err.Error(), time.Since(start))
+		return env.fail(AsProviderError(err))
+	}
+	logf("runpod.stream.done job=%s items=%d dur=%s", jobID, items, time.Since(start))
+	return env.complete()
+}
+
+// drainStream polls /stream/{job} until the job is terminal, handing
+// each output to emit. Waits follow the same adaptive schedule as
+// pollUntilDone, except that the wait snaps back to PollInitial
+// whenever a call returned outputs — a job that's producing is worth
+// watching closely. Returns how many outputs were emitted.
+func (r *RunPodProvider) drainStream(ctx context.Context, endpointID, jobID string, emit func(json.RawMessage) error) (int, error) {
+	streamURL := fmt.Sprintf("%s/%s/stream/%s", runpodBase, endpointID, jobID)
+	deadline := time.Now().Add(r.opts.PollMax)
+	delay := r.opts.PollInitial
+	failures, total := 0, 0
+	for {
+		// floor is the upstream's Retry-After, which jitter must not
+		// shorten.
+		wait, floor := delay, time.Duration(0)
+		status, n, err := r.getStream(ctx, streamURL, emit)
+		total += n
+		switch {
+		case err != nil && !errors.As(err, new(*streamCutError)) && retry.Retryable(err, true):
+			// RunPod never answered 2xx, so it handed out no outputs
+			// and a retry can't skip or duplicate any.
+			failures++
+			if failures >= r.opts.PollMaxErrors {
+				return total, fmt.Errorf("runpod job %s: giving up after %d consecutive /stream failures: %w", jobID, failures, err)
+			}
+			logf("runpod.stream.retry job=%s attempt=%d err=%q", jobID, failures, err.Error())
+			floor = retry.RetryAfter(err)
+		case err != nil:
+			return total, err
+		default:
+			failures = 0
+			switch status {
+			case "COMPLETED":
+				return total, nil
+			case "FAILED", "CANCELLED", "TIMED_OUT":
+				return total, fmt.Errorf("runpod job %s: %s", jobID, status)
+			}
+			if n > 0 {
+				delay, wait = r.opts.PollInitial, r.opts.PollInitial
+			}
+		}
+		if time.Now().After(deadline) {
+			return total, fmt.Errorf("runpod job %s still running after %s", jobID, r.opts.PollMax)
+		}
+		wait = max(retry.Jitter(wait, r.opts.PollJitter), floor)
+		if remaining := time.Until(deadline); wait > remaining {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay = retry.Next(delay, r.opts.PollMultiplier, r.opts.PollMaxInterval)
+	}
+}
+
+// getStream does one GET /stream/{job}, decoding the body token by
+// token so only one output is in memory at a time. Returns the job
+// status and how many outputs were handed to emit.
+//
+// Body shape: {"status":"IN_PROGRESS","stream":[{"output":<item>},...]}
+func (r *RunPodProvider) getStream(ctx context.Context, streamURL string, emit func(json.RawMessage) error) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.opts.APIKey)
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("runpod /stream: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 400))
+		return "", 0, retry.NewHTTPError("/stream", resp, truncate(string(body), 200))
+	}
+	status, n, err := decodeStream(resp.Body, emit)
+	if err != nil {
+		return status, n, &streamCutError{err: err}
+	}
+	return status, n, nil
+}
+
+// streamCutError is a /stream call that failed after RunPod answered
+// 200. RunPod hands each output out once, so whatever the rest of
+// that body held is gone: retrying would silently skip it, and the
+// job must fail instead.
+type streamCutError struct{ err error }
+
+func (e *streamCutError) Error() string {
+	return e.err.Error() + " (response cut off; outputs in it were lost)"
+}
+
+func (e *streamCutError) Unwrap() error { return e.err }
+
+// decodeStream reads one /stream body token by token, handing each
+// output to emit.
+func decodeStream(body io.Reader, emit func(json.RawMessage) error) (string, int, error) {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", 0, fmt.Errorf("runpod /stream: %w", err)
+	}
+	var status string
+	n := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return status, n, fmt.Errorf("runpod /stream: %w", err)
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "status":
+			if err := dec.Decode(&status); err != nil {
+				return status, n, fmt.Errorf("runpod /stream: status: %w", err)
+			}
+		case "stream":
+			if err := expectDelim(dec, '['); err != nil {
+				return status, n, fmt.Errorf("runpod /stream: stream: %w", err)
+			}
+			for dec.More() {
+				var item struct {
+					Output json.RawMessage `json:"output"`
+				}
+				if err := dec.Decode(&item); err != nil {
+					return status, n, fmt.Errorf("runpod /stream: item %d: %w", n, err)
+				}
+				if len(item.Output) > maxStreamItem {
+					return status, n, fmt.Errorf("runpod /stream: item %d is %d bytes (cap %d)", n, len(item.Output), maxStreamItem)
+				}
+				if len(item.Output) == 0 {
+					continue
+				}
+				if err := emit(item.Output); err != nil {
+					return status, n, err
+				}
+				n++
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return status, n, fmt.Errorf("runpod /stream: stream: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return status, n, fmt.Errorf("runpod /stream: %s: %w", key, err)
+			}
+		}
+	}
+	return status, n, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// streamEnvelope writes the client-facing envelope around the
+// forwarded outputs. The opening bytes are deferred until the first
+// output (or the end of the job) so an error before anything was
+// forwarded can still become a proper HTTP error.
+type streamEnvelope struct {
+	w       io.Writer
+	jobID   string
+	started bool
+	items   int
+}
+
+func (e *streamEnvelope) open() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	id, _ := json.Marshal(e.jobID)
+	_, err := fmt.Fprintf(e.w, `{"id":%s,"output":{"outputs":[`, id)
+	return err
+}
+
+func (e *streamEnvelope) item(raw json.RawMessage) error {
+	if err := e.open(); err != nil {
+		return err
+	}
+	if e.items > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.items++
+	_, err := e.w.Write(raw)
+	return err
+}
+
+func (e *streamEnvelope) complete() error {
+	if err := e.open(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, `]},"status":"COMPLETED"}`)
+	return err
+}
+
+// fail closes the envelope with a FAILED status when outputs were
+// already forwarded, and returns cause either way so the caller can
+// log it (or, if nothing was written, turn it into an HTTP error).
+func (e *streamEnvelope) fail(cause error) error {
+	if !e.started {
+		return cause
+	}
+	msg, _ := json.Marshal(cause.Error())
+	if _, err := fmt.Fprintf(e.w, `]},"status":"FAILED","error":%s}`, msg); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
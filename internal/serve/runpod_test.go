@@ -0,0 +1,453 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iosuite.io/internal/retry"
+)
+
+// fakeRunPod serves /runsync (IN_QUEUE unless runsyncFn overrides
+// it) and hands each /status call to statusFn with a 1-based call
+// counter.
+func fakeRunPod(t *testing.T, statusFn func(n int32, w http.ResponseWriter)) *httptest.Server {
+	return fakeRunPodWith(t, nil, statusFn)
+}
+
+func fakeRunPodWith(t *testing.T, runsyncFn, statusFn func(n int32, w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	var polls, posts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/runsync"):
+			n := atomic.AddInt32(&posts, 1)
+			if runsyncFn != nil {
+				runsyncFn(n, w)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_QUEUE"}`))
+		case strings.Contains(r.URL.Path, "/status/job-1"):
+			statusFn(atomic.AddInt32(&polls, 1), w)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	prev := runpodBase
+	runpodBase = srv.URL
+	t.Cleanup(func() {
+		runpodBase = prev
+		srv.Close()
+	})
+	return srv
+}
+
+func fastPollProvider() *RunPodProvider {
+	return NewRunPod(RunPodProviderOptions{
+		EndpointID:      "ep",
+		APIKey:          "key",
+		PollMax:         5 * time.Second,
+		PollInitial:     time.Millisecond,
+		PollMaxInterval: 5 * time.Millisecond,
+		Retry:           retry.Policy{Initial: time.Millisecond, Max: time.Millisecond},
+	})
+}
+
+func TestRunPodPoll_RetriesTransientStatusFailures(t *testing.T) {
+	fakeRunPod(t, func(n int32, w http.ResponseWriter) {
+		switch n {
+		case 1:
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case 3:
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_PROGRESS"}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"COMPLETED","output":{"ok":true}}`))
+		}
+	})
+
+	body, err := fastPollProvider().Run(context.Background(), []byte(`{"input":{}}`))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(string(body), `"ok":true`) {
+		t.Errorf("body = %s, want the COMPLETED /status payload", body)
+	}
+}
+
+func TestRunPodPoll_HonoursRetryAfterDespiteJitter(t *testing.T) {
+	fakeRunPod(t, func(n int32, w http.ResponseWriter) {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"job-1","status":"COMPLETED","output":{}}`))
+	})
+
+	p := fastPollProvider()
+	p.opts.PollJitter = 1 // as much shortening as jitter can do
+	start := time.Now()
+	if _, err := p.Run(context.Background(), []byte(`{"input":{}}`)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("polled again after %s, want at least the 1s Retry-After", d)
+	}
+}
+
+func TestNewRunPod_ClampsPollJitter(t *testing.T) {
+	for in, want := range map[float64]float64{-1: 0, 0: 0.2, 0.5: 0.5, 3: 1} {
+		if got := NewRunPod(RunPodProviderOptions{PollJitter: in}).opts.PollJitter; got != want {
+			t.Errorf("PollJitter %v → %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestRunPodPoll_FailsFastOnClientError(t *testing.T) {
+	var calls int32
+	fakeRunPod(t, func(n int32, w http.ResponseWriter) {
+		atomic.StoreInt32(&calls, n)
+		http.Error(w, "no such job", http.StatusNotFound)
+	})
+
+	_, err := fastPollProvider().Run(context.Background(), []byte(`{"input":{}}`))
+	if err == nil {
+		t.Fatal("expected error on /status 404")
+	}
+	if !strings.Contains(err.Error(), "404") {
+		t.Errorf("error should carry the upstream status: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("/status calls = %d, want 1 (4xx is not retried)", got)
+	}
+}
+
+func TestRunPodPoll_GivesUpAfterMaxErrors(t *testing.T) {
+	var calls int32
+	fakeRunPod(t, func(n int32, w http.ResponseWriter) {
+		atomic.StoreInt32(&calls, n)
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+
+	p := fastPollProvider()
+	p.opts.PollMaxErrors = 3
+	_, err := p.Run(context.Background(), []byte(`{"input":{}}`))
+	if err == nil || !strings.Contains(err.Error(), "3 consecutive") {
+		t.Fatalf("expected give-up error after 3 failures, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("/status calls = %d, want 3", got)
+	}
+}
+
+func TestRunPodRunsync_RetriesGatewayErrors(t *testing.T) {
+	fakeRunPodWith(t, func(n int32, w http.ResponseWriter) {
+		if n < 3 {
+			http.Error(w, "gateway", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"job-1","status":"COMPLETED","output":{}}`))
+	}, nil)
+
+	if _, err := fastPollProvider().Run(context.Background(), []byte(`{"input":{}}`)); err != nil {
+		t.Fatalf("Run should succeed on the third /runsync attempt: %v", err)
+	}
+}
+
+func TestRunPodRunsync_DoesNotRetryClientErrors(t *testing.T) {
+	var posts int32
+	fakeRunPodWith(t, func(n int32, w http.ResponseWriter) {
+		atomic.StoreInt32(&posts, n)
+		http.Error(w, "bad input", http.StatusBadRequest)
+	}, nil)
+
+	if _, err := fastPollProvider().Run(context.Background(), []byte(`{"input":{}}`)); err == nil {
+		t.Fatal("expected error on 400")
+	}
+	if got := atomic.LoadInt32(&posts); got != 1 {
+		t.Errorf("/runsync attempts = %d, want 1 (4xx is never retried)", got)
+	}
+}
+
+// fakeRunPodStream serves /run (always accepting job-1) and hands
+// each /stream/job-1 call to streamFn with a 1-based call counter.
+func fakeRunPodStream(t *testing.T, streamFn func(n int32, w http.ResponseWriter)) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/run"):
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_QUEUE"}`))
+		case strings.Contains(r.URL.Path, "/stream/job-1"):
+			streamFn(atomic.AddInt32(&calls, 1), w)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	prev := runpodBase
+	runpodBase = srv.URL
+	t.Cleanup(func() {
+		runpodBase = prev
+		srv.Close()
+	})
+}
+
+func fastStreamProvider() *RunPodProvider {
+	p := fastPollProvider()
+	p.opts.Mode = ModeStream
+	return p
+}
+
+func TestRunPodStream_ForwardsOutputsAcrossCalls(t *testing.T) {
+	fakeRunPodStream(t, func(n int32, w http.ResponseWriter) {
+		switch n {
+		case 1:
+			_, _ = w.Write([]byte(`{"status":"IN_QUEUE","stream":[]}`))
+		case 2:
+			_, _ = w.Write([]byte(`{"status":"IN_PROGRESS","stream":[{"output":{"i":0}},{"output":{"i":1}}]}`))
+		case 3:
+			http.Error(w, "gateway", http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"status":"COMPLETED","stream":[{"output":{"i":2}}]}`))
+		}
+	})
+
+	var buf bytes.Buffer
+	if err := fastStreamProvider().RunStream(context.Background(), []byte(`{"input":{}}`), &buf); err != nil {
+		t.Fatalf("RunStream: %v", err)
+	}
+	want := `{"id":"job-1","output":{"outputs":[{"i":0},{"i":1},{"i":2}]},"status":"COMPLETED"}`
+	if buf.String() != want {
+		t.Errorf("body =\n %s\nwant\n %s", buf.String(), want)
+	}
+}
+
+func TestRunPodStream_FailedMidStreamClosesEnvelope(t *testing.T) {
+	fakeRunPodStream(t, func(n int32, w http.ResponseWriter) {
+		if n == 1 {
+			_, _ = w.Write([]byte(`{"status":"IN_PROGRESS","stream":[{"output":{"i":0}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"FAILED","stream":[]}`))
+	})
+
+	var buf bytes.Buffer
+	err := fastStreamProvider().RunStream(context.Background(), []byte(`{"input":{}}`), &buf)
+	if err == nil {
+		t.Fatal("expected error for FAILED job")
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, `{"id":"job-1","output":{"outputs":[{"i":0}]},"status":"FAILED","error":`) {
+		t.Errorf("body = %s, want a FAILED envelope after the forwarded output", got)
+	}
+}
+
+func TestRunPodStream_TruncatedBodyFailsInsteadOfRetrying(t *testing.T) {
+	// The first call's body is cut off inside its first output. RunPod
+	// has already handed that output out, so retrying would drop it
+	// without a trace; the stream must fail instead.
+	var calls int32
+	fakeRunPodStream(t, func(n int32, w http.ResponseWriter) {
+		atomic.StoreInt32(&calls, n)
+		w.Header().Set("Content-Length", "200")
+		_, _ = w.Write([]byte(`{"status":"IN_PROGRESS","stream":[{"output":{"i":`))
+	})
+
+	var buf bytes.Buffer
+	err := fastStreamProvider().RunStream(context.Background(), []byte(`{"input":{}}`), &buf)
+	if err == nil || !strings.Contains(err.Error(), "lost") {
+		t.Fatalf("err = %v, want the cut-off response reported", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("/stream calls = %d, want 1 (a consumed response is never retried)", got)
+	}
+}
+
+func TestRunPodStream_HandlerMapsEarlyFailureTo502(t *testing.T) {
+	fakeRunPodStream(t, func(n int32, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"status":"FAILED","stream":[]}`))
+	})
+
+	srv := newTestServer(t, fastStreamProvider())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/runsync", "application/json", strings.NewReader(`{"input":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		body, _ := io.ReadAll(resp.Body)
+		t.Errorf("status = %d, want 502 (nothing was forwarded yet); body=%s", resp.StatusCode, body)
+	}
+}
+
+func TestRunPodStream_HandlerStreamsBody(t *testing.T) {
+	fakeRunPodStream(t, func(n int32, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"status":"COMPLETED","stream":[{"output":{"i":0}}]}`))
+	})
+
+	srv := newTestServer(t, fastStreamProvider())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/runsync", "application/json", strings.NewReader(`{"input":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d; body=%s", resp.StatusCode, body)
+	}
+	if !strings.Contains(string(body), `"outputs":[{"i":0}]`) || !strings.HasSuffix(string(body), `"status":"COMPLETED"}`) {
+		t.Errorf("body = %s", body)
+	}
+}
+
+// fakeRunPodRouted answers /health and /runsync for any endpoint id,
+// recording which endpoint each /runsync landed on. /runsync on an
+// id starting "dead" answers 503.
+func fakeRunPodRouted(t *testing.T) *[]string {
+	t.Helper()
+	var hits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch parts[len(parts)-1] {
+		case "health":
+			_, _ = w.Write([]byte(`{}`))
+		case "runsync":
+			hits = append(hits, parts[0])
+			if strings.HasPrefix(parts[0], "dead") {
+				http.Error(w, "no workers", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"COMPLETED","output":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	prev := runpodBase
+	runpodBase = srv.URL
+	t.Cleanup(func() {
+		runpodBase = prev
+		srv.Close()
+	})
+	return &hits
+}
+
+func TestRunPodRoutes_PickEndpointFromEnvelope(t *testing.T) {
+	hits := fakeRunPodRouted(t)
+	p := NewRunPod(RunPodProviderOptions{
+		APIKey: "key",
+		Routes: map[string]string{
+			"realesrgan-x4plus": "esrgan-ep",
+			"ffmpeg":            "name:ffmpeg-cpu",
+		},
+		ResolveName: func(_ context.Context, name string) (string, error) {
+			if name != "ffmpeg-cpu" {
+				t.Errorf("ResolveName(%q), want ffmpeg-cpu", name)
+			}
+			return "ffmpeg-ep", nil
+		},
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for _, body := range []string{
+		`{"input":{"model":"realesrgan-x4plus"}}`,
+		`{"input":{"tool":"ffmpeg","op":"reframe"}}`,
+		// model wins over tool when both are mapped
+		`{"input":{"model":"realesrgan-x4plus","tool":"ffmpeg"}}`,
+	} {
+		if _, err := p.Run(context.Background(), []byte(body)); err != nil {
+			t.Fatalf("Run(%s): %v", body, err)
+		}
+	}
+	want := []string{"esrgan-ep", "ffmpeg-ep", "esrgan-ep"}
+	if strings.Join(*hits, ",") != strings.Join(want, ",") {
+		t.Errorf("endpoints hit = %v, want %v", *hits, want)
+	}
+}
+
+func TestRunPodRoutes_UnmappedModel(t *testing.T) {
+	fakeRunPodRouted(t)
+	p := NewRunPod(RunPodProviderOptions{
+		APIKey: "key",
+		Routes: map[string]string{"realesrgan-x4plus": "esrgan-ep"},
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	_, err := p.Run(context.Background(), []byte(`{"input":{"model":"whisper-large"}}`))
+	if err == nil {
+		t.Fatal("expected error for unmapped model")
+	}
+	if !strings.Contains(err.Error(), `"whisper-large"`) || !strings.Contains(err.Error(), "realesrgan-x4plus") {
+		t.Errorf("error should name the model and the known routes: %v", err)
+	}
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		t.Error("unmapped model is a caller error, not a ProviderError")
+	}
+	var rerr *RequestError
+	if !errors.As(err, &rerr) {
+		t.Error("unmapped model should be a RequestError (400)")
+	}
+}
+
+func TestRunPodRoutes_DefaultEndpointOnlyForUnnamed(t *testing.T) {
+	hits := fakeRunPodRouted(t)
+	p := NewRunPod(RunPodProviderOptions{
+		EndpointID: "default-ep",
+		APIKey:     "key",
+		Routes:     map[string]string{"ffmpeg": "ffmpeg-ep"},
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := p.Run(context.Background(), []byte(`{"input":{"op":"probe"}}`)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// A model nobody serves must not land on the default endpoint.
+	_, err := p.Run(context.Background(), []byte(`{"input":{"model":"realesrgan-x4plus"}}`))
+	var rerr *RequestError
+	if !errors.As(err, &rerr) {
+		t.Errorf("unmapped model with a default endpoint: err = %v, want a RequestError", err)
+	}
+	if len(*hits) != 1 || (*hits)[0] != "default-ep" {
+		t.Errorf("endpoints hit = %v, want [default-ep]", *hits)
+	}
+}
+
+func TestRunPodRoutes_BreakerPerEndpoint(t *testing.T) {
+	hits := fakeRunPodRouted(t)
+	p := NewRunPod(RunPodProviderOptions{
+		APIKey: "key",
+		Routes: map[string]string{"broken": "dead-ep", "ffmpeg": "ffmpeg-ep"},
+		Retry:  retry.Policy{MaxAttempts: 1, Breaker: retry.NewBreaker(1, time.Hour)},
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	dead := []byte(`{"input":{"model":"broken"}}`)
+	if _, err := p.Run(context.Background(), dead); err == nil {
+		t.Fatal("dead endpoint succeeded")
+	}
+	if _, err := p.Run(context.Background(), dead); !errors.Is(err, retry.ErrOpen) {
+		t.Errorf("second call to the dead endpoint: err = %v, want the breaker open", err)
+	}
+	if _, err := p.Run(context.Background(), []byte(`{"input":{"tool":"ffmpeg"}}`)); err != nil {
+		t.Errorf("healthy endpoint failed behind another endpoint's breaker: %v", err)
+	}
+	if want := "dead-ep,ffmpeg-ep"; strings.Join(*hits, ",") != want {
+		t.Errorf("endpoints hit = %v, want %s", *hits, want)
+	}
+}
@@ -74,6 +74,24 @@ type Provider interface {
 	Close() error
 }
 
+// StreamingProvider is implemented by providers that can forward a
+// job's outputs to the client while the job is still running (see
+// RunPodProvider's stream mode). When Streams reports true the HTTP
+// layer calls RunStream instead of Run.
+type StreamingProvider interface {
+	Provider
+
+	// Streams reports whether this provider is configured to stream.
+	Streams() bool
+
+	// RunStream executes one job, writing the complete response body
+	// to w as it becomes available. Errors returned before anything
+	// was written map to HTTP statuses exactly like Run's; once bytes
+	// are out the status is committed, so the provider must encode
+	// the failure in the body itself.
+	RunStream(ctx context.Context, requestBody []byte, w io.Writer) error
+}
+
 // Options is the full configuration surface for the daemon.
 type Options struct {
 	// Bind address — "127.0.0.1" by default, "0.0.0.0" to expose to
@@ -206,16 +224,25 @@ func makeJobHandler(p Provider) http.HandlerFunc {
 		}
 		logf("req.dispatch id=%s body_size=%d", reqID, len(body))
 
+		if sp, ok := p.(StreamingProvider); ok && sp.Streams() {
+			fw := &flushWriter{w: w}
+			err := sp.RunStream(r.Context(), body, fw)
+			switch {
+			case err != nil && fw.wrote:
+				// Status line is gone; the provider already closed the
+				// body with a failure envelope.
+				logf("req.stream_err id=%s err=%q bytes=%d dur=%s", reqID, err.Error(), fw.n, time.Since(start))
+			case err != nil:
+				writeProviderErr(w, reqID, start, err)
+			default:
+				logf("req.ok id=%s body_size=%d dur=%s streamed=true", reqID, fw.n, time.Since(start))
+			}
+			return
+		}
+
 		respBody, err := p.Run(r.Context(), body)
 		if err != nil {
-			var perr *ProviderError
-			if errors.As(err, &perr) {
-				logf("req.provider_err id=%s err=%q dur=%s", reqID, perr.Error(), time.Since(start))
-				http.Error(w, perr.Error(), http.StatusBadGateway)
-				return
-			}
-			logf("req.internal_err id=%s err=%q dur=%s", reqID, err.Error(), time.Since(start))
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProviderErr(w, reqID, start, err)
 			return
 		}
 		logf("req.ok id=%s body_size=%d dur=%s", reqID, len(respBody), time.Since(start))
@@ -224,6 +251,50 @@ func makeJobHandler(p Provider) http.HandlerFunc {
 	}
 }
 
+// writeProviderErr maps a provider error to 400 (RequestError), 502
+// (ProviderError) or 500 (anything else) and logs it against the
+// request id.
+func writeProviderErr(w http.ResponseWriter, reqID string, start time.Time, err error) {
+	var rerr *RequestError
+	if errors.As(err, &rerr) {
+		logf("req.rejected id=%s err=%q dur=%s", reqID, rerr.Error(), time.Since(start))
+		http.Error(w, rerr.Error(), http.StatusBadRequest)
+		return
+	}
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		logf("req.provider_err id=%s err=%q dur=%s", reqID, perr.Error(), time.Since(start))
+		http.Error(w, perr.Error(), http.StatusBadGateway)
+		return
+	}
+	logf("req.internal_err id=%s err=%q dur=%s", reqID, err.Error(), time.Since(start))
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// flushWriter commits a 200 JSON response on first write and flushes
+// after every write so streamed outputs reach the client as they're
+// forwarded rather than when Go's response buffer fills.
+type flushWriter struct {
+	w     http.ResponseWriter
+	wrote bool
+	n     int
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	if !f.wrote {
+		f.wrote = true
+		f.w.Header().Set("Content-Type", "application/json")
+		f.w.WriteHeader(http.StatusOK)
+	}
+	n, err := f.w.Write(p)
+	f.n += n
+	if err != nil {
+		return n, err
+	}
+	_ = http.NewResponseController(f.w).Flush()
+	return n, nil
+}
+
 // logf — single-line, space-separated key=value to stderr. Loki's
 // promtail picks it up directly; humans grep it. Keeping the format
 // simple on purpose — JSON would force everything (including the
@@ -246,9 +317,9 @@ func newReqID() string {
 }
 
 // ProviderError marks "the backend is unhealthy / failed our request"
-// so the HTTP layer can map to 502 instead of 500. Caller-facing
-// errors (bad envelope, oversize input) should be returned as
-// ordinary errors → 500 → caller can decode and re-classify.
+// so the HTTP layer can map to 502 instead of 500. Errors the caller
+// can fix should be RequestErrors (400); anything else is returned
+// as an ordinary error → 500.
 type ProviderError struct {
 	Underlying error
 }
@@ -256,6 +327,16 @@ type ProviderError struct {
 func (e *ProviderError) Error() string { return "provider: " + e.Underlying.Error() }
 func (e *ProviderError) Unwrap() error { return e.Underlying }
 
+// RequestError marks a request the provider can't serve as asked,
+// such as one naming a model no endpoint is routed for, so the HTTP
+// layer answers 400 rather than blaming the backend.
+type RequestError struct {
+	Underlying error
+}
+
+func (e *RequestError) Error() string { return e.Underlying.Error() }
+func (e *RequestError) Unwrap() error { return e.Underlying }
+
 // AsProviderError wraps any error with ProviderError so the HTTP
 // layer renders it as 502.
 func AsProviderError(err error) error {
@@ -138,6 +138,26 @@ func TestRunsync_ProviderErrorMapsTo502(t *testing.T) {
 	}
 }
 
+func TestRunsync_RequestErrorMapsTo400(t *testing.T) {
+	p := &stubProvider{
+		runFn: func([]byte) ([]byte, error) {
+			return nil, &RequestError{errors.New("no route for model")}
+		},
+	}
+	srv := newTestServer(t, p)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/runsync", "application/json",
+		strings.NewReader(`{"input":{"model":"x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
 func TestRunsync_GenericErrorMapsTo500(t *testing.T) {
 	p := &stubProvider{
 		runFn: func([]byte) ([]byte, error) {
@@ -0,0 +1,492 @@
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tableKind is how a table came to exist, which decides how it may
+// be extended later (TOML forbids defining a table twice).
+type tableKind int
+
+const (
+	kindImplicit tableKind = iota // parent of a [header]; may be defined once later
+	kindHeader                    // defined by a [header] or [[header]]
+	kindDotted                    // created by a dotted key; only more dotted keys extend it
+	kindInline                    // an inline table; closed once written
+)
+
+// table and array are the parser's working forms; plain converts
+// them to map[string]any and []any.
+type table struct {
+	vals map[string]any
+	kind tableKind
+}
+
+type array struct {
+	items  []any
+	tables bool // built by [[header]]s, so a later one may append
+}
+
+func newTable(kind tableKind) *table { return &table{vals: map[string]any{}, kind: kind} }
+
+func (t *table) plain() map[string]any {
+	m := make(map[string]any, len(t.vals))
+	for k, v := range t.vals {
+		m[k] = plain(v)
+	}
+	return m
+}
+
+func plain(v any) any {
+	switch v := v.(type) {
+	case *table:
+		return v.plain()
+	case *array:
+		out := make([]any, len(v.items))
+		for i, item := range v.items {
+			out[i] = plain(item)
+		}
+		return out
+	}
+	return v
+}
+
+// freeze closes an inline table and everything dotted keys created
+// inside it.
+func (t *table) freeze() {
+	t.kind = kindInline
+	for _, v := range t.vals {
+		if sub, ok := v.(*table); ok {
+			sub.freeze()
+		}
+	}
+}
+
+type parser struct {
+	data []byte
+	pos  int
+	root *table
+	doc  *Document
+
+	cur     *table   // table the last header selected
+	curPath []string // its path; nil = root
+	inArray bool     // cur is (inside) an [[array]] element
+}
+
+// failAt aborts the parse with an error at byte offset off. Parse
+// recovers it.
+func (p *parser) failAt(off int, format string, args ...any) {
+	line, col := 1, 1
+	for _, r := range string(p.data[:off]) {
+		if r == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	panic(&Error{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) fail(format string, args ...any) { p.failAt(p.pos, format, args...) }
+
+func (p *parser) lineAt(off int) int { return 1 + bytes.Count(p.data[:off], []byte("\n")) }
+
+func (p *parser) eof() bool { return p.pos >= len(p.data) }
+
+// peek returns the next byte, or 0 at the end of input.
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool { return bytes.HasPrefix(p.data[p.pos:], []byte(s)) }
+
+// found describes the next character for "expected X, found Y".
+func (p *parser) found() string {
+	if p.eof() {
+		return "end of file"
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	switch r {
+	case '\n', '\r':
+		return "end of line"
+	}
+	return fmt.Sprintf("%q", r)
+}
+
+func (p *parser) expect(c byte, what string) {
+	if p.peek() != c {
+		p.fail("expected %s, found %s", what, p.found())
+	}
+	p.pos++
+}
+
+func (p *parser) skipWS() {
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipWSCommentNL skips whitespace, newlines and comments, as allowed
+// between array elements.
+func (p *parser) skipWSCommentNL() {
+	for {
+		p.skipWS()
+		switch {
+		case p.peek() == '#':
+			p.comment()
+		case p.peek() == '\n' || p.peek() == '\r':
+			p.newline()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) newline() {
+	switch {
+	case p.peek() == '\n':
+		p.pos++
+	case p.hasPrefix("\r\n"):
+		p.pos += 2
+	default:
+		p.fail("expected end of line, found %s", p.found())
+	}
+}
+
+// comment skips a # comment up to (not including) the newline.
+func (p *parser) comment() {
+	for p.pos++; !p.eof(); p.pos++ {
+		c := p.data[p.pos]
+		if c == '\n' || p.hasPrefix("\r\n") {
+			return
+		}
+		if isControl(c) {
+			p.fail("control character %U in comment", rune(c))
+		}
+	}
+}
+
+// isControl reports the characters TOML allows nowhere but as
+// escapes: C0 controls other than tab, and DEL.
+func isControl(c byte) bool { return c < 0x20 && c != '\t' || c == 0x7f }
+
+// endOfLine consumes optional whitespace and a comment, then the
+// newline (or end of input) that must end a key/value pair or header.
+func (p *parser) endOfLine() {
+	p.skipWS()
+	if p.peek() == '#' {
+		p.comment()
+	}
+	if p.eof() {
+		return
+	}
+	if p.peek() != '\n' && !p.hasPrefix("\r\n") {
+		p.fail("expected end of line, found %s", p.found())
+	}
+	p.newline()
+}
+
+func (p *parser) parse() {
+	for {
+		p.skipWS()
+		switch {
+		case p.eof():
+			return
+		case p.peek() == '#':
+			p.comment()
+			p.endOfLine()
+		case p.peek() == '\n' || p.peek() == '\r':
+			p.newline()
+		case p.peek() == '[':
+			p.header()
+		default:
+			p.keyValue()
+			p.endOfLine()
+		}
+	}
+}
+
+// header parses a [table] or [[array]] line and makes it current.
+func (p *parser) header() {
+	start := p.pos
+	isArray := p.hasPrefix("[[")
+	p.pos++
+	if isArray {
+		p.pos++
+	}
+	p.skipWS()
+	path := p.key()
+	p.skipWS()
+	if isArray {
+		if !p.hasPrefix("]]") {
+			p.fail("expected ]] to close the array of tables header, found %s", p.found())
+		}
+		p.pos += 2
+	} else {
+		p.expect(']', "] to close the table header")
+	}
+	p.endOfLine()
+	p.openTable(start, path, isArray)
+	p.doc.Tables = append(p.doc.Tables, Table{Path: path, Line: p.lineAt(start), Array: isArray, End: p.pos})
+}
+
+// openTable finds or creates the table a header names.
+func (p *parser) openTable(off int, path []string, isArray bool) {
+	t := p.root
+	p.inArray = false
+	for i, k := range path[:len(path)-1] {
+		switch next := t.vals[k].(type) {
+		case nil:
+			sub := newTable(kindImplicit)
+			t.vals[k] = sub
+			t = sub
+		case *table:
+			if next.kind == kindInline {
+				p.failAt(off, "can't add to inline table %s", dotted(path[:i+1]))
+			}
+			t = next
+		case *array:
+			if !next.tables {
+				p.failAt(off, "%s is an array, not a table", dotted(path[:i+1]))
+			}
+			t = next.items[len(next.items)-1].(*table)
+			p.inArray = true
+		default:
+			p.failAt(off, "%s is already a %s, not a table", dotted(path[:i+1]), TypeName(next))
+		}
+	}
+	last := path[len(path)-1]
+	existing := t.vals[last]
+	if isArray {
+		arr, ok := existing.(*array)
+		switch {
+		case existing == nil:
+			arr = &array{tables: true}
+			t.vals[last] = arr
+		case !ok:
+			p.failAt(off, "%s is already a %s, not an array of tables", dotted(path), TypeName(existing))
+		case !arr.tables:
+			p.failAt(off, "can't append to static array %s", dotted(path))
+		}
+		p.cur = newTable(kindHeader)
+		arr.items = append(arr.items, p.cur)
+		p.inArray = true
+	} else {
+		sub, ok := existing.(*table)
+		switch {
+		case existing == nil:
+			sub = newTable(kindHeader)
+			t.vals[last] = sub
+		case !ok:
+			p.failAt(off, "%s is already a %s, not a table", dotted(path), TypeName(existing))
+		case sub.kind != kindImplicit:
+			p.failAt(off, "table %s is already defined", dotted(path))
+		}
+		sub.kind = kindHeader
+		p.cur = sub
+	}
+	p.curPath = path
+}
+
+// keyValue parses `key = value` into the current table.
+func (p *parser) keyValue() {
+	start := p.pos
+	path := p.key()
+	p.skipWS()
+	p.expect('=', "= after the key")
+	p.skipWS()
+	valStart := p.pos
+	v, members := p.value()
+	p.set(p.cur, path, v, start)
+
+	full := append(append([]string(nil), p.curPath...), path...)
+	p.record(Key{Path: full, Table: p.curPath, Value: v, Line: p.lineAt(start), Start: valStart, End: p.pos, InArray: p.inArray})
+	for _, m := range members {
+		m.Path = append(append([]string(nil), full...), m.Path...)
+		m.Table, m.InArray = p.curPath, p.inArray
+		p.record(m)
+	}
+}
+
+func (p *parser) record(k Key) { p.doc.Keys = append(p.doc.Keys, k) }
+
+// set assigns v at the dotted key path under t, creating the tables
+// dotted keys imply.
+func (p *parser) set(t *table, path []string, v any, off int) {
+	for i, k := range path[:len(path)-1] {
+		switch next := t.vals[k].(type) {
+		case nil:
+			sub := newTable(kindDotted)
+			t.vals[k] = sub
+			t = sub
+		case *table:
+			if next.kind != kindDotted {
+				p.failAt(off, "can't add to table %s with a dotted key: it is already defined", dotted(path[:i+1]))
+			}
+			t = next
+		default:
+			p.failAt(off, "%s is already a %s, not a table", dotted(path[:i+1]), TypeName(next))
+		}
+	}
+	last := path[len(path)-1]
+	if _, ok := t.vals[last]; ok {
+		p.failAt(off, "duplicate key %s", dotted(path))
+	}
+	t.vals[last] = v
+}
+
+// key parses a possibly dotted key into its parts.
+func (p *parser) key() []string {
+	path := []string{p.simpleKey()}
+	for {
+		save := p.pos
+		p.skipWS()
+		if p.peek() != '.' {
+			p.pos = save
+			return path
+		}
+		p.pos++
+		p.skipWS()
+		path = append(path, p.simpleKey())
+	}
+}
+
+func (p *parser) simpleKey() string {
+	switch p.peek() {
+	case '"':
+		if p.hasPrefix(`"""`) {
+			p.fail("a key can't be a multi-line string")
+		}
+		return p.basicString()
+	case '\'':
+		if p.hasPrefix(`'''`) {
+			p.fail("a key can't be a multi-line string")
+		}
+		return p.literalString()
+	}
+	start := p.pos
+	for !p.eof() && isBare(p.data[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.fail("expected a key, found %s", p.found())
+	}
+	return string(p.data[start:p.pos])
+}
+
+func isBare(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value parses one value. For an inline table it also returns the
+// members' key records, with paths relative to the table.
+func (p *parser) value() (any, []Key) {
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		return p.multilineString('"'), nil
+	case c == '"':
+		return p.basicString(), nil
+	case p.hasPrefix(`'''`):
+		return p.multilineString('\''), nil
+	case c == '\'':
+		return p.literalString(), nil
+	case c == '[':
+		return p.array(), nil
+	case c == '{':
+		return p.inlineTable()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	case c >= '0' && c <= '9' || c == '+' || c == '-' || c == 'i' || c == 'n':
+		return p.numberOrDate(), nil
+	}
+	p.fail("expected a value, found %s", p.found())
+	return nil, nil
+}
+
+func (p *parser) array() *array {
+	p.pos++ // [
+	arr := &array{items: []any{}}
+	for {
+		p.skipWSCommentNL()
+		if p.peek() == ']' {
+			p.pos++
+			return arr
+		}
+		v, _ := p.value()
+		arr.items = append(arr.items, v)
+		p.skipWSCommentNL()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return arr
+		default:
+			p.fail("expected , or ] in array, found %s", p.found())
+		}
+	}
+}
+
+func (p *parser) inlineTable() (*table, []Key) {
+	p.pos++ // {
+	t := newTable(kindInline)
+	var members []Key
+	p.skipWS()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		start := p.pos
+		path := p.key()
+		p.skipWS()
+		p.expect('=', "= after the key")
+		p.skipWS()
+		valStart := p.pos
+		v, sub := p.value()
+		p.set(t, path, v, start)
+		members = append(members, Key{Path: path, Value: v, Line: p.lineAt(start), Start: valStart, End: p.pos, Inline: true})
+		for _, m := range sub {
+			m.Path = append(append([]string(nil), path...), m.Path...)
+			members = append(members, m)
+		}
+		p.skipWS()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.skipWS()
+			if p.peek() == '}' {
+				p.fail("trailing comma in inline table")
+			}
+		case '}':
+			p.pos++
+			t.freeze()
+			return t, members
+		default:
+			p.fail("expected , or } in inline table, found %s", p.found())
+		}
+	}
+}
+
+// dotted renders a key path for messages, quoting parts that aren't
+// bare keys.
+func dotted(path []string) string {
+	parts := make([]string, len(path))
+	for i, k := range path {
+		parts[i] = k
+		if k == "" || strings.IndexFunc(k, func(r rune) bool { return r > 0x7f || !isBare(byte(r)) }) >= 0 {
+			parts[i] = fmt.Sprintf("%q", k)
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,142 @@
+// Package toml is a TOML v1.0 parser, written in-house so iosuite
+// stays free of third-party dependencies. It decodes into plain Go
+// values and records where every key and value sits in the source,
+// which is what lets `iosuite config set` rewrite one value in place
+// without disturbing comments or layout.
+//
+// Decoded types:
+//
+//	string, int64, float64, bool
+//	time.Time                            offset date-time
+//	LocalDateTime, LocalDate, LocalTime  date-times without an offset
+//	[]any                                arrays and arrays of tables
+//	map[string]any                       tables and inline tables
+package toml
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Document is a parsed TOML file.
+type Document struct {
+	Root map[string]any
+	// Keys is every key/value pair in source order, including the
+	// members of inline tables. Pairs in tables inside an inline array
+	// are not listed; pairs under an [[array]] header are, with InArray
+	// set, and their Path doesn't say which element they belong to.
+	Keys []Key
+	// Tables is every [table] and [[array]] header in source order.
+	Tables []Table
+}
+
+// Key is where one key/value pair sits in the source.
+type Key struct {
+	Path  []string // from the root, e.g. ["runpod", "routes", "ffmpeg"]
+	Table []string // the [header] it sits under; nil = before any header
+	Value any
+	Line  int // 1-based line of the key
+	// Start and End are the value's byte offsets in the input, so
+	// input[Start:End] is the value as written.
+	Start, End int
+	Inline     bool // a member of an inline table
+	InArray    bool // under an [[array]] header
+}
+
+// Table is one [table] or [[array]] header.
+type Table struct {
+	Path  []string
+	Line  int
+	Array bool // [[array]]
+	// End is the byte offset just past the header line's newline.
+	End int
+}
+
+// Error is a parse error, positioned at a 1-based line and column
+// (counted in characters, not bytes).
+type Error struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// LocalDateTime, LocalDate and LocalTime are TOML's date-times with
+// no offset. They hold the value in UTC; only the parts the type
+// names mean anything.
+type (
+	LocalDateTime struct{ time.Time }
+	LocalDate     struct{ time.Time }
+	LocalTime     struct{ time.Time }
+)
+
+func (d LocalDateTime) String() string { return d.Format("2006-01-02T15:04:05.999999999") }
+func (d LocalDate) String() string     { return d.Format("2006-01-02") }
+func (t LocalTime) String() string     { return t.Format("15:04:05.999999999") }
+
+// Parse parses a TOML v1.0 document.
+func Parse(data []byte) (doc *Document, err error) {
+	p := &parser{data: data, root: newTable(kindHeader), doc: &Document{}}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	p.checkEncoding()
+	p.cur = p.root
+	p.parse()
+	p.doc.Root = p.root.plain()
+	for i := range p.doc.Keys {
+		p.doc.Keys[i].Value = plain(p.doc.Keys[i].Value)
+	}
+	return p.doc, nil
+}
+
+// checkEncoding rejects input that isn't UTF-8 and skips a leading
+// byte order mark.
+func (p *parser) checkEncoding() {
+	for off := 0; off < len(p.data); {
+		r, n := utf8.DecodeRune(p.data[off:])
+		if r == utf8.RuneError && n <= 1 {
+			p.failAt(off, "invalid UTF-8")
+		}
+		off += n
+	}
+	if len(p.data) >= 3 && string(p.data[:3]) == "\xEF\xBB\xBF" {
+		p.pos = 3
+	}
+}
+
+// TypeName names v's TOML type ("string", "integer", "table", …).
+func TypeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "offset date-time"
+	case LocalDateTime:
+		return "local date-time"
+	case LocalDate:
+		return "local date"
+	case LocalTime:
+		return "local time"
+	case []any, *array:
+		return "array"
+	case map[string]any, *table:
+		return "table"
+	}
+	return fmt.Sprintf("%T", v)
+}
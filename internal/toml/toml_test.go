@@ -0,0 +1,280 @@
+package toml
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// corpus returns the .toml files under testdata/<dir>.
+func corpus(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(filepath.Join("testdata", dir), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".toml") {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil || len(files) == 0 {
+		t.Fatalf("read corpus: %v (%d files)", err, len(files))
+	}
+	return files
+}
+
+func TestCorpus_Valid(t *testing.T) {
+	for _, path := range corpus(t, "valid") {
+		name := strings.TrimSuffix(strings.TrimPrefix(path, filepath.Join("testdata", "valid")+string(filepath.Separator)), ".toml")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			raw, err := os.ReadFile(strings.TrimSuffix(path, ".toml") + ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want any
+			if err := json.Unmarshal(raw, &want); err != nil {
+				t.Fatal(err)
+			}
+			if got := tagged(doc.Root); !sameTagged(want, got) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, raw)
+			}
+		})
+	}
+}
+
+func TestCorpus_Invalid(t *testing.T) {
+	for _, path := range corpus(t, "invalid") {
+		name := strings.TrimSuffix(strings.TrimPrefix(path, filepath.Join("testdata", "invalid")+string(filepath.Separator)), ".toml")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(data)
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse = %v, want a *toml.Error", err)
+			}
+			if perr.Line < 1 || perr.Column < 1 {
+				t.Errorf("error not positioned: %+v", perr)
+			}
+		})
+	}
+}
+
+// tagged renders a decoded value in toml-test's JSON encoding: every
+// scalar becomes {"type": …, "value": "…"}.
+func tagged(v any) any {
+	leaf := func(typ, val string) any { return map[string]any{"type": typ, "value": val} }
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[k] = tagged(x)
+		}
+		return m
+	case []any:
+		out := make([]any, len(v))
+		for i, x := range v {
+			out[i] = tagged(x)
+		}
+		return out
+	case string:
+		return leaf("string", v)
+	case int64:
+		return leaf("integer", strconv.FormatInt(v, 10))
+	case float64:
+		return leaf("float", strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		return leaf("bool", strconv.FormatBool(v))
+	case time.Time:
+		return leaf("datetime", v.Format(time.RFC3339Nano))
+	case LocalDateTime:
+		return leaf("datetime-local", v.String())
+	case LocalDate:
+		return leaf("date-local", v.String())
+	case LocalTime:
+		return leaf("time-local", v.String())
+	}
+	panic("untagged value " + TypeName(v))
+}
+
+// sameTagged compares tagged values, numbers and date-times by value
+// rather than spelling.
+func sameTagged(want, got any) bool {
+	switch w := want.(type) {
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(w) != len(g) {
+			return false
+		}
+		for i := range w {
+			if !sameTagged(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		typ, isLeaf := w["type"].(string)
+		val, _ := w["value"].(string)
+		if isLeaf && len(w) == 2 {
+			return g["type"] == typ && sameScalar(typ, val, g["value"].(string))
+		}
+		if len(w) != len(g) {
+			return false
+		}
+		for k := range w {
+			if !sameTagged(w[k], g[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sameScalar(typ, want, got string) bool {
+	switch typ {
+	case "float":
+		w, _ := parseNumber(strings.ReplaceAll(strings.ReplaceAll(want, "+", ""), "Inf", "inf"))
+		g, _ := strconv.ParseFloat(got, 64)
+		wf, ok := w.(float64)
+		if !ok {
+			if wi, isInt := w.(int64); isInt {
+				wf = float64(wi)
+			}
+		}
+		return wf == g || math.IsNaN(wf) && math.IsNaN(g)
+	case "datetime", "datetime-local", "date-local", "time-local":
+		w, _ := parseDatetime(want)
+		g, _ := parseDatetime(got)
+		return reflect.DeepEqual(w, g) || isTime(w) && isTime(g) && w.(time.Time).Equal(g.(time.Time))
+	}
+	return want == got
+}
+
+func isTime(v any) bool { _, ok := v.(time.Time); return ok }
+
+func TestParse_Values(t *testing.T) {
+	doc, err := Parse([]byte(`
+int = +1_000
+hex = 0xDEAD_beef
+oct = 0o755
+bin = 0b1101
+flt = -3.5e-2
+big = 1e1_0
+inf = -inf
+odt = 1979-05-27 07:32:00.123-07:00
+ldt = 1979-05-27T07:32:00
+ld  = 1979-05-27
+lt  = 07:32:00.5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := doc.Root
+	for key, want := range map[string]any{
+		"int": int64(1000), "hex": int64(0xdeadbeef), "oct": int64(0o755), "bin": int64(13),
+		"flt": -0.035, "big": 1e10, "inf": math.Inf(-1),
+	} {
+		if r[key] != want {
+			t.Errorf("%s = %#v, want %#v", key, r[key], want)
+		}
+	}
+	if got := r["odt"].(time.Time); !got.Equal(time.Date(1979, 5, 27, 14, 32, 0, 123e6, time.UTC)) {
+		t.Errorf("odt = %v", got)
+	}
+	for key, want := range map[string]string{"ldt": "1979-05-27T07:32:00", "ld": "1979-05-27", "lt": "07:32:00.5"} {
+		if got := r[key].(interface{ String() string }).String(); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestParse_ErrorPosition(t *testing.T) {
+	for _, tc := range []struct {
+		in        string
+		line, col int
+		msg       string
+	}{
+		{"a = 1\nb = \n", 2, 5, "expected a value"},
+		{"[a]\nx = 1\n[a]\n", 3, 1, "table a is already defined"},
+		{"x = 1\nx = 2", 2, 1, "duplicate key x"},
+		{`s = "héllo` + "\n", 1, 11, "newline in single-line string"},
+		{"n = 01\n", 1, 5, "leading zero"},
+		{"d = 2024-02-30\n", 1, 5, "invalid date-time"},
+		{"[t]\nk = 'v' x\n", 2, 9, `expected end of line, found 'x'`},
+	} {
+		_, err := Parse([]byte(tc.in))
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: err = %v", tc.in, err)
+			continue
+		}
+		if perr.Line != tc.line || perr.Column != tc.col || !strings.Contains(perr.Msg, tc.msg) {
+			t.Errorf("%q: got %v, want line %d, column %d: …%s…", tc.in, perr, tc.line, tc.col, tc.msg)
+		}
+	}
+}
+
+func TestParse_KeyPositions(t *testing.T) {
+	src := `top = "a # not a comment"   # comment
+[runpod]
+routes.ffmpeg = 'x'
+inline = { k = 1, sub.j = "y" }
+
+[[jobs]]
+name = "n"
+`
+	doc, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type pos struct {
+		path, table string
+		line        int
+		raw         string
+		inline, arr bool
+	}
+	var got []pos
+	for _, k := range doc.Keys {
+		got = append(got, pos{strings.Join(k.Path, "."), strings.Join(k.Table, "."), k.Line, src[k.Start:k.End], k.Inline, k.InArray})
+	}
+	want := []pos{
+		{"top", "", 1, `"a # not a comment"`, false, false},
+		{"runpod.routes.ffmpeg", "runpod", 3, `'x'`, false, false},
+		{"runpod.inline", "runpod", 4, `{ k = 1, sub.j = "y" }`, false, false},
+		{"runpod.inline.k", "runpod", 4, `1`, true, false},
+		{"runpod.inline.sub.j", "runpod", 4, `"y"`, true, false},
+		{"jobs.name", "jobs", 7, `"n"`, false, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keys\n got %+v\nwant %+v", got, want)
+	}
+	if doc.Root["top"] != "a # not a comment" {
+		t.Errorf("top = %q", doc.Root["top"])
+	}
+	if tb := doc.Tables[1]; !tb.Array || tb.Line != 6 || src[tb.End:] != "name = \"n\"\n" {
+		t.Errorf("jobs header = %+v", tb)
+	}
+}
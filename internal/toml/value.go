@@ -0,0 +1,400 @@
+package toml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// basicString parses a "double-quoted" string.
+func (p *parser) basicString() string {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() {
+			p.failAt(start, "unterminated string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String()
+		case c == '\\':
+			p.escape(&b)
+		case c == '\n' || c == '\r':
+			p.fail("newline in single-line string (use \"\"\" for multi-line)")
+		case isControl(c):
+			p.fail("control character %U in string", rune(c))
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// literalString parses a 'single-quoted' string: no escapes.
+func (p *parser) literalString() string {
+	start := p.pos
+	p.pos++
+	for i := p.pos; ; i++ {
+		if i >= len(p.data) {
+			p.failAt(start, "unterminated string")
+		}
+		c := p.data[i]
+		switch {
+		case c == '\'':
+			s := string(p.data[p.pos:i])
+			p.pos = i + 1
+			return s
+		case c == '\n' || c == '\r':
+			p.failAt(i, "newline in single-line string (use ''' for multi-line)")
+		case isControl(c):
+			p.failAt(i, "control character %U in string", rune(c))
+		}
+	}
+}
+
+// multilineString parses a """basic""" (quote '"') or ”'literal”'
+// (quote '\”) multi-line string. A newline straight after the
+// opening delimiter is dropped; up to two quotes may sit against the
+// closing one.
+func (p *parser) multilineString(quote byte) string {
+	start := p.pos
+	p.pos += 3
+	if p.peek() == '\n' {
+		p.pos++
+	} else if p.hasPrefix("\r\n") {
+		p.pos += 2
+	}
+	var b strings.Builder
+	for {
+		if p.eof() {
+			p.failAt(start, "unterminated multi-line string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			n := 0
+			for p.pos+n < len(p.data) && p.data[p.pos+n] == quote && n < 6 {
+				n++
+			}
+			if n >= 3 {
+				if n > 5 {
+					p.failAt(p.pos+5, "too many quotes at the end of a multi-line string")
+				}
+				b.WriteString(strings.Repeat(string(quote), n-3))
+				p.pos += n
+				return b.String()
+			}
+			b.WriteString(strings.Repeat(string(quote), n))
+			p.pos += n
+		case c == '\\' && quote == '"':
+			if !p.lineEndingBackslash() {
+				p.escape(&b)
+			}
+		case c == '\n':
+			b.WriteByte('\n')
+			p.pos++
+		case p.hasPrefix("\r\n"):
+			b.WriteString("\r\n")
+			p.pos += 2
+		case isControl(c):
+			p.fail("control character %U in string", rune(c))
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// lineEndingBackslash skips a `\` that ends a line, with the
+// whitespace and newlines after it, reporting whether there was one.
+func (p *parser) lineEndingBackslash() bool {
+	i := p.pos + 1
+	for i < len(p.data) && (p.data[i] == ' ' || p.data[i] == '\t') {
+		i++
+	}
+	if i >= len(p.data) || p.data[i] != '\n' && !strings.HasPrefix(string(p.data[i:min(i+2, len(p.data))]), "\r\n") {
+		return false
+	}
+	for i < len(p.data) {
+		switch {
+		case p.data[i] == ' ' || p.data[i] == '\t' || p.data[i] == '\n':
+			i++
+		case p.data[i] == '\r' && i+1 < len(p.data) && p.data[i+1] == '\n':
+			i += 2
+		default:
+			p.pos = i
+			return true
+		}
+	}
+	p.pos = i
+	return true
+}
+
+// escape decodes the backslash escape at p.pos into b.
+func (p *parser) escape(b *strings.Builder) {
+	start := p.pos
+	p.pos++
+	if p.eof() {
+		p.failAt(start, "unterminated string")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		hex := string(p.data[p.pos:min(p.pos+n, len(p.data))])
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != n || err != nil {
+			p.failAt(start, "invalid escape \\%c%s: want %d hex digits", c, hex, n)
+		}
+		if !utf8.ValidRune(rune(v)) {
+			p.failAt(start, "invalid escape \\%c%s: not a Unicode scalar value", c, hex)
+		}
+		b.WriteRune(rune(v))
+		p.pos += n
+	default:
+		r, _ := utf8.DecodeRune(p.data[p.pos-1:])
+		p.failAt(start, "invalid escape \\%c", r)
+	}
+}
+
+// numberOrDate parses an integer, float or date-time.
+func (p *parser) numberOrDate() any {
+	start := p.pos
+	for !p.eof() && isNumberChar(p.data[p.pos]) {
+		p.pos++
+	}
+	// A date and time may be separated by a space instead of T.
+	if tok := p.data[start:p.pos]; len(tok) == 10 && isDate(string(tok)) &&
+		p.pos+3 < len(p.data) && p.data[p.pos] == ' ' && isDigit(p.data[p.pos+1]) && isDigit(p.data[p.pos+2]) && p.data[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && isNumberChar(p.data[p.pos]) {
+			p.pos++
+		}
+	}
+	tok := string(p.data[start:p.pos])
+	if strings.Contains(tok, ":") || len(tok) >= 10 && isDate(tok[:10]) {
+		v, ok := parseDatetime(tok)
+		if !ok {
+			p.failAt(start, "invalid date-time %q", tok)
+		}
+		return v
+	}
+	v, msg := parseNumber(tok)
+	if msg != "" {
+		p.failAt(start, "invalid number %q: %s", tok, msg)
+	}
+	return v
+}
+
+func isNumberChar(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '+' || c == '-' || c == '.' || c == ':'
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isDate(s string) bool {
+	return len(s) == 10 && s[4] == '-' && s[7] == '-' && allDigits(s[:4]) && allDigits(s[5:7]) && allDigits(s[8:])
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseNumber parses an integer or float token, returning a reason
+// when it's malformed.
+func parseNumber(s string) (any, string) {
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(1), ""
+	case "-inf":
+		return math.Inf(-1), ""
+	case "nan", "+nan", "-nan":
+		return math.NaN(), ""
+	}
+	if len(s) > 2 && s[0] == '0' {
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[s[1]]
+		if base != 0 {
+			digits := s[2:]
+			if !validDigits(digits, base) {
+				return nil, "bad digits"
+			}
+			v, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+			if err != nil {
+				return nil, "out of range"
+			}
+			return v, ""
+		}
+	}
+	body := s
+	if s[0] == '+' || s[0] == '-' {
+		body = s[1:]
+	}
+	mant, exp, hasExp := body, "", false
+	if i := strings.IndexAny(body, "eE"); i >= 0 {
+		mant, exp, hasExp = body[:i], body[i+1:], true
+	}
+	intPart, frac, hasFrac := strings.Cut(mant, ".")
+	if !validDigits(intPart, 10) {
+		return nil, "bad digits"
+	}
+	if len(intPart) > 1 && intPart[0] == '0' {
+		return nil, "leading zero"
+	}
+	if !hasFrac && !hasExp {
+		v, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64)
+		if err != nil {
+			return nil, "out of range"
+		}
+		return v, ""
+	}
+	if hasFrac && !validDigits(frac, 10) {
+		return nil, "bad fraction"
+	}
+	if hasExp {
+		if exp != "" && (exp[0] == '+' || exp[0] == '-') {
+			exp = exp[1:]
+		}
+		if !validDigits(exp, 10) {
+			return nil, "bad exponent"
+		}
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+	if err != nil {
+		return nil, "out of range"
+	}
+	return v, ""
+}
+
+// validDigits reports whether s is one or more digits in base, with
+// underscores only between digits.
+func validDigits(s string, base int) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' {
+			if i == 0 || i == len(s)-1 || s[i+1] == '_' {
+				return false
+			}
+			continue
+		}
+		if d := digitVal(c); d < 0 || d >= base {
+			return false
+		}
+	}
+	return true
+}
+
+func digitVal(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// parseDatetime parses the four TOML date-time forms.
+func parseDatetime(s string) (any, bool) {
+	var year, month, day int
+	hasDate := len(s) >= 10 && isDate(s[:10])
+	clock := s
+	if hasDate {
+		year, month, day = atoi(s[:4]), atoi(s[5:7]), atoi(s[8:10])
+		if month < 1 || month > 12 || day < 1 || day > daysIn(time.Month(month), year) {
+			return nil, false
+		}
+		if len(s) == 10 {
+			return LocalDate{time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)}, true
+		}
+		if c := s[10]; c != 'T' && c != 't' && c != ' ' {
+			return nil, false
+		}
+		clock = s[11:]
+	}
+	if len(clock) < 8 || clock[2] != ':' || clock[5] != ':' || !allDigits(clock[:2]) || !allDigits(clock[3:5]) || !allDigits(clock[6:8]) {
+		return nil, false
+	}
+	hour, minute, sec := atoi(clock[:2]), atoi(clock[3:5]), atoi(clock[6:8])
+	if hour > 23 || minute > 59 || sec > 59 {
+		return nil, false
+	}
+	rest, nsec := clock[8:], 0
+	if frac, ok := strings.CutPrefix(rest, "."); ok {
+		n := 0
+		for n < len(frac) && isDigit(frac[n]) {
+			n++
+		}
+		if n == 0 {
+			return nil, false
+		}
+		digits := (frac[:n] + "000000000")[:9]
+		nsec, rest = atoi(digits), frac[n:]
+	}
+	if !hasDate {
+		if rest != "" {
+			return nil, false
+		}
+		return LocalTime{time.Date(0, 1, 1, hour, minute, sec, nsec, time.UTC)}, true
+	}
+	if rest == "" {
+		return LocalDateTime{time.Date(year, time.Month(month), day, hour, minute, sec, nsec, time.UTC)}, true
+	}
+	loc := time.UTC
+	if rest != "Z" && rest != "z" {
+		if len(rest) != 6 || rest[0] != '+' && rest[0] != '-' || rest[3] != ':' || !allDigits(rest[1:3]) || !allDigits(rest[4:]) {
+			return nil, false
+		}
+		oh, om := atoi(rest[1:3]), atoi(rest[4:])
+		if oh > 23 || om > 59 {
+			return nil, false
+		}
+		off := oh*3600 + om*60
+		if rest[0] == '-' {
+			off = -off
+		}
+		loc = time.FixedZone("", off)
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, sec, nsec, loc), true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}